@@ -80,7 +80,7 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	// Convert the app name to corresponding GitOpsDeployment name, ensuring that the GitOpsDeployment name fits within 64 chars
+	// Convert the app name to corresponding GitOpsDeployment name, ensuring that the GitOpsDeployment name is valid, and fits within 63 chars
 	gitopsDeplName := sanitizeAppNameWithSuffix(asApplication.Name, deploymentSuffix)
 
 	gitopsDeployment := &gitopsdeploymentv1alpha1.GitOpsDeployment{
@@ -297,7 +297,7 @@ func generateNewGitOpsDeploymentFromApplication(asApplication applicationv1alpha
 		return gitopsdeploymentv1alpha1.GitOpsDeployment{}, err
 	}
 
-	gitopsDeplName := sanitizeAppNameWithSuffix(asApplication.Name, "-deployment")
+	gitopsDeplName := sanitizeAppNameWithSuffix(asApplication.Name, deploymentSuffix)
 
 	res := gitopsdeploymentv1alpha1.GitOpsDeployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -329,22 +329,24 @@ func generateNewGitOpsDeploymentFromApplication(asApplication applicationv1alpha
 	return res, nil
 }
 
-// Ensure that the name of the GitOpsDeployment is always <= 64 characters
+// maxApplicationGitOpsDeploymentNameLength is the maximum length of the name of the GitOpsDeployment generated for an
+// Application.
+const maxApplicationGitOpsDeploymentNameLength = 63
+
+// Ensure that the name of the GitOpsDeployment is always a valid name of at most 63 characters
 func sanitizeAppNameWithSuffix(appName string, suffix string) string {
 
 	fullName := appName + suffix
 
-	if len(fullName) < 64 {
-		return fullName
+	if len(fullName) > maxApplicationGitOpsDeploymentNameLength {
+		// Names that are too long have always been replaced with a hash of the Application name: this name must not
+		// change, as otherwise the existing GitOpsDeployments of those Applications would be replaced.
+		hashValStr := fmt.Sprintf("%x", sha256.Sum256([]byte(appName)))
+		fullName = hashValStr[0:32] + suffix
 	}
 
-	sha256 := sha256.New()
-	sha256.Write(([]byte)(appName))
-	hashValBytes := (string)(sha256.Sum(nil))
-
-	hashValStr := fmt.Sprintf("%x", hashValBytes)
-
-	return hashValStr[0:32] + suffix
+	// Only if the name is (still) not valid, generate a new valid name
+	return sharedutil.GenerateValidResourceName(fullName, "", maxApplicationGitOpsDeploymentNameLength)
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -19,18 +19,27 @@ package appstudioredhatcom
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/go-logr/logr"
+
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 
 	appstudioshared "github.com/redhat-appstudio/managed-gitops/appstudio-shared/apis/appstudio.redhat.com/v1alpha1"
 	apibackend "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // ApplicationPromotionRunReconciler reconciles a ApplicationPromotionRun object
@@ -48,22 +57,13 @@ type ApplicationPromotionRunReconciler struct {
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.0/pkg/reconcile
-
 func (r *ApplicationPromotionRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
-
-	fmt.Println("ApplicationPromotionRun event: ", req)
-
-	return ctrl.Result{}, nil
-}
-
-// Proof-of-concept/pseudocode Reconcile. Comment out the above Reconcile, and rename this to Reconcile, when starting working on this.
-func (r *ApplicationPromotionRunReconciler) ReconcilePOC(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := log.FromContext(ctx)
+	log := log.FromContext(ctx).WithValues("name", req.Name, "namespace", req.Namespace)
+	defer log.V(sharedutil.LogLevel_Debug).Info("Application Promotion Run Reconcile() complete.")
 
 	promotionRun := &appstudioshared.ApplicationPromotionRun{}
 
-	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun); err != nil {
+	if err := r.Client.Get(ctx, req.NamespacedName, promotionRun); err != nil {
 		if apierr.IsNotFound(err) {
 			// Nothing more to do!
 			return ctrl.Result{}, nil
@@ -77,145 +77,540 @@ func (r *ApplicationPromotionRunReconciler) ReconcilePOC(ctx context.Context, re
 		return ctrl.Result{}, nil
 	}
 
+	if err := validatePromotionRunSpec(*promotionRun); err != nil {
+		// The spec of a PromotionRun is immutable, so an invalid PromotionRun will never be able to proceed.
+		log.Error(err, "PromotionRun has an invalid spec")
+		return ctrl.Result{}, r.completePromotionRun(ctx, promotionRun, appstudioshared.PromotionRunCompleteResult_Failure,
+			appstudioshared.PromotionRunReason_InvalidSpec, err, log)
+	}
+
 	if err := checkForExistingActivePromotions(ctx, *promotionRun, r.Client); err != nil {
-		// TODO: GITOPSRVCE-157 - Add error to error occurred conditions field
-		fmt.Println(err)
-		return ctrl.Result{}, nil
+		// Another PromotionRun is active for this Application: wait for it to complete before starting this one.
+		log.V(sharedutil.LogLevel_Debug).Info("Waiting for another PromotionRun to complete: " + err.Error())
+		promotionRun.Status.State = appstudioshared.PromotionRunState_Waiting
+
+		return ctrl.Result{RequeueAfter: promotionRunRequeueDelay},
+			r.setErrorOccurredCondition(ctx, promotionRun, appstudioshared.PromotionRunReason_ActivePromotionExists, err, log)
 	}
 
-	// If this is a automated promotion, ignore it for now
-	if promotionRun.Spec.AutomatedPromotion.InitialEnvironment != "" {
-		// TODO: GITOPSRVCE-157 - Add error to error occurred conditions field
-		fmt.Println("Note: Automated promotion not yet supported as of this writing.")
-		return ctrl.Result{}, nil
+	// The promotion starts, and thus its timeout begins, once no other PromotionRun is active for the Application
+	if promotionRun.Status.PromotionStartTime == nil {
+		now := metav1.Now()
+		promotionRun.Status.PromotionStartTime = &now
+
+		if err := r.Client.Status().Update(ctx, promotionRun); err != nil {
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun start time: %v", err)
+		}
+		sharedutil.LogAPIResourceChangeEvent(promotionRun.Namespace, promotionRun.Name, promotionRun, sharedutil.ResourceModified, log)
 	}
 
-	if promotionRun.Spec.ManualPromotion.TargetEnvironment == "" {
-		fmt.Println("Target environment has invalid value in " + promotionRun.Name)
-		// TODO: GITOPSRVCE-157 - Update Error Occurred status field to indicate the target environment value is invalid
-		return ctrl.Result{}, nil
+	// Fail the promotion if it has not completed within the timeout
+	remainingTime := remainingPromotionRunTime(*promotionRun)
+	if remainingTime <= 0 {
+		return ctrl.Result{}, r.timeoutPromotionRun(ctx, promotionRun, log)
 	}
 
-	// 1) Locate the binding that this PromotionRun is targetting
-	binding, err := locateTargetManualBinding(ctx, *promotionRun, r.Client)
-	if err != nil {
-		return ctrl.Result{}, nil
+	// 1) Verify that the Snapshot we are promoting exists, belongs to the Application we are promoting, and is valid
+	if reason, err := validatePromotionRunSnapshot(ctx, *promotionRun, r.Client); err != nil {
+		log.Error(err, "unable to validate the Snapshot referenced by the PromotionRun")
+
+		requeueAfter := promotionRunRequeueDelay
+		if requeueAfter > remainingTime {
+			requeueAfter = remainingTime
+		}
+		return ctrl.Result{RequeueAfter: requeueAfter}, r.setErrorOccurredCondition(ctx, promotionRun, reason, err, log)
 	}
 
 	if promotionRun.Status.State != appstudioshared.PromotionRunState_Active {
 		promotionRun.Status.State = appstudioshared.PromotionRunState_Active
+		updateErrorOccurredCondition(&promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred, "", nil)
 
-		if err := r.Client.Update(ctx, promotionRun); err != nil {
+		if err := r.Client.Status().Update(ctx, promotionRun); err != nil {
 			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun state: %v", err)
 		}
 		sharedutil.LogAPIResourceChangeEvent(promotionRun.Namespace, promotionRun.Name, promotionRun, sharedutil.ResourceModified, log)
+	}
+
+	res, err := r.reconcileActivePromotionRun(ctx, promotionRun, log)
 
-		// TODO: GITOPSRVCE-157 - log this action as debug
+	// Ensure we are requeued in time to detect the timeout
+	if err == nil && !res.Requeue && res.RequeueAfter > remainingTime {
+		res.RequeueAfter = remainingTime
 	}
 
-	// Verify: activebindings should not have a value which differs from the value specified in promotionrun.spec
-	if len(promotionRun.Status.ActiveBindings) > 0 {
-		for _, existingActiveBinding := range promotionRun.Status.ActiveBindings {
-			if existingActiveBinding != binding.Name {
+	return res, err
+}
+
+// reconcileActivePromotionRun promotes to the environment of the current step of an active PromotionRun, and moves on
+// to the next step (or completes the PromotionRun) once that is done.
+func (r *ApplicationPromotionRunReconciler) reconcileActivePromotionRun(ctx context.Context, promotionRun *appstudioshared.ApplicationPromotionRun,
+	log logr.Logger) (ctrl.Result, error) {
+
+	// 2) Determine which environment we are currently promoting to: this is always the environment of the last step.
+	// - For a manual promotion, there is only a single step: the target environment.
+	// - For an automated promotion, once a step succeeds, the next environment in the environment graph is promoted to.
+	if len(promotionRun.Status.EnvironmentStatus) == 0 {
+		initialEnvironment := promotionRun.Spec.ManualPromotion.TargetEnvironment
+		if promotionRun.Spec.AutomatedPromotion.InitialEnvironment != "" {
+			initialEnvironment = promotionRun.Spec.AutomatedPromotion.InitialEnvironment
+		}
+		return r.startEnvironmentStep(ctx, promotionRun, initialEnvironment, log)
+	}
 
-				fmt.Printf("The binding changed after the PromotionRun first start. "+
-					"The .spec fields of the PromotionRun are immutable, and should not be changed "+
-					"after being created. old-binding: %s, new-binding: %s\n", existingActiveBinding, binding.Name)
+	currentStep := promotionRun.Status.EnvironmentStatus[len(promotionRun.Status.EnvironmentStatus)-1]
 
-				// TODO: GITOPSRVCE-157 - Update error occurred status field
+	switch currentStep.Status {
+	case appstudioshared.ApplicationPromotionRunEnvironmentStatus_Failed:
+		// Halt the promotion on the first failure
+		err := fmt.Errorf("promotion to environment '%s' failed: %s", currentStep.EnvironmentName, currentStep.DisplayStatus)
+		return ctrl.Result{}, r.completePromotionRun(ctx, promotionRun, appstudioshared.PromotionRunCompleteResult_Failure,
+			appstudioshared.PromotionRunReason_PromotionFailed, err, log)
 
-				return ctrl.Result{}, nil
+	case appstudioshared.ApplicationPromotionRunEnvironmentStatus_Success:
+
+		nextEnvironment := ""
+		if promotionRun.Spec.AutomatedPromotion.InitialEnvironment != "" {
+			var err error
+			if nextEnvironment, err = nextAutomatedPromotionEnvironment(ctx, *promotionRun, r.Client); err != nil {
+				log.Error(err, "unable to determine the next environment of the automated promotion")
+				return ctrl.Result{RequeueAfter: promotionRunRequeueDelay},
+					r.setErrorOccurredCondition(ctx, promotionRun, appstudioshared.PromotionRunReason_EnvironmentNotFound, err, log)
 			}
 		}
+
+		if nextEnvironment == "" {
+			// There are no more environments to promote to, so the promotion is complete.
+			return ctrl.Result{}, r.completePromotionRun(ctx, promotionRun, appstudioshared.PromotionRunCompleteResult_Success, "", nil, log)
+		}
+
+		return r.startEnvironmentStep(ctx, promotionRun, nextEnvironment, log)
+	}
+
+	// 3) The current step is in progress: wait for the Binding of the environment to deploy the Snapshot, and be Synced/Healthy
+	return r.reconcileEnvironmentStep(ctx, promotionRun, currentStep.EnvironmentName, log)
+}
+
+// startEnvironmentStep begins promoting to a new environment: the Binding of the environment is located, and a new
+// (in progress) step is added to the EnvironmentStatus of the PromotionRun.
+func (r *ApplicationPromotionRunReconciler) startEnvironmentStep(ctx context.Context, promotionRun *appstudioshared.ApplicationPromotionRun,
+	environmentName string, log logr.Logger) (ctrl.Result, error) {
+
+	binding, err := locateTargetBinding(ctx, *promotionRun, environmentName, r.Client)
+	if err != nil {
+		log.Error(err, "unable to locate the Binding targeted by the PromotionRun")
+		return ctrl.Result{RequeueAfter: promotionRunRequeueDelay},
+			r.setErrorOccurredCondition(ctx, promotionRun, appstudioshared.PromotionRunReason_BindingNotFound, err, log)
+	}
+
+	promotionRun.Status.ActiveBindings = []string{binding.Name}
+	setEnvironmentStatus(promotionRun, environmentName, appstudioshared.ApplicationPromotionRunEnvironmentStatus_InProgress,
+		"Waiting for the Binding to create the GitOpsDeployments of the Snapshot")
+	updateErrorOccurredCondition(&promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred, "", nil)
+
+	if err := r.Client.Status().Update(ctx, promotionRun); err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun active binding: %v", err)
+	}
+	sharedutil.LogAPIResourceChangeEvent(promotionRun.Namespace, promotionRun.Name, promotionRun, sharedutil.ResourceModified, log)
+
+	return r.reconcileEnvironmentStep(ctx, promotionRun, environmentName, log)
+}
+
+// reconcileEnvironmentStep ensures that the Binding of the environment targets the promoted Snapshot, and then waits for
+// the GitOpsDeployments of the Binding to deploy a GitOps repository commit of the Snapshot, and to be Synced/Healthy.
+// Once they are, the step is marked as successful.
+func (r *ApplicationPromotionRunReconciler) reconcileEnvironmentStep(ctx context.Context, promotionRun *appstudioshared.ApplicationPromotionRun,
+	environmentName string, log logr.Logger) (ctrl.Result, error) {
+
+	binding, err := locateTargetBinding(ctx, *promotionRun, environmentName, r.Client)
+	if err != nil {
+		log.Error(err, "unable to locate the Binding targeted by the PromotionRun")
+		return ctrl.Result{RequeueAfter: promotionRunRequeueDelay},
+			r.setErrorOccurredCondition(ctx, promotionRun, appstudioshared.PromotionRunReason_BindingNotFound, err, log)
 	}
 
-	// TODO: GITOPSRVCE-157 - Verify that the snapshot refered in binding.spec.snapshot actually exists, if not, throw error
+	// Verify: activebindings should not have a value which differs from the binding of the current step
+	for _, existingActiveBinding := range promotionRun.Status.ActiveBindings {
+		if existingActiveBinding != binding.Name {
+
+			err := fmt.Errorf("the binding changed after the PromotionRun first started. "+
+				"The .spec fields of the PromotionRun are immutable, and should not be changed "+
+				"after being created. old-binding: %s, new-binding: %s", existingActiveBinding, binding.Name)
+
+			log.Error(err, "PromotionRun binding changed")
+			return ctrl.Result{}, r.setErrorOccurredCondition(ctx, promotionRun, appstudioshared.PromotionRunReason_BindingChanged, err, log)
+		}
+	}
 
-	// 2) Set the Binding to target the expected snapshot, if not already done
-	if binding.Spec.Snapshot != promotionRun.Spec.Snapshot || len(promotionRun.Status.ActiveBindings) == 0 {
+	// Set the Binding to target the expected snapshot, if not already done
+	if binding.Spec.Snapshot != promotionRun.Spec.Snapshot {
 		binding.Spec.Snapshot = promotionRun.Spec.Snapshot
-		if err := r.Client.Update(ctx, promotionRun); err != nil {
+		if err := r.Client.Update(ctx, &binding); err != nil {
 			return ctrl.Result{}, fmt.Errorf("unable to update Binding '%s' snapshot: %v", binding.Name, err)
 		}
-		sharedutil.LogAPIResourceChangeEvent(promotionRun.Namespace, promotionRun.Name, promotionRun, sharedutil.ResourceModified, log)
+		sharedutil.LogAPIResourceChangeEvent(binding.Namespace, binding.Name, binding, sharedutil.ResourceModified, log)
+
+		return ctrl.Result{RequeueAfter: promotionRunRequeueDelay}, nil
+	}
+
+	// Wait for the environment binding to create all of the expected GitOpsDeployments
+	if len(binding.Status.GitOpsDeployments) != len(binding.Spec.Components) {
+		return ctrl.Result{RequeueAfter: promotionRunRequeueDelay}, r.updateEnvironmentStatus(ctx, promotionRun, environmentName,
+			appstudioshared.ApplicationPromotionRunEnvironmentStatus_InProgress,
+			"Waiting for the Binding to create the GitOpsDeployments of the Snapshot", log)
+	}
 
-		// TODO: GITOPSRVCE-157 - log this action
+	// Wait for all the GitOpsDeployments of the binding to have the expected state
+	waitingGitOpsDeployments, degradedGitOpsDeployments, err := checkBindingGitOpsDeployments(ctx, binding, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if len(degradedGitOpsDeployments) > 0 {
+		// The promotion to this environment failed: the next reconcile will complete the PromotionRun.
+		return ctrl.Result{Requeue: true}, r.updateEnvironmentStatus(ctx, promotionRun, environmentName,
+			appstudioshared.ApplicationPromotionRunEnvironmentStatus_Failed,
+			"The following GitOpsDeployments are Degraded: "+strings.Join(degradedGitOpsDeployments, ", "), log)
+	}
+
+	if len(waitingGitOpsDeployments) > 0 {
+		log.V(sharedutil.LogLevel_Debug).Info("Waiting for GitOpsDeployments to have expected commit/sync/health", "gitopsDeployments", waitingGitOpsDeployments)
+
+		return ctrl.Result{RequeueAfter: promotionRunRequeueDelay}, r.updateEnvironmentStatus(ctx, promotionRun, environmentName,
+			appstudioshared.ApplicationPromotionRunEnvironmentStatus_InProgress,
+			"Waiting for the following GitOpsDeployments to deploy the Snapshot, and be Synced/Healthy: "+strings.Join(waitingGitOpsDeployments, ", "), log)
+	}
+
+	// All the GitOpsDeployments are synced/healthy, so the promotion to this environment is complete: the next
+	// reconcile will either move on to the next environment, or complete the PromotionRun.
+	return ctrl.Result{Requeue: true}, r.updateEnvironmentStatus(ctx, promotionRun, environmentName,
+		appstudioshared.ApplicationPromotionRunEnvironmentStatus_Success, "All GitOpsDeployments have deployed the Snapshot, and are Synced/Healthy", log)
+}
+
+// completePromotionRun sets the PromotionRun state to 'Complete', with the given result. On failure, the reason and
+// error are reported via the ErrorOccurred condition.
+func (r *ApplicationPromotionRunReconciler) completePromotionRun(ctx context.Context, promotionRun *appstudioshared.ApplicationPromotionRun,
+	result appstudioshared.PromotionRunCompleteResult, reason string, errMessage error, log logr.Logger) error {
+
+	promotionRun.Status.CompletionResult = result
+	promotionRun.Status.State = appstudioshared.PromotionRunState_Complete
+	updateErrorOccurredCondition(&promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred, reason, errMessage)
+
+	if err := r.Client.Status().Update(ctx, promotionRun); err != nil {
+		return fmt.Errorf("unable to update PromotionRun on completion: %v", err)
+	}
+	sharedutil.LogAPIResourceChangeEvent(promotionRun.Namespace, promotionRun.Name, promotionRun, sharedutil.ResourceModified, log)
+
+	log.Info("PromotionRun completed", "result", result)
+
+	return nil
+}
+
+// timeoutPromotionRun fails the PromotionRun because it did not complete within its timeout: the step that was in
+// progress is marked as failed, with the reason it was still waiting.
+func (r *ApplicationPromotionRunReconciler) timeoutPromotionRun(ctx context.Context, promotionRun *appstudioshared.ApplicationPromotionRun,
+	log logr.Logger) error {
 
-		promotionRun.Status.ActiveBindings = []string{binding.Name}
-		if err := r.Client.Update(ctx, promotionRun); err != nil {
-			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun active binding: %v", err)
+	timeoutMessage := fmt.Sprintf("Promotion did not complete within the timeout of %v", promotionRunTimeout(*promotionRun))
+
+	if len(promotionRun.Status.EnvironmentStatus) == 0 {
+		// We never started promoting to the first environment (for example, because the Binding could not be found),
+		// so add a failed step for it, including the error that prevented the promotion from starting.
+		initialEnvironment := promotionRun.Spec.ManualPromotion.TargetEnvironment
+		if promotionRun.Spec.AutomatedPromotion.InitialEnvironment != "" {
+			initialEnvironment = promotionRun.Spec.AutomatedPromotion.InitialEnvironment
 		}
-		sharedutil.LogAPIResourceChangeEvent(promotionRun.Namespace, promotionRun.Name, promotionRun, sharedutil.ResourceModified, log)
 
-		return ctrl.Result{}, nil
+		displayStatus := timeoutMessage
+		if condition := meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred); condition != nil &&
+			condition.Status == metav1.ConditionTrue {
+			displayStatus += ": " + condition.Message
+		}
+
+		setEnvironmentStatus(promotionRun, initialEnvironment, appstudioshared.ApplicationPromotionRunEnvironmentStatus_Failed, displayStatus)
+
+	} else {
+		currentStep := &promotionRun.Status.EnvironmentStatus[len(promotionRun.Status.EnvironmentStatus)-1]
+
+		if currentStep.Status == appstudioshared.ApplicationPromotionRunEnvironmentStatus_InProgress {
+			currentStep.Status = appstudioshared.ApplicationPromotionRunEnvironmentStatus_Failed
+			currentStep.DisplayStatus = timeoutMessage + ": " + currentStep.DisplayStatus
+		}
 	}
 
-	// 3) Wait for the environment binding to create all of the expected GitOpsDeployments
-	if len(binding.Status.GitOpsDeployments) != len(binding.Spec.Components) {
-		// TODO: GITOPSRVCE-157 - Update promotionRun.Status.EnvironmentStatus.DisplayName, indicating that we are waiting for all the gitopsdeployments
-		return ctrl.Result{}, nil
+	return r.completePromotionRun(ctx, promotionRun, appstudioshared.PromotionRunCompleteResult_Failure,
+		appstudioshared.PromotionRunReason_Timeout, fmt.Errorf("%s", timeoutMessage), log)
+}
+
+// defaultPromotionRunTimeout is the timeout of PromotionRuns that do not specify '.spec.timeout'.
+const defaultPromotionRunTimeout = time.Minute * 30
+
+// promotionRunTimeout returns the maximum amount of time the PromotionRun may take, once active.
+func promotionRunTimeout(promotionRun appstudioshared.ApplicationPromotionRun) time.Duration {
+	if promotionRun.Spec.Timeout != nil {
+		return promotionRun.Spec.Timeout.Duration
+	}
+	return defaultPromotionRunTimeout
+}
+
+// remainingPromotionRunTime returns how much time the PromotionRun has left before it times out. A value <= 0
+// indicates that the PromotionRun has timed out.
+//
+// The timeout is measured from when the promotion started or, if it has not yet started (for example, because the
+// Snapshot is not valid), from when the PromotionRun was created.
+func remainingPromotionRunTime(promotionRun appstudioshared.ApplicationPromotionRun) time.Duration {
+
+	startTime := promotionRun.CreationTimestamp
+	if promotionRun.Status.PromotionStartTime != nil {
+		startTime = *promotionRun.Status.PromotionStartTime
+	}
+
+	if startTime.IsZero() {
+		return promotionRunTimeout(promotionRun)
+	}
+
+	return time.Until(startTime.Add(promotionRunTimeout(promotionRun)))
+}
+
+// promotionRunRequeueDelay is how long to wait before checking again on a PromotionRun that is waiting on
+// another resource (for example, on GitOpsDeployments to become Synced/Healthy).
+const promotionRunRequeueDelay = time.Second * 15
+
+// validatePromotionRunSpec ensures that the required fields of the PromotionRun are set, and that exactly one of
+// 'manualPromotion' or 'automatedPromotion' is defined.
+func validatePromotionRunSpec(promotionRun appstudioshared.ApplicationPromotionRun) error {
+
+	if promotionRun.Spec.Application == "" {
+		return fmt.Errorf("the 'application' field of the PromotionRun must be set")
+	}
+
+	if promotionRun.Spec.Snapshot == "" {
+		return fmt.Errorf("the 'snapshot' field of the PromotionRun must be set")
 	}
 
-	gitopsRepositoryCommitsWithSnapshot := []string{} /* we need a mechanism to tell which gitops repository revision corresponds to which snapshot*/
+	manual := promotionRun.Spec.ManualPromotion.TargetEnvironment != ""
+	automated := promotionRun.Spec.AutomatedPromotion.InitialEnvironment != ""
+
+	if manual && automated {
+		return fmt.Errorf("only one of 'manualPromotion' or 'automatedPromotion' should be defined, but not both")
+	} else if !manual && !automated {
+		return fmt.Errorf("one of 'manualPromotion.targetEnvironment' or 'automatedPromotion.initialEnvironment' must be set")
+	}
+
+	return nil
+}
+
+// validatePromotionRunSnapshot verifies that the Snapshot referenced by the PromotionRun exists, that it
+// is a Snapshot of the Application that is being promoted, and that it was not marked as invalid.
+// On failure, the reason (to use for the ErrorOccurred condition) is returned with the error.
+func validatePromotionRunSnapshot(ctx context.Context, promotionRun appstudioshared.ApplicationPromotionRun, k8sClient client.Client) (string, error) {
+
+	snapshot := &appstudioshared.ApplicationSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      promotionRun.Spec.Snapshot,
+			Namespace: promotionRun.Namespace,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(snapshot), snapshot); err != nil {
+		if apierr.IsNotFound(err) {
+			return appstudioshared.PromotionRunReason_SnapshotNotFound,
+				fmt.Errorf("the Snapshot '%s' referenced by the PromotionRun was not found", snapshot.Name)
+		}
+		return appstudioshared.PromotionRunReason_SnapshotNotFound, fmt.Errorf("unable to retrieve Snapshot '%s': %v", snapshot.Name, err)
+	}
+
+	if snapshot.Spec.Application != promotionRun.Spec.Application {
+		return appstudioshared.PromotionRunReason_SnapshotNotFound,
+			fmt.Errorf("the Snapshot '%s' references Application '%s', but the PromotionRun targets Application '%s'",
+				snapshot.Name, snapshot.Spec.Application, promotionRun.Spec.Application)
+	}
+
+	if err := checkSnapshotIsValid(*snapshot); err != nil {
+		return appstudioshared.PromotionRunReason_InvalidSnapshot, err
+	}
+
+	return "", nil
+}
+
+// checkBindingGitOpsDeployments returns the names of the GitOpsDeployments of the Binding that have not yet deployed the
+// Binding's Snapshot (or are not yet Synced/Healthy), and the names of those that deployed it, but are Degraded.
+func checkBindingGitOpsDeployments(ctx context.Context, binding appstudioshared.ApplicationSnapshotEnvironmentBinding,
+	k8sClient client.Client) ([]string, []string, error) {
 
-	// 4) Wait for all the GitOpsDeployments of the binding to have the expected state
 	waitingGitOpsDeployments := []string{}
+	degradedGitOpsDeployments := []string{}
 
-	for _, gitopsDeploymentName := range binding.Status.GitOpsDeployments {
+	for _, bindingGitOpsDeployment := range binding.Status.GitOpsDeployments {
 
 		gitopsDeployment := &apibackend.GitOpsDeployment{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      gitopsDeploymentName.GitOpsDeployment,
+				Name:      bindingGitOpsDeployment.GitOpsDeployment,
 				Namespace: binding.Namespace,
 			},
 		}
-		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(gitopsDeployment), gitopsDeployment); err != nil {
-			return ctrl.Result{}, fmt.Errorf("unable to retrieve gitopsdeployment '%s', %v", gitopsDeployment.Name, err)
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(gitopsDeployment), gitopsDeployment); err != nil {
+			if apierr.IsNotFound(err) {
+				waitingGitOpsDeployments = append(waitingGitOpsDeployments, gitopsDeployment.Name)
+				continue
+			}
+			return nil, nil, fmt.Errorf("unable to retrieve gitopsdeployment '%s', %v", gitopsDeployment.Name, err)
 		}
 
-		// Must have status of Synced/Healthy
-		if gitopsDeployment.Status.Sync.Status == apibackend.SyncStatusCodeSynced && gitopsDeployment.Status.Health.Status != apibackend.HeathStatusCodeHealthy {
-			// TODO: GITOPSRVCE-157 - Update Status.EnvironmentStatus to indicate that the gitopsdeployment is not synced/healthy
+		// Argo CD must have deployed at least one of the commits that include the Snapshot container images
+		if !isSnapshotRevision(binding, bindingGitOpsDeployment.ComponentName, gitopsDeployment.Status.Sync.Revision) {
 			waitingGitOpsDeployments = append(waitingGitOpsDeployments, gitopsDeployment.Name)
 			continue
 		}
 
-		// Argo CD must have deployed at least one of the commits that include the Snapshot container images
-		match := false
-		for _, snapshotCommit := range gitopsRepositoryCommitsWithSnapshot {
-			if gitopsDeployment.Status.Sync.Revision == snapshotCommit {
-				match = true
-				break
-			}
+		if gitopsDeployment.Status.Sync.Status == apibackend.SyncStatusCodeSynced && gitopsDeployment.Status.Health.Status == apibackend.HeathStatusCodeDegraded {
+			degradedGitOpsDeployments = append(degradedGitOpsDeployments, gitopsDeployment.Name)
+			continue
 		}
-		if !match {
+
+		// Must have status of Synced/Healthy
+		if gitopsDeployment.Status.Sync.Status != apibackend.SyncStatusCodeSynced || gitopsDeployment.Status.Health.Status != apibackend.HeathStatusCodeHealthy {
 			waitingGitOpsDeployments = append(waitingGitOpsDeployments, gitopsDeployment.Name)
 			continue
 		}
 	}
 
-	// TODO: GITOPSRVCE-157 - Implement a time limit on the spec of Promotion Run, and fail if the conditions aren't met in the timeframe.
+	sort.Strings(waitingGitOpsDeployments)
+	sort.Strings(degradedGitOpsDeployments)
 
-	if len(waitingGitOpsDeployments) > 0 {
-		fmt.Println("Waiting for GitOpsDeployments to have expected commit/sync/health:", waitingGitOpsDeployments)
-		return ctrl.Result{RequeueAfter: time.Second * 15}, nil
+	return waitingGitOpsDeployments, degradedGitOpsDeployments, nil
+}
+
+// isSnapshotRevision returns true if the given GitOps repository revision is one of the commits that include the
+// container image of the Binding's Snapshot, for the given component. These commits are recorded in the Binding
+// status by the service that generates the GitOps repository.
+//
+// If no commits have been recorded for the component, the Snapshot is not yet known to have been written to the GitOps
+// repository, and so no revision is accepted: the PromotionRun keeps waiting (until it times out) for them to be recorded.
+func isSnapshotRevision(binding appstudioshared.ApplicationSnapshotEnvironmentBinding, componentName string, revision string) bool {
+
+	for _, componentStatus := range binding.Status.Components {
+		if componentStatus.Name != componentName {
+			continue
+		}
+
+		// The commits must have been recorded for the Snapshot that the Binding currently targets
+		if componentStatus.GitOpsRepository.Snapshot != binding.Spec.Snapshot || revision == "" {
+			return false
+		}
+
+		for _, commitID := range componentStatus.GitOpsRepository.CommitIDs {
+			if commitID == revision {
+				return true
+			}
+		}
+
+		return false
 	}
 
-	// All the GitOpsDeployments are synced/healthy, and they are synced with a commit that includes the target snapshot.
-	promotionRun.Status.ActiveBindings = []string{}
-	promotionRun.Status.CompletionResult = appstudioshared.PromotionRunCompleteResult_Success
-	promotionRun.Status.State = appstudioshared.PromotionRunState_Complete
-	promotionRun.Status.ActiveBindings = []string{binding.Name}
-	if err := r.Client.Update(ctx, promotionRun); err != nil {
-		return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun on successful completion: %v", err)
+	// No commits have been recorded for this component
+	return false
+}
+
+// updateEnvironmentStatus updates the EnvironmentStatus step of the given environment, and updates the
+// PromotionRun status if it changed.
+func (r *ApplicationPromotionRunReconciler) updateEnvironmentStatus(ctx context.Context, promotionRun *appstudioshared.ApplicationPromotionRun,
+	environmentName string, status appstudioshared.PromotionRunEnvironmentStatusField, displayStatus string, log logr.Logger) error {
+
+	if !setEnvironmentStatus(promotionRun, environmentName, status, displayStatus) {
+		return nil
+	}
+
+	if err := r.Client.Status().Update(ctx, promotionRun); err != nil {
+		return fmt.Errorf("unable to update PromotionRun environment status: %v", err)
 	}
 	sharedutil.LogAPIResourceChangeEvent(promotionRun.Namespace, promotionRun.Name, promotionRun, sharedutil.ResourceModified, log)
 
-	// TODO: GITOPSRVCE-157 - Update promotionRun.Status.EnvironmentStatus to indicate which GitOpsDeployments we are still waiting for
+	return nil
+}
+
+// setEnvironmentStatus sets the status of the EnvironmentStatus step for the given environment, adding a new step
+// if one does not already exist. Returns true if the PromotionRun was modified.
+func setEnvironmentStatus(promotionRun *appstudioshared.ApplicationPromotionRun, environmentName string,
+	status appstudioshared.PromotionRunEnvironmentStatusField, displayStatus string) bool {
 
-	// TODO: GITOPSRVCE-157 - Update promotionRun.Status.EnvironmentStatus
+	for i := range promotionRun.Status.EnvironmentStatus {
+		envStatus := &promotionRun.Status.EnvironmentStatus[i]
 
-	return ctrl.Result{}, nil
+		if envStatus.EnvironmentName != environmentName {
+			continue
+		}
+
+		if envStatus.Status == status && envStatus.DisplayStatus == displayStatus {
+			return false
+		}
+
+		envStatus.Status = status
+		envStatus.DisplayStatus = displayStatus
+		return true
+	}
+
+	promotionRun.Status.EnvironmentStatus = append(promotionRun.Status.EnvironmentStatus, appstudioshared.PromotionRunEnvironmentStatus{
+		Step:            len(promotionRun.Status.EnvironmentStatus) + 1,
+		EnvironmentName: environmentName,
+		Status:          status,
+		DisplayStatus:   displayStatus,
+	})
+
+	return true
+}
+
+// setErrorOccurredCondition sets the ErrorOccurred condition of the PromotionRun to the given error, and updates the
+// status of the PromotionRun.
+func (r *ApplicationPromotionRunReconciler) setErrorOccurredCondition(ctx context.Context, promotionRun *appstudioshared.ApplicationPromotionRun,
+	reason string, errMessage error, log logr.Logger) error {
+
+	updateErrorOccurredCondition(&promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred, reason, errMessage)
+
+	if err := r.Client.Status().Update(ctx, promotionRun); err != nil {
+		if apierr.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to update PromotionRun status conditions: %v", err)
+	}
+	sharedutil.LogAPIResourceChangeEvent(promotionRun.Namespace, promotionRun.Name, promotionRun, sharedutil.ResourceModified, log)
+
+	return nil
+}
+
+// updateErrorOccurredCondition updates the 'ErrorOccurred' condition (of the given type) in the given list of conditions:
+// - if errMessage is non-nil, the condition is set to True, with the given reason and the error as message.
+// - if errMessage is nil, and an unresolved ErrorOccurred condition exists, it is marked as resolved.
+// Returns true if the list of conditions was modified.
+func updateErrorOccurredCondition(conditions *[]metav1.Condition, conditionType string, reason string, errMessage error) bool {
+
+	existing := meta.FindStatusCondition(*conditions, conditionType)
+
+	if errMessage != nil {
+		if existing != nil && existing.Status == metav1.ConditionTrue && existing.Reason == reason && existing.Message == errMessage.Error() {
+			return false
+		}
+
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: errMessage.Error(),
+		})
+		return true
+	}
+
+	// If error does not exist, check if the condition exists or not, and mark it as resolved
+	if existing == nil || existing.Status != metav1.ConditionTrue {
+		return false
+	}
+
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  existing.Reason + "Resolved",
+		Message: "",
+	})
+
+	return true
 }
 
 // checkForExistingActivePromotions ensures that there are no other promotions that are active on this application.
@@ -223,12 +618,16 @@ func (r *ApplicationPromotionRunReconciler) ReconcilePOC(ctx context.Context, re
 func checkForExistingActivePromotions(ctx context.Context, reconciledPromotionRun appstudioshared.ApplicationPromotionRun, k8sClient client.Client) error {
 
 	otherPromotionRuns := &appstudioshared.ApplicationPromotionRunList{}
-	if err := k8sClient.List(ctx, otherPromotionRuns); err != nil {
-		// log me
-		return err
+	if err := k8sClient.List(ctx, otherPromotionRuns, &client.ListOptions{Namespace: reconciledPromotionRun.Namespace}); err != nil {
+		return fmt.Errorf("unable to list PromotionRuns: %v", err)
 	}
 
 	for _, otherPromotionRun := range otherPromotionRuns.Items {
+		if otherPromotionRun.Name == reconciledPromotionRun.Name {
+			// Ignore the PromotionRun we are reconciling
+			continue
+		}
+
 		if otherPromotionRun.Status.State == appstudioshared.PromotionRunState_Complete {
 			// Ignore completed promotions (these are no longer active)
 			continue
@@ -248,32 +647,131 @@ func checkForExistingActivePromotions(ctx context.Context, reconciledPromotionRu
 	return nil
 }
 
-func locateTargetManualBinding(ctx context.Context, promotionRun appstudioshared.ApplicationPromotionRun, k8sClient client.Client) (appstudioshared.ApplicationSnapshotEnvironmentBinding, error) {
+// locateTargetBinding returns the Binding of the promoted Application that targets the given environment.
+func locateTargetBinding(ctx context.Context, promotionRun appstudioshared.ApplicationPromotionRun, environmentName string,
+	k8sClient client.Client) (appstudioshared.ApplicationSnapshotEnvironmentBinding, error) {
 
 	// Locate the corresponding binding
 
 	bindingList := appstudioshared.ApplicationSnapshotEnvironmentBindingList{}
-	if err := k8sClient.List(ctx, &bindingList); err != nil {
+	if err := k8sClient.List(ctx, &bindingList, &client.ListOptions{Namespace: promotionRun.Namespace}); err != nil {
 		return appstudioshared.ApplicationSnapshotEnvironmentBinding{}, fmt.Errorf("unable to list bindings: %v", err)
 	}
 
 	for _, binding := range bindingList.Items {
 
-		if binding.Spec.Application == promotionRun.Spec.Application && binding.Spec.Environment == promotionRun.Spec.ManualPromotion.TargetEnvironment {
+		if binding.Spec.Application == promotionRun.Spec.Application && binding.Spec.Environment == environmentName {
 			return binding, nil
 		}
 	}
 
 	return appstudioshared.ApplicationSnapshotEnvironmentBinding{},
 		fmt.Errorf("unable to locate binding with application '%s' and target environment '%s'",
-			promotionRun.Spec.Application, promotionRun.Spec.ManualPromotion.TargetEnvironment)
+			promotionRun.Spec.Application, environmentName)
 
 }
 
+// nextAutomatedPromotionEnvironment returns the next environment that an automated promotion should promote to, or
+// "" if every environment has been promoted to.
+//
+// Environments are promoted to one at a time, in breadth-first order, starting with the initial environment: an
+// Environment is promoted to after its parent Environment (see 'parentEnvironment'). Only Environments with an
+// 'AppStudioAutomated' deployment strategy are automatically promoted to.
+func nextAutomatedPromotionEnvironment(ctx context.Context, promotionRun appstudioshared.ApplicationPromotionRun, k8sClient client.Client) (string, error) {
+
+	environmentList := appstudioshared.EnvironmentList{}
+	if err := k8sClient.List(ctx, &environmentList, &client.ListOptions{Namespace: promotionRun.Namespace}); err != nil {
+		return "", fmt.Errorf("unable to list environments: %v", err)
+	}
+
+	initialEnvironment := promotionRun.Spec.AutomatedPromotion.InitialEnvironment
+
+	// map: parent environment name -> names of the automated environments which have that parent
+	childEnvironments := map[string][]string{}
+	initialEnvironmentExists := false
+
+	for _, environment := range environmentList.Items {
+		if environment.Name == initialEnvironment {
+			initialEnvironmentExists = true
+		}
+
+		if environment.Spec.ParentEnvironment == "" ||
+			environment.Spec.DeploymentStrategy != appstudioshared.DeploymentStrategy_AppStudioAutomated {
+			continue
+		}
+
+		childEnvironments[environment.Spec.ParentEnvironment] = append(childEnvironments[environment.Spec.ParentEnvironment], environment.Name)
+	}
+
+	if !initialEnvironmentExists {
+		return "", fmt.Errorf("the initial environment '%s' of the automated promotion was not found", initialEnvironment)
+	}
+
+	promotedEnvironments := map[string]bool{}
+	for _, envStatus := range promotionRun.Status.EnvironmentStatus {
+		promotedEnvironments[envStatus.EnvironmentName] = true
+	}
+
+	// Walk the environment graph breadth-first, returning the first environment that has not been promoted to.
+	// The visited map ensures that a cycle in the graph does not cause an environment to be promoted to twice.
+	visited := map[string]bool{initialEnvironment: true}
+	queue := []string{initialEnvironment}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if !promotedEnvironments[current] {
+			return current, nil
+		}
+
+		children := childEnvironments[current]
+		sort.Strings(children)
+
+		for _, child := range children {
+			if !visited[child] {
+				visited[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	return "", nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ApplicationPromotionRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&appstudioshared.ApplicationPromotionRun{}).
-		Owns(&appstudioshared.ApplicationSnapshotEnvironmentBinding{}).
+		Watches(&source.Kind{Type: &appstudioshared.ApplicationSnapshotEnvironmentBinding{}},
+			handler.EnqueueRequestsFromMapFunc(r.findPromotionRunsForBinding)).
 		Complete(r)
 }
+
+// findPromotionRunsForBinding returns a request for each incomplete PromotionRun of the Application of the given Binding,
+// so that the PromotionRun can proceed as soon as the Binding (and its GitOpsDeployments) are updated.
+func (r *ApplicationPromotionRunReconciler) findPromotionRunsForBinding(bindingObj client.Object) []reconcile.Request {
+
+	binding, ok := bindingObj.(*appstudioshared.ApplicationSnapshotEnvironmentBinding)
+	if !ok {
+		return []reconcile.Request{}
+	}
+
+	promotionRunList := &appstudioshared.ApplicationPromotionRunList{}
+	if err := r.Client.List(context.Background(), promotionRunList, &client.ListOptions{Namespace: binding.Namespace}); err != nil {
+		log.Log.Error(err, "unable to list PromotionRuns for Binding", "binding", binding.Name)
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for _, promotionRun := range promotionRunList.Items {
+		if promotionRun.Spec.Application == binding.Spec.Application &&
+			promotionRun.Status.State != appstudioshared.PromotionRunState_Complete {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: promotionRun.Name, Namespace: promotionRun.Namespace},
+			})
+		}
+	}
+
+	return requests
+}
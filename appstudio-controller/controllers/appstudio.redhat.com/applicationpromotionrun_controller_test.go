@@ -0,0 +1,692 @@
+package appstudioredhatcom
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appstudioshared "github.com/redhat-appstudio/managed-gitops/appstudio-shared/apis/appstudio.redhat.com/v1alpha1"
+	apibackend "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+)
+
+var _ = Describe("ApplicationPromotionRun Reconciler Tests", func() {
+
+	Context("Testing ApplicationPromotionRunReconciler for manual promotions.", func() {
+
+		var ctx context.Context
+		var k8sClient client.Client
+		var promotionRunReconciler ApplicationPromotionRunReconciler
+
+		var snapshot *appstudioshared.ApplicationSnapshot
+		var binding *appstudioshared.ApplicationSnapshotEnvironmentBinding
+		var promotionRun *appstudioshared.ApplicationPromotionRun
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme,
+				argocdNamespace,
+				kubesystemNamespace,
+				apiNamespace,
+				err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+
+			err = appstudioshared.AddToScheme(scheme)
+			Expect(err).To(BeNil())
+
+			snapshot = &appstudioshared.ApplicationSnapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-snapshot-2",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudioshared.ApplicationSnapshotSpec{
+					Application: "new-demo-app",
+					Components: []appstudioshared.ApplicationSnapshotComponent{
+						{
+							Name:           "component-a",
+							ContainerImage: "quay.io/jgwest-redhat/sample-workload:latest",
+						},
+					},
+				},
+			}
+
+			binding = &appstudioshared.ApplicationSnapshotEnvironmentBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "appa-staging-binding",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudioshared.ApplicationSnapshotEnvironmentBindingSpec{
+					Application: "new-demo-app",
+					Environment: "staging",
+					Snapshot:    "my-snapshot",
+					Components: []appstudioshared.BindingComponent{
+						{
+							Name: "component-a",
+						},
+					},
+				},
+			}
+
+			promotionRun = &appstudioshared.ApplicationPromotionRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "new-demo-app-manual-promotion",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudioshared.ApplicationPromotionRunSpec{
+					Snapshot:    snapshot.Name,
+					Application: "new-demo-app",
+					ManualPromotion: appstudioshared.ManualPromotionConfiguration{
+						TargetEnvironment: "staging",
+					},
+				},
+			}
+
+			// Create fake client
+			k8sClient = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(apiNamespace, argocdNamespace, kubesystemNamespace, snapshot, binding).
+				Build()
+
+			promotionRunReconciler = ApplicationPromotionRunReconciler{Client: k8sClient, Scheme: scheme}
+		})
+
+		It("should retarget the Binding, and complete once the GitOpsDeployments are Synced/Healthy", func() {
+
+			err := k8sClient.Create(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			By("reconciling the PromotionRun, which should update the Binding to the new Snapshot")
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(binding), binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Spec.Snapshot).To(Equal(snapshot.Name))
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Active))
+			Expect(promotionRun.Status.ActiveBindings).To(Equal([]string{binding.Name}))
+			Expect(promotionRun.Status.EnvironmentStatus).To(HaveLen(1))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Step).To(Equal(1))
+			Expect(promotionRun.Status.EnvironmentStatus[0].EnvironmentName).To(Equal("staging"))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudioshared.ApplicationPromotionRunEnvironmentStatus_InProgress))
+
+			By("verifying that changes to the Binding cause the active PromotionRun to be reconciled")
+			Expect(promotionRunReconciler.findPromotionRunsForBinding(binding)).To(Equal([]reconcile.Request{
+				newRequest(promotionRun.Namespace, promotionRun.Name),
+			}))
+
+			By("simulating the Binding controller creating a GitOpsDeployment which is not yet healthy")
+			gitopsDeployment := &apibackend.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      GenerateBindingGitOpsDeploymentName(*binding, "component-a"),
+					Namespace: binding.Namespace,
+				},
+				Status: apibackend.GitOpsDeploymentStatus{
+					Sync:   apibackend.SyncStatus{Status: apibackend.SyncStatusCodeOutOfSync, Revision: "old-commit"},
+					Health: apibackend.HealthStatus{Status: apibackend.HeathStatusCodeProgressing},
+				},
+			}
+			err = k8sClient.Create(ctx, gitopsDeployment)
+			Expect(err).To(BeNil())
+
+			binding.Status.GitOpsDeployments = []appstudioshared.BindingStatusGitOpsDeployment{
+				{ComponentName: "component-a", GitOpsDeployment: gitopsDeployment.Name},
+			}
+			binding.Status.Components = []appstudioshared.ComponentStatus{
+				{
+					Name: "component-a",
+					GitOpsRepository: appstudioshared.BindingComponentGitOpsRepository{
+						Snapshot:  snapshot.Name,
+						CommitIDs: []string{"new-commit"},
+					},
+				},
+			}
+			err = k8sClient.Status().Update(ctx, binding)
+			Expect(err).To(BeNil())
+
+			res, err := promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+			Expect(res.RequeueAfter).To(Equal(promotionRunRequeueDelay))
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Active))
+			Expect(promotionRun.Status.EnvironmentStatus[0].DisplayStatus).To(ContainSubstring(gitopsDeployment.Name))
+
+			By("updating the GitOpsDeployment to Synced/Healthy, but on a commit which does not contain the Snapshot")
+			gitopsDeployment.Status.Sync.Status = apibackend.SyncStatusCodeSynced
+			gitopsDeployment.Status.Health.Status = apibackend.HeathStatusCodeHealthy
+			err = k8sClient.Status().Update(ctx, gitopsDeployment)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Active))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudioshared.ApplicationPromotionRunEnvironmentStatus_InProgress))
+
+			By("updating the GitOpsDeployment to the Snapshot commit, which should complete the promotion")
+			gitopsDeployment.Status.Sync.Revision = "new-commit"
+			err = k8sClient.Status().Update(ctx, gitopsDeployment)
+			Expect(err).To(BeNil())
+
+			reconcileUntilComplete(ctx, promotionRunReconciler, promotionRun)
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudioshared.PromotionRunCompleteResult_Success))
+			Expect(promotionRun.Status.EnvironmentStatus).To(HaveLen(1))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudioshared.ApplicationPromotionRunEnvironmentStatus_Success))
+
+			By("verifying that the completed PromotionRun is no longer reconciled on changes to the Binding")
+			Expect(promotionRunReconciler.findPromotionRunsForBinding(binding)).To(BeEmpty())
+		})
+
+		It("should set an ErrorOccurred condition if the target Binding does not exist, and resolve it once it does", func() {
+
+			promotionRun.Spec.ManualPromotion.TargetEnvironment = "production"
+			err := k8sClient.Create(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+
+			condition := meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(appstudioshared.PromotionRunReason_BindingNotFound))
+
+			By("creating the missing Binding, and reconciling again")
+			prodBinding := binding.DeepCopy()
+			prodBinding.ObjectMeta = metav1.ObjectMeta{Name: "appa-production-binding", Namespace: binding.Namespace}
+			prodBinding.Spec.Environment = "production"
+			err = k8sClient.Create(ctx, prodBinding)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Active))
+
+			condition = meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		})
+
+		It("should set an ErrorOccurred condition if the Snapshot does not exist", func() {
+
+			promotionRun.Spec.Snapshot = "missing-snapshot"
+			err := k8sClient.Create(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+
+			condition := meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(appstudioshared.PromotionRunReason_SnapshotNotFound))
+
+			By("ensuring the Binding was not modified")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(binding), binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Spec.Snapshot).To(Equal("my-snapshot"))
+		})
+
+		It("should set an ErrorOccurred condition if the Snapshot is not valid", func() {
+
+			meta.SetStatusCondition(&snapshot.Status.Conditions, metav1.Condition{
+				Type:    appstudioshared.ApplicationSnapshotCondition_Valid,
+				Status:  metav1.ConditionFalse,
+				Reason:  appstudioshared.ApplicationSnapshotReason_InvalidContainerImage,
+				Message: "the container image of component 'component-a' is not valid",
+			})
+			err := k8sClient.Status().Update(ctx, snapshot)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Create(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).ToNot(Equal(appstudioshared.PromotionRunState_Active))
+
+			condition := meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(appstudioshared.PromotionRunReason_InvalidSnapshot))
+
+			By("ensuring the Binding was not modified")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(binding), binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Spec.Snapshot).To(Equal("my-snapshot"))
+		})
+
+		It("should fail the promotion if both manual and automated promotion are specified", func() {
+
+			promotionRun.Spec.AutomatedPromotion.InitialEnvironment = "staging"
+			err := k8sClient.Create(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+
+			condition := meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(appstudioshared.PromotionRunReason_InvalidSpec))
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudioshared.PromotionRunCompleteResult_Failure))
+		})
+
+		It("should fail the promotion if the Snapshot does not become valid before the timeout", func() {
+
+			promotionRun.Spec.Snapshot = "missing-snapshot"
+			err := k8sClient.Create(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			res, err := promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+			Expect(res.RequeueAfter).To(Equal(promotionRunRequeueDelay))
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).ToNot(Equal(appstudioshared.PromotionRunState_Active))
+			Expect(promotionRun.Status.PromotionStartTime).ToNot(BeNil())
+
+			By("simulating a PromotionRun that started longer ago than the default timeout")
+			startTime := metav1.NewTime(time.Now().Add(-(defaultPromotionRunTimeout + time.Minute)))
+			promotionRun.Status.PromotionStartTime = &startTime
+			err = k8sClient.Status().Update(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudioshared.PromotionRunCompleteResult_Failure))
+			Expect(promotionRun.Status.EnvironmentStatus).To(HaveLen(1))
+			Expect(promotionRun.Status.EnvironmentStatus[0].DisplayStatus).To(ContainSubstring("missing-snapshot"))
+
+			condition := meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(appstudioshared.PromotionRunReason_Timeout))
+		})
+
+		It("should wait if an older PromotionRun is still active for the same Application", func() {
+
+			olderPromotionRun := promotionRun.DeepCopy()
+			olderPromotionRun.Name = "older-promotion"
+			olderPromotionRun.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+			olderPromotionRun.Status.State = appstudioshared.PromotionRunState_Active
+			err := k8sClient.Create(ctx, olderPromotionRun)
+			Expect(err).To(BeNil())
+
+			promotionRun.CreationTimestamp = metav1.Now()
+			err = k8sClient.Create(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			res, err := promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+			Expect(res.RequeueAfter).To(Equal(promotionRunRequeueDelay))
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Waiting))
+
+			condition := meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(appstudioshared.PromotionRunReason_ActivePromotionExists))
+
+			By("ensuring the Binding was not modified")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(binding), binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Spec.Snapshot).To(Equal("my-snapshot"))
+		})
+
+		It("should fail the promotion if the GitOpsDeployments do not become Synced/Healthy before the timeout", func() {
+
+			err := k8sClient.Create(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Active))
+			Expect(promotionRun.Status.PromotionStartTime).ToNot(BeNil())
+
+			By("simulating a PromotionRun that started longer ago than the default timeout")
+			startTime := metav1.NewTime(time.Now().Add(-(defaultPromotionRunTimeout + time.Minute)))
+			promotionRun.Status.PromotionStartTime = &startTime
+			err = k8sClient.Status().Update(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudioshared.PromotionRunCompleteResult_Failure))
+			Expect(promotionRun.Status.EnvironmentStatus).To(HaveLen(1))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudioshared.ApplicationPromotionRunEnvironmentStatus_Failed))
+			Expect(promotionRun.Status.EnvironmentStatus[0].DisplayStatus).To(ContainSubstring("timeout"))
+
+			condition := meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(appstudioshared.PromotionRunReason_Timeout))
+		})
+
+		It("should use the timeout from the spec, and report the error that prevented the promotion from starting", func() {
+
+			promotionRun.Spec.ManualPromotion.TargetEnvironment = "production"
+			promotionRun.Spec.Timeout = &metav1.Duration{Duration: time.Minute}
+			err := k8sClient.Create(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Active))
+			Expect(promotionRun.Status.EnvironmentStatus).To(BeEmpty())
+
+			By("simulating a PromotionRun that started longer ago than the spec timeout, but not the default timeout")
+			startTime := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+			promotionRun.Status.PromotionStartTime = &startTime
+			err = k8sClient.Status().Update(ctx, promotionRun)
+			Expect(err).To(BeNil())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+			Expect(err).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudioshared.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudioshared.PromotionRunCompleteResult_Failure))
+			Expect(promotionRun.Status.EnvironmentStatus).To(HaveLen(1))
+			Expect(promotionRun.Status.EnvironmentStatus[0].EnvironmentName).To(Equal("production"))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudioshared.ApplicationPromotionRunEnvironmentStatus_Failed))
+			Expect(promotionRun.Status.EnvironmentStatus[0].DisplayStatus).To(ContainSubstring("unable to locate binding"))
+
+			condition := meta.FindStatusCondition(promotionRun.Status.Conditions, appstudioshared.PromotionRunCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(appstudioshared.PromotionRunReason_Timeout))
+		})
+	})
+
+	Context("Testing ApplicationPromotionRunReconciler for automated promotions.", func() {
+
+		var ctx context.Context
+		var k8sClient client.Client
+		var promotionRunReconciler ApplicationPromotionRunReconciler
+		var namespace string
+
+		var promotionRun *appstudioshared.ApplicationPromotionRun
+
+		// environment name -> GitOpsDeployment of the binding of that environment
+		var gitopsDeployments map[string]*apibackend.GitOpsDeployment
+
+		newEnvironment := func(name string, parent string, strategy appstudioshared.DeploymentStrategyType) *appstudioshared.Environment {
+			return &appstudioshared.Environment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: appstudioshared.EnvironmentSpec{
+					DisplayName:        name,
+					Type:               appstudioshared.EnvironmentType_POC,
+					DeploymentStrategy: strategy,
+					ParentEnvironment:  parent,
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme,
+				argocdNamespace,
+				kubesystemNamespace,
+				apiNamespace,
+				err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+
+			err = appstudioshared.AddToScheme(scheme)
+			Expect(err).To(BeNil())
+
+			namespace = apiNamespace.Name
+
+			snapshot := &appstudioshared.ApplicationSnapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-snapshot-2",
+					Namespace: namespace,
+				},
+				Spec: appstudioshared.ApplicationSnapshotSpec{
+					Application: "new-demo-app",
+				},
+			}
+
+			// Environment graph:
+			// - staging
+			//   - production (automated)
+			//   - qa (manual: should not be promoted to)
+			// - production
+			//   - production-eu (automated)
+			objects := []client.Object{apiNamespace, argocdNamespace, kubesystemNamespace, snapshot,
+				newEnvironment("staging", "", appstudioshared.DeploymentStrategy_Manual),
+				newEnvironment("production", "staging", appstudioshared.DeploymentStrategy_AppStudioAutomated),
+				newEnvironment("qa", "staging", appstudioshared.DeploymentStrategy_Manual),
+				newEnvironment("production-eu", "production", appstudioshared.DeploymentStrategy_AppStudioAutomated),
+			}
+
+			gitopsDeployments = map[string]*apibackend.GitOpsDeployment{}
+
+			// Create a Binding for each environment, each with a single (Synced/Healthy) GitOpsDeployment
+			for _, envName := range []string{"staging", "production", "qa", "production-eu"} {
+
+				gitopsDeployment := &apibackend.GitOpsDeployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "new-demo-app-" + envName + "-component-a",
+						Namespace: namespace,
+					},
+					Status: apibackend.GitOpsDeploymentStatus{
+						Sync:   apibackend.SyncStatus{Status: apibackend.SyncStatusCodeSynced, Revision: "commit-" + envName},
+						Health: apibackend.HealthStatus{Status: apibackend.HeathStatusCodeHealthy},
+					},
+				}
+				gitopsDeployments[envName] = gitopsDeployment
+
+				binding := &appstudioshared.ApplicationSnapshotEnvironmentBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "new-demo-app-" + envName + "-binding",
+						Namespace: namespace,
+					},
+					Spec: appstudioshared.ApplicationSnapshotEnvironmentBindingSpec{
+						Application: "new-demo-app",
+						Environment: envName,
+						Snapshot:    "my-snapshot",
+						Components:  []appstudioshared.BindingComponent{{Name: "component-a"}},
+					},
+					Status: appstudioshared.ApplicationSnapshotEnvironmentBindingStatus{
+						GitOpsDeployments: []appstudioshared.BindingStatusGitOpsDeployment{
+							{ComponentName: "component-a", GitOpsDeployment: gitopsDeployment.Name},
+						},
+						Components: []appstudioshared.ComponentStatus{
+							{
+								Name: "component-a",
+								GitOpsRepository: appstudioshared.BindingComponentGitOpsRepository{
+									Snapshot:  snapshot.Name,
+									CommitIDs: []string{"commit-" + envName},
+								},
+							},
+						},
+					},
+				}
+
+				objects = append(objects, gitopsDeployment, binding)
+			}
+
+			promotionRun = &appstudioshared.ApplicationPromotionRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "new-demo-app-automated-promotion",
+					Namespace: namespace,
+				},
+				Spec: appstudioshared.ApplicationPromotionRunSpec{
+					Snapshot:    snapshot.Name,
+					Application: "new-demo-app",
+					AutomatedPromotion: appstudioshared.AutomatedPromotionConfiguration{
+						InitialEnvironment: "staging",
+					},
+				},
+			}
+			objects = append(objects, promotionRun)
+
+			k8sClient = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objects...).
+				Build()
+
+			promotionRunReconciler = ApplicationPromotionRunReconciler{Client: k8sClient, Scheme: scheme}
+		})
+
+		It("should promote to each automated environment in the graph, one step at a time", func() {
+
+			reconcileUntilComplete(ctx, promotionRunReconciler, promotionRun)
+
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudioshared.PromotionRunCompleteResult_Success))
+
+			Expect(promotionRun.Status.EnvironmentStatus).To(HaveLen(3))
+			for i, envName := range []string{"staging", "production", "production-eu"} {
+				Expect(promotionRun.Status.EnvironmentStatus[i].Step).To(Equal(i + 1))
+				Expect(promotionRun.Status.EnvironmentStatus[i].EnvironmentName).To(Equal(envName))
+				Expect(promotionRun.Status.EnvironmentStatus[i].Status).To(Equal(appstudioshared.ApplicationPromotionRunEnvironmentStatus_Success))
+
+				binding := &appstudioshared.ApplicationSnapshotEnvironmentBinding{}
+				err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "new-demo-app-" + envName + "-binding"}, binding)
+				Expect(err).To(BeNil())
+				Expect(binding.Spec.Snapshot).To(Equal(promotionRun.Spec.Snapshot))
+			}
+
+			By("ensuring that the environment with a manual deployment strategy was not promoted to")
+			binding := &appstudioshared.ApplicationSnapshotEnvironmentBinding{}
+			err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "new-demo-app-qa-binding"}, binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Spec.Snapshot).To(Equal("my-snapshot"))
+		})
+
+		It("should halt the promotion on the first failure", func() {
+
+			gitopsDeployment := gitopsDeployments["production"]
+			gitopsDeployment.Status.Health.Status = apibackend.HeathStatusCodeDegraded
+			err := k8sClient.Status().Update(ctx, gitopsDeployment)
+			Expect(err).To(BeNil())
+
+			reconcileUntilComplete(ctx, promotionRunReconciler, promotionRun)
+
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudioshared.PromotionRunCompleteResult_Failure))
+
+			Expect(promotionRun.Status.EnvironmentStatus).To(HaveLen(2))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudioshared.ApplicationPromotionRunEnvironmentStatus_Success))
+			Expect(promotionRun.Status.EnvironmentStatus[1].EnvironmentName).To(Equal("production"))
+			Expect(promotionRun.Status.EnvironmentStatus[1].Status).To(Equal(appstudioshared.ApplicationPromotionRunEnvironmentStatus_Failed))
+			Expect(promotionRun.Status.EnvironmentStatus[1].DisplayStatus).To(ContainSubstring(gitopsDeployment.Name))
+
+			By("ensuring that the environments after the failed environment were not promoted to")
+			binding := &appstudioshared.ApplicationSnapshotEnvironmentBinding{}
+			err = k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "new-demo-app-production-eu-binding"}, binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Spec.Snapshot).To(Equal("my-snapshot"))
+		})
+	})
+
+	Context("Testing isSnapshotRevision", func() {
+
+		newBinding := func(componentStatus ...appstudioshared.ComponentStatus) appstudioshared.ApplicationSnapshotEnvironmentBinding {
+			return appstudioshared.ApplicationSnapshotEnvironmentBinding{
+				Spec: appstudioshared.ApplicationSnapshotEnvironmentBindingSpec{
+					Snapshot: "my-snapshot",
+				},
+				Status: appstudioshared.ApplicationSnapshotEnvironmentBindingStatus{
+					Components: componentStatus,
+				},
+			}
+		}
+
+		It("should only accept the commits recorded for the Snapshot of the Binding", func() {
+			binding := newBinding(appstudioshared.ComponentStatus{
+				Name: "component-a",
+				GitOpsRepository: appstudioshared.BindingComponentGitOpsRepository{
+					Snapshot:  "my-snapshot",
+					CommitIDs: []string{"commit-1", "commit-2"},
+				},
+			})
+
+			Expect(isSnapshotRevision(binding, "component-a", "commit-2")).To(BeTrue())
+			Expect(isSnapshotRevision(binding, "component-a", "other-commit")).To(BeFalse())
+			Expect(isSnapshotRevision(binding, "component-a", "")).To(BeFalse())
+
+			By("ensuring commits that were recorded for a previous Snapshot are not accepted")
+			binding.Spec.Snapshot = "my-new-snapshot"
+			Expect(isSnapshotRevision(binding, "component-a", "commit-2")).To(BeFalse())
+		})
+
+		It("should not accept any revision if no commits are recorded for the component", func() {
+			binding := newBinding(appstudioshared.ComponentStatus{Name: "component-a"})
+			Expect(isSnapshotRevision(binding, "component-a", "any-commit")).To(BeFalse())
+
+			binding = newBinding()
+			Expect(isSnapshotRevision(binding, "component-a", "any-commit")).To(BeFalse())
+		})
+	})
+})
+
+// reconcileUntilComplete calls Reconcile on the PromotionRun until it is complete, and then updates
+// the given PromotionRun with the latest version from the client.
+func reconcileUntilComplete(ctx context.Context, reconciler ApplicationPromotionRunReconciler, promotionRun *appstudioshared.ApplicationPromotionRun) {
+
+	for i := 0; i < 20; i++ {
+		_, err := reconciler.Reconcile(ctx, newRequest(promotionRun.Namespace, promotionRun.Name))
+		Expect(err).To(BeNil())
+
+		err = reconciler.Client.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
+		Expect(err).To(BeNil())
+
+		if promotionRun.Status.State == appstudioshared.PromotionRunState_Complete {
+			return
+		}
+	}
+
+	Fail("PromotionRun did not complete")
+}
@@ -20,11 +20,24 @@ import (
 	"context"
 	"fmt"
 
+	// Registers the sha256 digest algorithm, which is required to parse digest-pinned container images
+	_ "crypto/sha256"
+
+	"github.com/docker/distribution/reference"
+	applicationv1alpha1 "github.com/redhat-appstudio/application-service/api/v1alpha1"
 	appstudioshared "github.com/redhat-appstudio/managed-gitops/appstudio-shared/apis/appstudio.redhat.com/v1alpha1"
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // ApplicationSnapshotReconciler reconciles a ApplicationSnapshot object
@@ -36,27 +49,158 @@ type ApplicationSnapshotReconciler struct {
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=applicationsnapshots,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=applicationsnapshots/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=applicationsnapshots/finalizers,verbs=update
+//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=applications,verbs=get;list;watch
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the ApplicationSnapshot object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// Reconcile validates the ApplicationSnapshot, and reports the result via the 'Valid' condition of the Snapshot status.
+// A Snapshot is valid if:
+// - the Application it references exists
+// - the names of its components are unique
+// - the container image of each component is a well-formed reference, which is pinned to a digest.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.0/pkg/reconcile
 func (r *ApplicationSnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
 
-	fmt.Println("ApplicationSnapshot event: ", req)
+	log := log.FromContext(ctx).WithValues("name", req.Name, "namespace", req.Namespace)
+	defer log.V(sharedutil.LogLevel_Debug).Info("Application Snapshot Reconcile() complete.")
+
+	snapshot := &appstudioshared.ApplicationSnapshot{}
+	if err := r.Client.Get(ctx, req.NamespacedName, snapshot); err != nil {
+		if apierr.IsNotFound(err) {
+			// Snapshot was deleted, so no work to do.
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("unable to retrieve Snapshot: %v", err)
+	}
+
+	reason, message, err := validateSnapshot(ctx, *snapshot, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	condition := metav1.Condition{
+		Type:    appstudioshared.ApplicationSnapshotCondition_Valid,
+		Status:  metav1.ConditionTrue,
+		Reason:  appstudioshared.ApplicationSnapshotReason_Valid,
+		Message: "Snapshot is valid",
+	}
+	if reason != "" {
+		log.V(sharedutil.LogLevel_Debug).Info("Snapshot is not valid", "reason", reason, "message", message)
+
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reason
+		condition.Message = message
+	}
+
+	if existing := meta.FindStatusCondition(snapshot.Status.Conditions, condition.Type); existing != nil &&
+		existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+		// The condition is already up to date
+		return ctrl.Result{}, nil
+	}
+
+	meta.SetStatusCondition(&snapshot.Status.Conditions, condition)
+	if err := r.Client.Status().Update(ctx, snapshot); err != nil {
+		if apierr.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("unable to update Snapshot status: %v", err)
+	}
+	sharedutil.LogAPIResourceChangeEvent(snapshot.Namespace, snapshot.Name, snapshot, sharedutil.ResourceModified, log)
 
 	return ctrl.Result{}, nil
 }
 
+// validateSnapshot checks the contents of the Snapshot. If the Snapshot is not valid, the reason and a
+// user-facing message are returned (the reason is empty if the Snapshot is valid). A non-nil error indicates that
+// the validation could not be performed.
+func validateSnapshot(ctx context.Context, snapshot appstudioshared.ApplicationSnapshot, k8sClient client.Client) (string, string, error) {
+
+	application := &applicationv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      snapshot.Spec.Application,
+			Namespace: snapshot.Namespace,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(application), application); err != nil {
+		if apierr.IsNotFound(err) {
+			return appstudioshared.ApplicationSnapshotReason_ApplicationNotFound,
+				fmt.Sprintf("the Application '%s' referenced by the Snapshot was not found", snapshot.Spec.Application), nil
+		}
+		return "", "", fmt.Errorf("unable to retrieve Application '%s': %v", snapshot.Spec.Application, err)
+	}
+
+	componentNames := map[string]bool{}
+	for _, component := range snapshot.Spec.Components {
+		if componentNames[component.Name] {
+			return appstudioshared.ApplicationSnapshotReason_DuplicateComponentName,
+				fmt.Sprintf("the component name '%s' is used by more than one component of the Snapshot", component.Name), nil
+		}
+		componentNames[component.Name] = true
+
+		if err := validateContainerImage(component.ContainerImage); err != nil {
+			return appstudioshared.ApplicationSnapshotReason_InvalidContainerImage,
+				fmt.Sprintf("the container image of component '%s' is not valid: %v", component.Name, err), nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// validateContainerImage returns an error if the container image is not a well-formed image reference that
+// is pinned to a digest, for example 'quay.io/org/image@sha256:(...)'.
+func validateContainerImage(containerImage string) error {
+
+	named, err := reference.ParseNormalizedNamed(containerImage)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a well-formed container image reference: %v", containerImage, err)
+	}
+
+	if _, isDigested := named.(reference.Digested); !isDigested {
+		return fmt.Errorf("'%s' is not pinned to a digest", containerImage)
+	}
+
+	return nil
+}
+
+// checkSnapshotIsValid returns an error if the Snapshot was marked as invalid by the ApplicationSnapshot controller.
+// Snapshots that have not yet been validated are not considered invalid.
+func checkSnapshotIsValid(snapshot appstudioshared.ApplicationSnapshot) error {
+
+	condition := meta.FindStatusCondition(snapshot.Status.Conditions, appstudioshared.ApplicationSnapshotCondition_Valid)
+	if condition != nil && condition.Status == metav1.ConditionFalse {
+		return fmt.Errorf("the Snapshot '%s' is not valid: %s", snapshot.Name, condition.Message)
+	}
+
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ApplicationSnapshotReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&appstudioshared.ApplicationSnapshot{}).
+		Watches(&source.Kind{Type: &applicationv1alpha1.Application{}},
+			handler.EnqueueRequestsFromMapFunc(r.findSnapshotsForApplication)).
 		Complete(r)
 }
+
+// findSnapshotsForApplication returns a request for each Snapshot that references the given Application, so that
+// Snapshots are revalidated when the Application is created or deleted.
+func (r *ApplicationSnapshotReconciler) findSnapshotsForApplication(application client.Object) []reconcile.Request {
+
+	snapshotList := &appstudioshared.ApplicationSnapshotList{}
+	if err := r.Client.List(context.Background(), snapshotList, &client.ListOptions{Namespace: application.GetNamespace()}); err != nil {
+		log.Log.Error(err, "unable to list Snapshots for Application", "application", application.GetName())
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for _, snapshot := range snapshotList.Items {
+		if snapshot.Spec.Application == application.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: snapshot.Name, Namespace: snapshot.Namespace},
+			})
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,166 @@
+package appstudioredhatcom
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	applicationv1alpha1 "github.com/redhat-appstudio/application-service/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appstudioshared "github.com/redhat-appstudio/managed-gitops/appstudio-shared/apis/appstudio.redhat.com/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+)
+
+const testDigestPinnedImage = "quay.io/jgwest-redhat/sample-workload@sha256:8a01fd8a5ff6c7cd2a8b7b4b5e7e5d0d2b4b0bde6a4f9e3c3b3aa7a9a1e0f0c1"
+
+var _ = Describe("ApplicationSnapshot Reconciler Tests", func() {
+
+	Context("Testing ApplicationSnapshotReconciler.", func() {
+
+		var ctx context.Context
+		var k8sClient client.Client
+		var snapshotReconciler ApplicationSnapshotReconciler
+
+		var application *applicationv1alpha1.Application
+		var snapshot *appstudioshared.ApplicationSnapshot
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme,
+				argocdNamespace,
+				kubesystemNamespace,
+				apiNamespace,
+				err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+
+			err = appstudioshared.AddToScheme(scheme)
+			Expect(err).To(BeNil())
+
+			err = applicationv1alpha1.AddToScheme(scheme)
+			Expect(err).To(BeNil())
+
+			application = &applicationv1alpha1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "new-demo-app",
+					Namespace: apiNamespace.Name,
+				},
+			}
+
+			snapshot = &appstudioshared.ApplicationSnapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-snapshot",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudioshared.ApplicationSnapshotSpec{
+					Application: application.Name,
+					Components: []appstudioshared.ApplicationSnapshotComponent{
+						{
+							Name:           "component-a",
+							ContainerImage: testDigestPinnedImage,
+						},
+					},
+				},
+			}
+
+			// Create fake client
+			k8sClient = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(apiNamespace, argocdNamespace, kubesystemNamespace, application).
+				Build()
+
+			snapshotReconciler = ApplicationSnapshotReconciler{Client: k8sClient, Scheme: scheme}
+		})
+
+		// reconcileAndGetValidCondition reconciles the Snapshot, and returns its 'Valid' condition
+		reconcileAndGetValidCondition := func() *metav1.Condition {
+			_, err := snapshotReconciler.Reconcile(ctx, newRequest(snapshot.Namespace, snapshot.Name))
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(snapshot), snapshot)
+			Expect(err).To(BeNil())
+
+			condition := meta.FindStatusCondition(snapshot.Status.Conditions, appstudioshared.ApplicationSnapshotCondition_Valid)
+			Expect(condition).ToNot(BeNil())
+			return condition
+		}
+
+		It("should set the Valid condition to True for a valid Snapshot", func() {
+			err := k8sClient.Create(ctx, snapshot)
+			Expect(err).To(BeNil())
+
+			condition := reconcileAndGetValidCondition()
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(appstudioshared.ApplicationSnapshotReason_Valid))
+			Expect(checkSnapshotIsValid(*snapshot)).To(Succeed())
+		})
+
+		It("should set the Valid condition to False if the Application does not exist, and to True once it does", func() {
+			err := k8sClient.Delete(ctx, application)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Create(ctx, snapshot)
+			Expect(err).To(BeNil())
+
+			condition := reconcileAndGetValidCondition()
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(appstudioshared.ApplicationSnapshotReason_ApplicationNotFound))
+			Expect(checkSnapshotIsValid(*snapshot)).ToNot(Succeed())
+
+			By("creating the Application, which should make the Snapshot valid")
+			application.ResourceVersion = ""
+			err = k8sClient.Create(ctx, application)
+			Expect(err).To(BeNil())
+
+			Expect(snapshotReconciler.findSnapshotsForApplication(application)).To(HaveLen(1))
+
+			condition = reconcileAndGetValidCondition()
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("should set the Valid condition to False if component names are not unique", func() {
+			snapshot.Spec.Components = append(snapshot.Spec.Components, snapshot.Spec.Components[0])
+			err := k8sClient.Create(ctx, snapshot)
+			Expect(err).To(BeNil())
+
+			condition := reconcileAndGetValidCondition()
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(appstudioshared.ApplicationSnapshotReason_DuplicateComponentName))
+		})
+
+		It("should set the Valid condition to False if a container image is not pinned to a digest", func() {
+			snapshot.Spec.Components[0].ContainerImage = "quay.io/jgwest-redhat/sample-workload:latest"
+			err := k8sClient.Create(ctx, snapshot)
+			Expect(err).To(BeNil())
+
+			condition := reconcileAndGetValidCondition()
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(appstudioshared.ApplicationSnapshotReason_InvalidContainerImage))
+			Expect(condition.Message).To(ContainSubstring("component-a"))
+		})
+
+		DescribeTable("validateContainerImage",
+			func(containerImage string, expectValid bool) {
+				err := validateContainerImage(containerImage)
+				if expectValid {
+					Expect(err).To(BeNil())
+				} else {
+					Expect(err).ToNot(BeNil())
+				}
+			},
+			Entry("digest-pinned image", testDigestPinnedImage, true),
+			Entry("digest-pinned image with tag", "quay.io/org/image:v1@sha256:8a01fd8a5ff6c7cd2a8b7b4b5e7e5d0d2b4b0bde6a4f9e3c3b3aa7a9a1e0f0c1", true),
+			Entry("digest-pinned image on Docker Hub", "nginx@sha256:8a01fd8a5ff6c7cd2a8b7b4b5e7e5d0d2b4b0bde6a4f9e3c3b3aa7a9a1e0f0c1", true),
+			Entry("tagged image", "quay.io/org/image:latest", false),
+			Entry("untagged image", "quay.io/org/image", false),
+			Entry("malformed digest", "quay.io/org/image@sha256:1234", false),
+			Entry("uppercase repository", "quay.io/Org/Image@sha256:8a01fd8a5ff6c7cd2a8b7b4b5e7e5d0d2b4b0bde6a4f9e3c3b3aa7a9a1e0f0c1", false),
+			Entry("empty image", "", false),
+		)
+	})
+})
@@ -22,16 +22,23 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/go-logr/logr"
 	appstudioshared "github.com/redhat-appstudio/managed-gitops/appstudio-shared/apis/appstudio.redhat.com/v1alpha1"
 	apibackend "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	corev1 "k8s.io/api/core/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/yaml"
 )
 
 // ApplicationSnapshotEnvironmentBindingReconciler reconciles a ApplicationSnapshotEnvironmentBinding object
@@ -70,7 +77,8 @@ func (r *ApplicationSnapshotEnvironmentBindingReconciler) Reconcile(ctx context.
 	}
 	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(&environment), &environment); err != nil {
 		if apierr.IsNotFound(err) {
-			return ctrl.Result{}, nil
+			return ctrl.Result{}, r.setErrorOccurredCondition(ctx, binding, appstudioshared.BindingReason_EnvironmentNotFound,
+				fmt.Errorf("the Environment '%s' referenced by the Binding was not found", environment.Name), log)
 		} else {
 			return ctrl.Result{}, fmt.Errorf("unable to retrieve Environment '%s' referenced by Binding: %v", environment.Name, err)
 		}
@@ -85,19 +93,37 @@ func (r *ApplicationSnapshotEnvironmentBindingReconciler) Reconcile(ctx context.
 		// if the ApplicationSnapshotEventBinding GitOps Repo Conditions status is false - return;
 		// since there was an unexpected issue with refreshing/syncing the GitOps repository
 		log.V(sharedutil.LogLevel_Debug).Info("Can not Reconcile Binding '" + binding.Name + "', since GitOps Repo Conditions status is false.")
-		// TODO: GITOPSRVCE-182: Add this to .status.conditions field once it is implemented.
-		return ctrl.Result{}, nil
+
+		lastGitOpsRepoCondition := binding.Status.GitOpsRepoConditions[len(binding.Status.GitOpsRepoConditions)-1]
+
+		return ctrl.Result{}, r.setErrorOccurredCondition(ctx, binding, appstudioshared.BindingReason_GitOpsRepositoryError,
+			fmt.Errorf("the GitOps repository of the Binding could not be generated/processed: %s", lastGitOpsRepoCondition.Message), log)
 
 	} else if len(binding.Status.Components) == 0 {
 
 		log.V(sharedutil.LogLevel_Debug).Info("ApplicationSnapshotEventBinding Component status is required to "+
 			"generate GitOps deployment, waiting for the Application Service controller to finish reconciling binding", "bindingName", binding.Name)
 
-		// TODO: GITOPSRVCE-182: Add this to .status.conditions field once it is implemented.
-
 		// if length of the Binding component status is 0 and there is no issue with the GitOps Repo Conditions;
 		// the Application Service controller has not synced the GitOps repository yet, return and requeue.
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, r.setErrorOccurredCondition(ctx, binding, appstudioshared.BindingReason_ComponentStatusMissing,
+			fmt.Errorf("%s: waiting for the Application Service controller to finish reconciling the Binding", errComponentStatusMissing), log)
+	}
+
+	// Don't deploy the Snapshot if it was marked as invalid by the ApplicationSnapshot controller
+	snapshot := appstudioshared.ApplicationSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      binding.Spec.Snapshot,
+			Namespace: req.Namespace,
+		},
+	}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(&snapshot), &snapshot); err != nil {
+		if !apierr.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("unable to retrieve Snapshot '%s' referenced by Binding: %v", snapshot.Name, err)
+		}
+	} else if err := checkSnapshotIsValid(snapshot); err != nil {
+		log.V(sharedutil.LogLevel_Debug).Info("Can not Reconcile Binding '"+binding.Name+"', since the Snapshot is not valid", "error", err.Error())
+		return ctrl.Result{}, r.setErrorOccurredCondition(ctx, binding, appstudioshared.BindingReason_InvalidSnapshot, err, log)
 	}
 
 	// map: componentName (string) -> expected GitOpsDeployment for that component name
@@ -107,14 +133,17 @@ func (r *ApplicationSnapshotEnvironmentBindingReconciler) Reconcile(ctx context.
 
 		// sanity test that there are no duplicate components by name
 		if _, exists := expectedDeployments[component.Name]; exists {
-			// TODO: GITOPSRVCE-182: Add this to .status.conditions field once it is implemented.
-			log.Error(nil, fmt.Sprintf("%s: %s", errDuplicateKeysFound, component.Name))
-			return ctrl.Result{}, nil
+			err := fmt.Errorf("%s: %s", errDuplicateKeysFound, component.Name)
+			log.Error(err, "unable to generate GitOpsDeployments for Binding")
+			return ctrl.Result{}, r.setErrorOccurredCondition(ctx, binding, appstudioshared.BindingReason_DuplicateComponents, err, log)
 		}
 
 		var err error
 		expectedDeployments[component.Name], err = generateExpectedGitOpsDeployment(component, *binding, environment)
 		if err != nil {
+			if conditionErr := r.setErrorOccurredCondition(ctx, binding, appstudioshared.BindingReason_MissingTargetNamespace, err, log); conditionErr != nil {
+				return ctrl.Result{}, conditionErr
+			}
 			return ctrl.Result{RequeueAfter: time.Second * 10}, fmt.Errorf("invalid target namespace: %v", err)
 		}
 	}
@@ -147,8 +176,27 @@ func (r *ApplicationSnapshotEnvironmentBindingReconciler) Reconcile(ctx context.
 		}
 	}
 
+	// Delete any GitOpsDeployments owned by the Binding whose component is no longer part of the Binding
+	if err := deleteOrphanedGitOpsDeployments(ctx, expectedDeployments, *binding, r.Client); err != nil {
+
+		errorMessage := fmt.Sprintf("Error occurred while deleting orphaned GitOpsDeployments of Binding '%s'", binding.Name)
+		log.Error(err, errorMessage)
+
+		if allErrors == nil {
+			allErrors = fmt.Errorf("%s, error: %w", errorMessage, err)
+		} else {
+			allErrors = fmt.Errorf("%s.\n%s, error: %w", allErrors.Error(), errorMessage, err)
+		}
+	}
+
 	// Update the status field with statusField vars (even if an error occurred)
 	binding.Status.GitOpsDeployments = statusField
+	if allErrors != nil {
+		updateErrorOccurredCondition(&binding.Status.Conditions, appstudioshared.BindingStatusCondition_ErrorOccurred,
+			appstudioshared.BindingReason_GitOpsDeploymentFailure, allErrors)
+	} else {
+		updateErrorOccurredCondition(&binding.Status.Conditions, appstudioshared.BindingStatusCondition_ErrorOccurred, "", nil)
+	}
 	if err := r.Client.Status().Update(ctx, binding); err != nil {
 		if apierr.IsNotFound(err) {
 			return ctrl.Result{}, nil
@@ -169,8 +217,29 @@ func (r *ApplicationSnapshotEnvironmentBindingReconciler) Reconcile(ctx context.
 const (
 	errDuplicateKeysFound     = "duplicate component keys found in status field"
 	errMissingTargetNamespace = "TargetNamespace field of Environment was empty"
+	errComponentStatusMissing = "component status is required to generate GitOps deployment"
 )
 
+// setErrorOccurredCondition updates the ErrorOccurred condition of the Binding, and updates the Binding status
+// if the condition changed.
+func (r *ApplicationSnapshotEnvironmentBindingReconciler) setErrorOccurredCondition(ctx context.Context,
+	binding *appstudioshared.ApplicationSnapshotEnvironmentBinding, reason string, errMessage error, log logr.Logger) error {
+
+	if !updateErrorOccurredCondition(&binding.Status.Conditions, appstudioshared.BindingStatusCondition_ErrorOccurred, reason, errMessage) {
+		return nil
+	}
+
+	if err := r.Client.Status().Update(ctx, binding); err != nil {
+		if apierr.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to update conditions of Binding '%s': %v", binding.Name, err)
+	}
+	sharedutil.LogAPIResourceChangeEvent(binding.Namespace, binding.Name, binding, sharedutil.ResourceModified, log)
+
+	return nil
+}
+
 // processExpectedGitOpsDeployment processed the GitOpsDeployment that is expected for a particular Component
 func processExpectedGitOpsDeployment(ctx context.Context, expectedGitopsDeployment apibackend.GitOpsDeployment,
 	binding appstudioshared.ApplicationSnapshotEnvironmentBinding, k8sClient client.Client) error {
@@ -211,19 +280,70 @@ func processExpectedGitOpsDeployment(ctx context.Context, expectedGitopsDeployme
 	return nil
 }
 
-// GenerateBindingGitOpsDeploymentName generates the name that will be used for a given GitOpsDeployment of a binding
+// deleteOrphanedGitOpsDeployments deletes the GitOpsDeployments that are owned by the Binding, but which are not in
+// the list of expected GitOpsDeployments: for example, because the corresponding component was removed from the Application.
+func deleteOrphanedGitOpsDeployments(ctx context.Context, expectedDeployments map[string]apibackend.GitOpsDeployment,
+	binding appstudioshared.ApplicationSnapshotEnvironmentBinding, k8sClient client.Client) error {
+
+	log := log.FromContext(ctx).WithValues("binding", binding.Name, "namespace", binding.Namespace)
+
+	expectedNames := map[string]bool{}
+	for _, expectedGitOpsDeployment := range expectedDeployments {
+		expectedNames[expectedGitOpsDeployment.Name] = true
+	}
+
+	gitopsDeploymentList := apibackend.GitOpsDeploymentList{}
+	if err := k8sClient.List(ctx, &gitopsDeploymentList, &client.ListOptions{Namespace: binding.Namespace}); err != nil {
+		return fmt.Errorf("unable to list GitOpsDeployments in namespace '%s': %v", binding.Namespace, err)
+	}
+
+	for idx := range gitopsDeploymentList.Items {
+		gitopsDeployment := gitopsDeploymentList.Items[idx]
+
+		if expectedNames[gitopsDeployment.Name] || !isOwnedByBinding(gitopsDeployment, binding) {
+			continue
+		}
+
+		if err := k8sClient.Delete(ctx, &gitopsDeployment); err != nil {
+			if apierr.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("unable to delete orphaned GitOpsDeployment '%s': %v", gitopsDeployment.Name, err)
+		}
+		sharedutil.LogAPIResourceChangeEvent(gitopsDeployment.Namespace, gitopsDeployment.Name, gitopsDeployment, sharedutil.ResourceDeleted, log)
+	}
+
+	return nil
+}
+
+// isOwnedByBinding returns true if the GitOpsDeployment has an owner reference to the Binding.
+func isOwnedByBinding(gitopsDeployment apibackend.GitOpsDeployment, binding appstudioshared.ApplicationSnapshotEnvironmentBinding) bool {
+	for _, ownerRef := range gitopsDeployment.OwnerReferences {
+		if ownerRef.Name == binding.Name && ownerRef.UID == binding.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// maxBindingGitOpsDeploymentNameLength is the maximum length of the full (binding-application-environment-component) name
+// of the GitOpsDeployments generated for a Binding: longer names are shortened to binding-component.
+const maxBindingGitOpsDeploymentNameLength = 250
+
+// GenerateBindingGitOpsDeploymentName generates the name that will be used for a given GitOpsDeployment of a binding.
+// The generated name is deterministic, and is always a valid Kubernetes resource name.
 func GenerateBindingGitOpsDeploymentName(binding appstudioshared.ApplicationSnapshotEnvironmentBinding, componentName string) string {
 
 	expectedName := binding.Name + "-" + binding.Spec.Application + "-" + binding.Spec.Environment + "-" + componentName
 
 	// If the length of the GitOpsDeployment exceeds the K8s maximum, shorten it to just binding+component
-	if len(expectedName) > 250 {
+	if len(expectedName) > maxBindingGitOpsDeploymentNameLength {
 		expectedName = binding.Name + "-" + componentName
 	}
-	// TODO: GITOPSRVCE-183: Improve the logic here; it is not guaranteed that the updated name will be valid (plus add tests).
-
-	return expectedName
 
+	// Only if the name is still too long for K8s, or contains invalid characters, sanitize and truncate it, and append a
+	// hash: names that are already valid must not change, as otherwise the existing GitOpsDeployments would be replaced.
+	return sharedutil.GenerateValidResourceName(expectedName, "", 0)
 }
 
 func generateExpectedGitOpsDeployment(component appstudioshared.ComponentStatus,
@@ -268,14 +388,179 @@ func generateExpectedGitOpsDeployment(component appstudioshared.ComponentStatus,
 		}
 	}
 
+	// Apply the component configuration from the Binding (and Environment), if any, as a kustomize patch
+	patch, err := generateComponentConfigurationPatch(component.Name, binding, environment)
+	if err != nil {
+		return apibackend.GitOpsDeployment{}, err
+	}
+	if patch != nil {
+		res.Spec.Source.Kustomize = &apibackend.ApplicationSourceKustomize{
+			Patches: []apibackend.KustomizePatch{*patch},
+		}
+	}
+
 	return res, nil
 }
 
+// componentContainerName is the name of the container, within the Deployment generated by the application-service
+// for a component, that runs the component's container image.
+const componentContainerName = "container-image"
+
+// generateComponentConfigurationPatch returns a kustomize patch that applies the configuration for the given component,
+// as defined on the Binding and Environment, to the component's Deployment.
+//
+// Environment variables from the Environment are merged with those from the Binding component (see mergeEnvVarPairs).
+// A replicas value of 0 is treated as unset, in which case the replica count from the GitOps repository is used.
+//
+// Returns nil if there is no configuration to apply.
+func generateComponentConfigurationPatch(componentName string, binding appstudioshared.ApplicationSnapshotEnvironmentBinding,
+	environment appstudioshared.Environment) (*apibackend.KustomizePatch, error) {
+
+	var componentConfig *appstudioshared.BindingComponentConfiguration
+	for idx := range binding.Spec.Components {
+		if binding.Spec.Components[idx].Name == componentName {
+			componentConfig = &binding.Spec.Components[idx].Configuration
+			break
+		}
+	}
+
+	var componentEnv []appstudioshared.EnvVarPair
+	var replicas int
+	var resources *corev1.ResourceRequirements
+
+	if componentConfig != nil {
+		componentEnv = componentConfig.Env
+		replicas = componentConfig.Replicas
+		resources = componentConfig.Resources
+	}
+
+	envVars := mergeEnvVarPairs(environment.Spec.Configuration.Env, componentEnv)
+
+	if len(envVars) == 0 && replicas <= 0 && resources == nil {
+		return nil, nil
+	}
+
+	spec := map[string]interface{}{}
+
+	if replicas > 0 {
+		spec["replicas"] = replicas
+	}
+
+	if len(envVars) > 0 || resources != nil {
+		container := map[string]interface{}{
+			"name": componentContainerName,
+		}
+
+		if len(envVars) > 0 {
+			env := []corev1.EnvVar{}
+			for _, envVar := range envVars {
+				env = append(env, corev1.EnvVar{Name: envVar.Name, Value: envVar.Value})
+			}
+			container["env"] = env
+		}
+
+		if resources != nil {
+			container["resources"] = resources
+		}
+
+		spec["template"] = map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{container},
+			},
+		}
+	}
+
+	patch := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name": componentName,
+		},
+		"spec": spec,
+	}
+
+	patchBytes, err := yaml.Marshal(patch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal configuration patch for component '%s': %v", componentName, err)
+	}
+
+	return &apibackend.KustomizePatch{
+		Patch: string(patchBytes),
+		Target: &apibackend.KustomizeSelector{
+			Group: "apps",
+			Kind:  "Deployment",
+			Name:  componentName,
+		},
+	}, nil
+}
+
+// mergeEnvVarPairs merges the environment variables of the Environment with those of the Binding component. Variables
+// from the component take precedence over Environment variables with the same name. The order of the Environment
+// variables is preserved, and component variables that are not defined by the Environment are appended.
+func mergeEnvVarPairs(environmentEnv []appstudioshared.EnvVarPair, componentEnv []appstudioshared.EnvVarPair) []appstudioshared.EnvVarPair {
+
+	res := []appstudioshared.EnvVarPair{}
+
+	componentValues := map[string]string{}
+	for _, envVar := range componentEnv {
+		componentValues[envVar.Name] = envVar.Value
+	}
+
+	seen := map[string]bool{}
+
+	for _, envVar := range environmentEnv {
+		if seen[envVar.Name] {
+			continue
+		}
+		seen[envVar.Name] = true
+
+		if value, exists := componentValues[envVar.Name]; exists {
+			envVar.Value = value
+		}
+		res = append(res, envVar)
+	}
+
+	for _, envVar := range componentEnv {
+		if seen[envVar.Name] {
+			continue
+		}
+		seen[envVar.Name] = true
+
+		res = append(res, appstudioshared.EnvVarPair{Name: envVar.Name, Value: componentValues[envVar.Name]})
+	}
+
+	return res
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ApplicationSnapshotEnvironmentBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		// Uncomment the following line adding a pointer to an instance of the controlled resource as an argument
 		For(&appstudioshared.ApplicationSnapshotEnvironmentBinding{}).
 		Owns(&apibackend.GitOpsDeployment{}).
+		Watches(&source.Kind{Type: &appstudioshared.ApplicationSnapshot{}},
+			handler.EnqueueRequestsFromMapFunc(r.findBindingsForSnapshot)).
 		Complete(r)
 }
+
+// findBindingsForSnapshot returns a request for each Binding that references the given Snapshot, so that Bindings are
+// reconciled when the Snapshot changes (for example, when an invalid Snapshot becomes valid).
+func (r *ApplicationSnapshotEnvironmentBindingReconciler) findBindingsForSnapshot(snapshot client.Object) []reconcile.Request {
+
+	bindingList := &appstudioshared.ApplicationSnapshotEnvironmentBindingList{}
+	if err := r.Client.List(context.Background(), bindingList, &client.ListOptions{Namespace: snapshot.GetNamespace()}); err != nil {
+		log.Log.Error(err, "unable to list Bindings for Snapshot", "snapshot", snapshot.GetName())
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for _, binding := range bindingList.Items {
+		if binding.Spec.Snapshot == snapshot.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: binding.Name, Namespace: binding.Namespace},
+			})
+		}
+	}
+
+	return requests
+}
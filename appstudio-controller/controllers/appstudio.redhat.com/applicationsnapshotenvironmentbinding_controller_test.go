@@ -7,11 +7,18 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
 
 	appstudiosharedv1 "github.com/redhat-appstudio/managed-gitops/appstudio-shared/apis/appstudio.redhat.com/v1alpha1"
 	apibackend "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
@@ -238,6 +245,33 @@ var _ = Describe("ApplicationSnapshotEnvironmentBinding Reconciler Tests", func(
 				To(Equal(binding.Name + "-" + binding.Spec.Components[0].Name))
 		})
 
+		DescribeTable("GenerateBindingGitOpsDeploymentName should always generate a valid GitOpsDeployment name",
+			func(bindingName string, applicationName string, environmentName string, componentName string, expected string) {
+				testBinding := appstudiosharedv1.ApplicationSnapshotEnvironmentBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: bindingName},
+					Spec: appstudiosharedv1.ApplicationSnapshotEnvironmentBindingSpec{
+						Application: applicationName,
+						Environment: environmentName,
+					},
+				}
+
+				res := GenerateBindingGitOpsDeploymentName(testBinding, componentName)
+				Expect(res).To(Equal(expected))
+				Expect(validation.IsDNS1123Subdomain(res)).To(BeEmpty())
+				Expect(len(res)).To(BeNumerically("<=", validation.DNS1123SubdomainMaxLength))
+			},
+			Entry("uses binding, application, environment and component names",
+				"binding", "app", "staging", "component-a", "binding-app-staging-component-a"),
+			Entry("uses only binding and component names if the full name is too long",
+				"binding", strings.Repeat("abcde", 50), "staging", "component-a", "binding-component-a"),
+			Entry("keeps the binding and component names unchanged, if they are a valid K8s name",
+				strings.Repeat("b", 240), "app", "staging", "component-a", strings.Repeat("b", 240)+"-component-a"),
+			Entry("truncates the name, and appends a hash, if the binding and component names are too long",
+				strings.Repeat("b", 245), "app", "staging", "component-a", strings.Repeat("b", 244)+"-fb19d1d4"),
+			Entry("sanitizes the name, and appends a hash, if the name contains invalid characters",
+				"binding", "app", "Staging", "component-a", "binding-app-staging-component-a-752e2e26"),
+		)
+
 		It("Should not return error if Status.Components is not available in Binding object.", func() {
 			binding.Status.Components = []appstudiosharedv1.ComponentStatus{}
 			// Create ApplicationSnapshotEnvironmentBinding CR in cluster.
@@ -247,10 +281,15 @@ var _ = Describe("ApplicationSnapshotEnvironmentBinding Reconciler Tests", func(
 			// Trigger Reconciler
 			_, err = bindingReconciler.Reconcile(ctx, request)
 			Expect(err).To(BeNil())
-			// Expect(err.Error()).To(Equal("ApplicationSnapshotEventBinding Component status is required to generate GitOps deployment, waiting for the Application Service controller to finish reconciling binding appa-staging-binding"))
 
-			// TODO: GITOPSRVCE-182: Once GITOPSRVCE-182 is implemented, check for the above message in the condition.
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
 
+			condition := meta.FindStatusCondition(binding.Status.Conditions, appstudiosharedv1.BindingStatusCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(appstudiosharedv1.BindingReason_ComponentStatusMissing))
+			Expect(condition.Message).To(ContainSubstring(errComponentStatusMissing))
 		})
 
 		It("Should return error if Status.GitOpsRepoConditions Status is set to False in Binding object.", func() {
@@ -267,6 +306,62 @@ var _ = Describe("ApplicationSnapshotEnvironmentBinding Reconciler Tests", func(
 			// Trigger Reconciler
 			_, err = bindingReconciler.Reconcile(ctx, request)
 			Expect(err).To(BeNil())
+
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
+
+			condition := meta.FindStatusCondition(binding.Status.Conditions, appstudiosharedv1.BindingStatusCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(appstudiosharedv1.BindingReason_GitOpsRepositoryError))
+		})
+
+		It("should not create GitOpsDeployments if the Snapshot of the Binding is not valid", func() {
+			snapshot := &appstudiosharedv1.ApplicationSnapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      binding.Spec.Snapshot,
+					Namespace: binding.Namespace,
+				},
+				Spec: appstudiosharedv1.ApplicationSnapshotSpec{
+					Application: binding.Spec.Application,
+				},
+				Status: appstudiosharedv1.ApplicationSnapshotStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               appstudiosharedv1.ApplicationSnapshotCondition_Valid,
+							Status:             metav1.ConditionFalse,
+							Reason:             appstudiosharedv1.ApplicationSnapshotReason_ApplicationNotFound,
+							LastTransitionTime: metav1.Now(),
+						},
+					},
+				},
+			}
+			err := bindingReconciler.Create(ctx, snapshot)
+			Expect(err).To(BeNil())
+
+			err = bindingReconciler.Create(ctx, binding)
+			Expect(err).To(BeNil())
+
+			_, err = bindingReconciler.Reconcile(ctx, request)
+			Expect(err).To(BeNil())
+
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Status.GitOpsDeployments).To(BeEmpty())
+
+			condition := meta.FindStatusCondition(binding.Status.Conditions, appstudiosharedv1.BindingStatusCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(appstudiosharedv1.BindingReason_InvalidSnapshot))
+
+			By("verifying that the Binding is reconciled again when its Snapshot changes")
+			Expect(bindingReconciler.findBindingsForSnapshot(snapshot)).To(Equal([]reconcile.Request{request}))
+
+			otherSnapshot := &appstudiosharedv1.ApplicationSnapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other-snapshot",
+					Namespace: binding.Namespace,
+				},
+			}
+			Expect(bindingReconciler.findBindingsForSnapshot(otherSnapshot)).To(BeEmpty())
 		})
 
 		It("should not return an error if there are duplicate components in binding.Status.Components", func() {
@@ -302,10 +397,14 @@ var _ = Describe("ApplicationSnapshotEnvironmentBinding Reconciler Tests", func(
 			_, err = bindingReconciler.Reconcile(ctx, request)
 			Expect(err).To(BeNil())
 
-			// Expect(strings.Contains(err.Error(), errDuplicateKeysFound)).To(BeTrue(),
-			// 	"duplicate components should be detected by the reconciler")
-			// TODO: GITOPSRVCE-182: Once GITOPSRVCE-182 is implemented, check for the above message in the condition.
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
 
+			condition := meta.FindStatusCondition(binding.Status.Conditions, appstudiosharedv1.BindingStatusCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(appstudiosharedv1.BindingReason_DuplicateComponents))
+			Expect(condition.Message).To(ContainSubstring(errDuplicateKeysFound))
 		})
 
 		It("should verify that if the Environment contains configuration information, that it is included in the generate GitOpsDeployment", func() {
@@ -371,6 +470,194 @@ var _ = Describe("ApplicationSnapshotEnvironmentBinding Reconciler Tests", func(
 			Expect(err).ToNot(BeNil())
 			Expect(strings.Contains(err.Error(), errMissingTargetNamespace)).To(BeTrue())
 
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
+
+			condition := meta.FindStatusCondition(binding.Status.Conditions, appstudiosharedv1.BindingStatusCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(appstudiosharedv1.BindingReason_MissingTargetNamespace))
+		})
+
+		It("should apply the component configuration of the Binding and Environment to the GitOpsDeployment as a kustomize patch", func() {
+
+			By("adding environment variables to the Environment, one of which is also defined by the Binding component")
+			environment.Spec.Configuration.Env = []appstudiosharedv1.EnvVarPair{
+				{Name: "My_STG_ENV", Value: "1"},
+				{Name: "My_ENV_LEVEL_VAR", Value: "env-value"},
+			}
+			err := bindingReconciler.Client.Update(ctx, &environment)
+			Expect(err).To(BeNil())
+
+			By("adding resources to the Binding component")
+			binding.Spec.Components[0].Configuration.Resources = &corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("500m"),
+				},
+			}
+			err = bindingReconciler.Client.Create(ctx, binding)
+			Expect(err).To(BeNil())
+
+			By("calling Reconcile")
+			_, err = bindingReconciler.Reconcile(ctx, request)
+			Expect(err).To(BeNil())
+
+			gitopsDeploymentKey := client.ObjectKey{
+				Namespace: binding.Namespace,
+				Name:      GenerateBindingGitOpsDeploymentName(*binding, binding.Spec.Components[0].Name),
+			}
+			gitopsDeployment := &apibackend.GitOpsDeployment{}
+			err = bindingReconciler.Get(ctx, gitopsDeploymentKey, gitopsDeployment)
+			Expect(err).To(BeNil())
+
+			By("ensuring the GitOpsDeployment contains a patch that targets the component's Deployment")
+			Expect(gitopsDeployment.Spec.Source.Kustomize).ToNot(BeNil())
+			Expect(gitopsDeployment.Spec.Source.Kustomize.Patches).To(HaveLen(1))
+
+			patch := gitopsDeployment.Spec.Source.Kustomize.Patches[0]
+			Expect(patch.Target).ToNot(BeNil())
+			Expect(patch.Target.Kind).To(Equal("Deployment"))
+			Expect(patch.Target.Name).To(Equal("component-a"))
+
+			deployment := appsv1.Deployment{}
+			err = yaml.Unmarshal([]byte(patch.Patch), &deployment)
+			Expect(err).To(BeNil())
+
+			Expect(deployment.Name).To(Equal("component-a"))
+			Expect(deployment.Spec.Replicas).ToNot(BeNil())
+			Expect(*deployment.Spec.Replicas).To(Equal(int32(3)))
+
+			Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Name).To(Equal(componentContainerName))
+			Expect(container.Resources.Limits.Cpu().String()).To(Equal("500m"))
+
+			By("ensuring the Binding component's environment variable takes precedence over the Environment's")
+			Expect(container.Env).To(Equal([]corev1.EnvVar{
+				{Name: "My_STG_ENV", Value: "1000"},
+				{Name: "My_ENV_LEVEL_VAR", Value: "env-value"},
+			}))
+
+			By("removing the configuration from the Binding and Environment, and ensuring the patch is removed")
+			environment.Spec.Configuration.Env = nil
+			err = bindingReconciler.Client.Update(ctx, &environment)
+			Expect(err).To(BeNil())
+
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
+			binding.Spec.Components[0].Configuration = appstudiosharedv1.BindingComponentConfiguration{}
+			err = bindingReconciler.Client.Update(ctx, binding)
+			Expect(err).To(BeNil())
+
+			_, err = bindingReconciler.Reconcile(ctx, request)
+			Expect(err).To(BeNil())
+
+			err = bindingReconciler.Get(ctx, gitopsDeploymentKey, gitopsDeployment)
+			Expect(err).To(BeNil())
+			Expect(gitopsDeployment.Spec.Source.Kustomize).To(BeNil())
+		})
+
+		It("should delete the GitOpsDeployment of a component that was removed from the Binding", func() {
+
+			By("creating a Binding with two components")
+			binding.Status.Components = append(binding.Status.Components, appstudiosharedv1.ComponentStatus{
+				Name: "component-b",
+				GitOpsRepository: appstudiosharedv1.BindingComponentGitOpsRepository{
+					URL:    "https://github.com/redhat-appstudio/gitops-repository-template",
+					Branch: "main",
+					Path:   "components/componentB/overlays/staging",
+				},
+			})
+			err := bindingReconciler.Client.Create(ctx, binding)
+			Expect(err).To(BeNil())
+
+			By("creating a GitOpsDeployment that is not owned by the Binding")
+			unrelatedGitOpsDeployment := &apibackend.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "unrelated-gitops-deployment",
+					Namespace: binding.Namespace,
+				},
+				Spec: apibackend.GitOpsDeploymentSpec{
+					Source: apibackend.ApplicationSource{
+						RepoURL: "https://github.com/redhat-appstudio/gitops-repository-template",
+						Path:    "environments/overlays/dev",
+					},
+					Type: apibackend.GitOpsDeploymentSpecType_Automated,
+				},
+			}
+			err = bindingReconciler.Client.Create(ctx, unrelatedGitOpsDeployment)
+			Expect(err).To(BeNil())
+
+			_, err = bindingReconciler.Reconcile(ctx, request)
+			Expect(err).To(BeNil())
+
+			gitopsDeploymentKeyA := client.ObjectKey{Namespace: binding.Namespace, Name: GenerateBindingGitOpsDeploymentName(*binding, "component-a")}
+			gitopsDeploymentKeyB := client.ObjectKey{Namespace: binding.Namespace, Name: GenerateBindingGitOpsDeploymentName(*binding, "component-b")}
+
+			gitopsDeployment := &apibackend.GitOpsDeployment{}
+			Expect(bindingReconciler.Get(ctx, gitopsDeploymentKeyA, gitopsDeployment)).To(Succeed())
+			Expect(bindingReconciler.Get(ctx, gitopsDeploymentKeyB, gitopsDeployment)).To(Succeed())
+
+			By("removing component-b from the Binding")
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
+			binding.Status.Components = binding.Status.Components[0:1]
+			err = bindingReconciler.Client.Status().Update(ctx, binding)
+			Expect(err).To(BeNil())
+
+			_, err = bindingReconciler.Reconcile(ctx, request)
+			Expect(err).To(BeNil())
+
+			By("ensuring only the GitOpsDeployment of component-b was deleted")
+			Expect(bindingReconciler.Get(ctx, gitopsDeploymentKeyA, gitopsDeployment)).To(Succeed())
+
+			err = bindingReconciler.Get(ctx, gitopsDeploymentKeyB, gitopsDeployment)
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			err = bindingReconciler.Get(ctx, client.ObjectKeyFromObject(unrelatedGitOpsDeployment), gitopsDeployment)
+			Expect(err).To(BeNil())
+
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Status.GitOpsDeployments).To(HaveLen(1))
+			Expect(binding.Status.GitOpsDeployments[0].ComponentName).To(Equal("component-a"))
+		})
+
+		It("should set an ErrorOccurred condition if the Environment does not exist, and resolve it once it does", func() {
+			err := bindingReconciler.Client.Delete(ctx, &environment)
+			Expect(err).To(BeNil())
+
+			err = bindingReconciler.Create(ctx, binding)
+			Expect(err).To(BeNil())
+
+			_, err = bindingReconciler.Reconcile(ctx, request)
+			Expect(err).To(BeNil())
+
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Status.GitOpsDeployments).To(BeEmpty())
+
+			condition := meta.FindStatusCondition(binding.Status.Conditions, appstudiosharedv1.BindingStatusCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(appstudiosharedv1.BindingReason_EnvironmentNotFound))
+
+			By("creating the Environment, and reconciling again")
+			environment.ResourceVersion = ""
+			err = bindingReconciler.Client.Create(ctx, &environment)
+			Expect(err).To(BeNil())
+
+			_, err = bindingReconciler.Reconcile(ctx, request)
+			Expect(err).To(BeNil())
+
+			err = bindingReconciler.Client.Get(ctx, request.NamespacedName, binding)
+			Expect(err).To(BeNil())
+			Expect(binding.Status.GitOpsDeployments).To(HaveLen(1))
+
+			condition = meta.FindStatusCondition(binding.Status.Conditions, appstudiosharedv1.BindingStatusCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(appstudiosharedv1.BindingReason_EnvironmentNotFound + "Resolved"))
 		})
 	})
 })
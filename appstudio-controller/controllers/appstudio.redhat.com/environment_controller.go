@@ -20,6 +20,9 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+
+	"github.com/go-logr/logr"
 
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 
@@ -28,11 +31,16 @@ import (
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // EnvironmentReconciler reconciles a Environment object
@@ -44,6 +52,10 @@ type EnvironmentReconciler struct {
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=environments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=environments/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=environments/finalizers,verbs=update
+//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=applicationsnapshotenvironmentbindings,verbs=get;list;watch
+//+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeploymentmanagedenvironments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeployments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -56,7 +68,8 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	// The goal of this function is to ensure that if an Environment exists, and that Environment
 	// has the 'kubernetesCredentials' field defined, that a corresponding
-	// GitOpsDeploymentManagedEnvironment exists (and is up-to-date).
+	// GitOpsDeploymentManagedEnvironment exists (and is up-to-date), and to report the result
+	// (and the GitOpsDeployments that target the Environment) in the Environment status.
 	environment := &appstudioshared.Environment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      req.Name,
@@ -75,12 +88,32 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, fmt.Errorf("unable to retrieve Environment: %v", err)
 	}
 
-	desiredManagedEnv, err := generateDesiredResource(ctx, *environment, r.Client)
+	managedEnv, reason, reconcileErr := r.reconcileManagedEnvironment(ctx, *environment, log)
+
+	if err := r.updateEnvironmentStatus(ctx, environment, managedEnv, reason, reconcileErr, log); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, reconcileErr
+}
+
+// reconcileManagedEnvironment ensures that, if the Environment has the 'kubernetesCredentials' field defined, a
+// corresponding GitOpsDeploymentManagedEnvironment exists (and is up-to-date).
+// Returns the GitOpsDeploymentManagedEnvironment (or nil if the Environment doesn't define the field), plus the
+// condition reason and error, if an error occurred.
+func (r *EnvironmentReconciler) reconcileManagedEnvironment(ctx context.Context, environment appstudioshared.Environment,
+	log logr.Logger) (*managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment, string, error) {
+
+	desiredManagedEnv, err := generateDesiredResource(ctx, environment, r.Client)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("unable to generate expected GitOpsDeploymentManagedEnvironment resource: %v", err)
+		reason := appstudioshared.EnvironmentReason_ManagedEnvironmentError
+		if apierr.IsNotFound(err) {
+			reason = appstudioshared.EnvironmentReason_SecretNotFound
+		}
+		return nil, reason, fmt.Errorf("unable to generate expected GitOpsDeploymentManagedEnvironment resource: %v", err)
 	}
 	if desiredManagedEnv == nil {
-		return ctrl.Result{}, nil
+		return nil, "", nil
 	}
 
 	currentManagedEnv := generateEmptyManagedEnvironment(environment.Name, environment.Namespace)
@@ -91,24 +124,25 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 			log.Info("Creating GitOpsDeploymentManagedEnvironment", "managedEnv", desiredManagedEnv.Name)
 			if err := r.Client.Create(ctx, desiredManagedEnv); err != nil {
-				return ctrl.Result{}, fmt.Errorf("unable to create new GitOpsDeploymentManagedEnvironment: %v", err)
+				return nil, appstudioshared.EnvironmentReason_ManagedEnvironmentError,
+					fmt.Errorf("unable to create new GitOpsDeploymentManagedEnvironment: %v", err)
 			}
 			sharedutil.LogAPIResourceChangeEvent(desiredManagedEnv.Namespace, desiredManagedEnv.Name, desiredManagedEnv, sharedutil.ResourceCreated, log)
 
 			// Success: the resource has been created.
-			return ctrl.Result{}, nil
+			return desiredManagedEnv, "", nil
 
 		} else {
 			// For any other error, return it
-			return ctrl.Result{}, fmt.Errorf("unable to retrieve existing GitOpsDeploymentManagedEnvironment '%s': %v",
-				currentManagedEnv.Name, err)
+			return nil, appstudioshared.EnvironmentReason_ManagedEnvironmentError,
+				fmt.Errorf("unable to retrieve existing GitOpsDeploymentManagedEnvironment '%s': %v", currentManagedEnv.Name, err)
 		}
 	}
 
 	// C) The GitOpsDeploymentManagedEnvironment already exists, so compare it with the desired state, and update it if different.
 	if reflect.DeepEqual(currentManagedEnv.Spec, desiredManagedEnv.Spec) {
 		// If the spec field is the same, no more work is needed.
-		return ctrl.Result{}, nil
+		return &currentManagedEnv, "", nil
 	}
 
 	log.Info("Updating GitOpsDeploymentManagedEnvironment as a change was detected", "managedEnv", desiredManagedEnv.Name)
@@ -117,36 +151,129 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	currentManagedEnv.Spec = desiredManagedEnv.Spec
 
 	if err := r.Client.Update(ctx, &currentManagedEnv); err != nil {
-		return ctrl.Result{},
+		return nil, appstudioshared.EnvironmentReason_ManagedEnvironmentError,
 			fmt.Errorf("unable to update existing GitOpsDeploymentManagedEnvironment '%s': %v", currentManagedEnv.Name, err)
 	}
 	sharedutil.LogAPIResourceChangeEvent(currentManagedEnv.Namespace, currentManagedEnv.Name, currentManagedEnv, sharedutil.ResourceModified, log)
 
-	return ctrl.Result{}, nil
+	return &currentManagedEnv, "", nil
+}
+
+// updateEnvironmentStatus updates the status of the Environment, based on the result of reconciling the
+// GitOpsDeploymentManagedEnvironment, and updates the Environment resource if the status has changed.
+func (r *EnvironmentReconciler) updateEnvironmentStatus(ctx context.Context, environment *appstudioshared.Environment,
+	managedEnv *managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment, reason string, reconcileErr error, log logr.Logger) error {
+
+	oldStatus := environment.Status.DeepCopy()
+
+	updateErrorOccurredCondition(&environment.Status.Conditions, appstudioshared.EnvironmentCondition_ErrorOccurred, reason, reconcileErr)
+
+	environment.Status.ManagedEnvironment = ""
+	if managedEnv != nil {
+		environment.Status.ManagedEnvironment = managedEnv.Name
+	}
+
+	if environment.Spec.UnstableConfigurationFields == nil {
+		meta.RemoveStatusCondition(&environment.Status.Conditions, appstudioshared.EnvironmentCondition_ConnectionInitializationSucceeded)
+	} else {
+		meta.SetStatusCondition(&environment.Status.Conditions, generateConnectionCondition(managedEnv))
+	}
+
+	gitopsDeployments, err := getGitOpsDeploymentsTargetingEnvironment(ctx, *environment, r.Client)
+	if err != nil {
+		return err
+	}
+	environment.Status.GitOpsDeployments = gitopsDeployments
+
+	if reflect.DeepEqual(*oldStatus, environment.Status) {
+		return nil
+	}
+
+	if err := r.Client.Status().Update(ctx, environment); err != nil {
+		if apierr.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to update status of Environment '%s': %v", environment.Name, err)
+	}
+	sharedutil.LogAPIResourceChangeEvent(environment.Namespace, environment.Name, environment, sharedutil.ResourceModified, log)
+
+	return nil
+}
+
+// generateConnectionCondition returns the ConnectionInitializationSucceeded condition of the Environment, based on the
+// corresponding condition of its GitOpsDeploymentManagedEnvironment.
+func generateConnectionCondition(managedEnv *managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment) metav1.Condition {
+
+	res := metav1.Condition{
+		Type:    appstudioshared.EnvironmentCondition_ConnectionInitializationSucceeded,
+		Status:  metav1.ConditionUnknown,
+		Reason:  appstudioshared.EnvironmentReason_ConnectionPending,
+		Message: "The GitOps Service has not yet verified the credentials of the target cluster",
+	}
+
+	if managedEnv == nil {
+		res.Message = "The GitOpsDeploymentManagedEnvironment of the Environment does not exist"
+		return res
+	}
+
+	managedEnvCondition := meta.FindStatusCondition(managedEnv.Status.Conditions,
+		managedgitopsv1alpha1.ManagedEnvironmentConditionConnectionInitializationSucceeded)
+	if managedEnvCondition == nil {
+		return res
+	}
+
+	res.Status = managedEnvCondition.Status
+	res.Reason = managedEnvCondition.Reason
+	res.Message = managedEnvCondition.Message
+
+	return res
 }
 
-// TODO: GITOPSRVCE-182: Uncomment this code and use it to implement conditions.
+// getGitOpsDeploymentsTargetingEnvironment returns the sorted names of the GitOpsDeployments that target the Environment:
+// - GitOpsDeployments that were generated by an ApplicationSnapshotEnvironmentBinding of the Environment
+// - GitOpsDeployments whose destination is the GitOpsDeploymentManagedEnvironment of the Environment
+func getGitOpsDeploymentsTargetingEnvironment(ctx context.Context, environment appstudioshared.Environment, k8sClient client.Client) ([]string, error) {
+
+	gitopsDeploymentNames := map[string]bool{}
 
-// const EnvironmentStatus_ConditionErrorOccurred = "ErrorOccurred"
+	bindingList := appstudioshared.ApplicationSnapshotEnvironmentBindingList{}
+	if err := k8sClient.List(ctx, &bindingList, &client.ListOptions{Namespace: environment.Namespace}); err != nil {
+		return nil, fmt.Errorf("unable to list Bindings in namespace '%s': %v", environment.Namespace, err)
+	}
+	for _, binding := range bindingList.Items {
+		if binding.Spec.Environment != environment.Name {
+			continue
+		}
+		for _, bindingGitOpsDeployment := range binding.Status.GitOpsDeployments {
+			gitopsDeploymentNames[bindingGitOpsDeployment.GitOpsDeployment] = true
+		}
+	}
 
-// func updateStatusConditions(err error) error {
+	if environment.Spec.UnstableConfigurationFields != nil {
 
-// 	message := ""
-// 	status := metav1.ConditionFalse
-// 	if err != nil {
-// 		status = metav1.ConditionTrue
-// 		message = err.Error()
-// 	}
+		managedEnvironmentName := generateEmptyManagedEnvironment(environment.Name, environment.Namespace).Name
 
-// 	newCondition := metav1.Condition{
-// 		Type:   "ErrorOccurred",
-// 		Status: status,
-// 		// ObservedGeneration: ,
-// 		LastTransitionTime: metav1.NewTime(time.Now()),
-// 		Reason:             EnvironmentStatus_ConditionErrorOccurred,
-// 		Message:            message,
-// 	}
-// }
+		gitopsDeploymentList := managedgitopsv1alpha1.GitOpsDeploymentList{}
+		if err := k8sClient.List(ctx, &gitopsDeploymentList, &client.ListOptions{Namespace: environment.Namespace}); err != nil {
+			return nil, fmt.Errorf("unable to list GitOpsDeployments in namespace '%s': %v", environment.Namespace, err)
+		}
+		for _, gitopsDeployment := range gitopsDeploymentList.Items {
+			if gitopsDeployment.Spec.Destination.Environment == managedEnvironmentName {
+				gitopsDeploymentNames[gitopsDeployment.Name] = true
+			}
+		}
+	}
+
+	var res []string
+	for name := range gitopsDeploymentNames {
+		if name != "" {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+
+	return res, nil
+}
 
 func generateDesiredResource(ctx context.Context, env appstudioshared.Environment, k8sClient client.Client) (*managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment, error) {
 
@@ -164,7 +291,7 @@ func generateDesiredResource(ctx context.Context, env appstudioshared.Environmen
 	}
 	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
 		if apierr.IsNotFound(err) {
-			return nil, fmt.Errorf("the secret '%s' referenced by the Environment resource was not found: %v", secret.Name, err)
+			return nil, fmt.Errorf("the secret '%s' referenced by the Environment resource was not found: %w", secret.Name, err)
 		}
 		return nil, err
 	}
@@ -190,7 +317,7 @@ func generateDesiredResource(ctx context.Context, env appstudioshared.Environmen
 func generateEmptyManagedEnvironment(environmentName string, environmentNamespace string) managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment {
 	res := managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "managed-environment-" + environmentName,
+			Name:      sharedutil.GenerateValidResourceName("managed-environment-"+environmentName, "", 0),
 			Namespace: environmentNamespace,
 		},
 	}
@@ -201,5 +328,68 @@ func generateEmptyManagedEnvironment(environmentName string, environmentNamespac
 func (r *EnvironmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&appstudioshared.Environment{}).
+		Watches(&source.Kind{Type: &managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{}},
+			handler.EnqueueRequestsFromMapFunc(findEnvironmentForManagedEnvironment)).
+		Watches(&source.Kind{Type: &appstudioshared.ApplicationSnapshotEnvironmentBinding{}},
+			handler.EnqueueRequestsFromMapFunc(findEnvironmentForBinding)).
+		Watches(&source.Kind{Type: &managedgitopsv1alpha1.GitOpsDeployment{}},
+			handler.EnqueueRequestsFromMapFunc(r.findEnvironmentForGitOpsDeployment)).
 		Complete(r)
 }
+
+// findEnvironmentForManagedEnvironment returns a request for the Environment that owns the given
+// GitOpsDeploymentManagedEnvironment, so that the Environment status is updated when the connection status changes.
+func findEnvironmentForManagedEnvironment(managedEnv client.Object) []reconcile.Request {
+
+	requests := []reconcile.Request{}
+	for _, ownerRef := range managedEnv.GetOwnerReferences() {
+		if ownerRef.Kind == "Environment" {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: ownerRef.Name, Namespace: managedEnv.GetNamespace()},
+			})
+		}
+	}
+
+	return requests
+}
+
+// findEnvironmentForBinding returns a request for the Environment referenced by the given Binding, so that the list of
+// GitOpsDeployments in the Environment status is updated when the Binding changes.
+func findEnvironmentForBinding(bindingObj client.Object) []reconcile.Request {
+
+	binding, ok := bindingObj.(*appstudioshared.ApplicationSnapshotEnvironmentBinding)
+	if !ok || binding.Spec.Environment == "" {
+		return []reconcile.Request{}
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: binding.Spec.Environment, Namespace: binding.Namespace}},
+	}
+}
+
+// findEnvironmentForGitOpsDeployment returns a request for the Environment whose GitOpsDeploymentManagedEnvironment is
+// the destination of the given GitOpsDeployment, so that the list of GitOpsDeployments in the Environment status is
+// updated when such a GitOpsDeployment is created, updated or deleted.
+func (r *EnvironmentReconciler) findEnvironmentForGitOpsDeployment(gitopsDeplObj client.Object) []reconcile.Request {
+
+	gitopsDepl, ok := gitopsDeplObj.(*managedgitopsv1alpha1.GitOpsDeployment)
+	if !ok || gitopsDepl.Spec.Destination.Environment == "" {
+		return []reconcile.Request{}
+	}
+
+	managedEnv := &managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gitopsDepl.Spec.Destination.Environment,
+			Namespace: gitopsDepl.Namespace,
+		},
+	}
+	if err := r.Client.Get(context.Background(), client.ObjectKeyFromObject(managedEnv), managedEnv); err != nil {
+		if !apierr.IsNotFound(err) {
+			log.Log.Error(err, "unable to retrieve GitOpsDeploymentManagedEnvironment of GitOpsDeployment",
+				"gitopsDeployment", gitopsDepl.Name, "managedEnvironment", managedEnv.Name)
+		}
+		return []reconcile.Request{}
+	}
+
+	return findEnvironmentForManagedEnvironment(managedEnv)
+}
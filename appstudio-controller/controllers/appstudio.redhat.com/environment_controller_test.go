@@ -2,6 +2,7 @@ package appstudioredhatcom
 
 import (
 	"context"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -11,8 +12,10 @@ import (
 
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -264,6 +267,15 @@ var _ = Describe("Environment controller tests", func() {
 			}
 			_, err = reconciler.Reconcile(ctx, req)
 			Expect(err).ToNot(BeNil())
+
+			By("ensuring the error is reported via the ErrorOccurred condition")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&env), &env)
+			Expect(err).To(BeNil())
+
+			condition := meta.FindStatusCondition(env.Status.Conditions, appstudioshared.EnvironmentCondition_ErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(appstudioshared.EnvironmentReason_SecretNotFound))
 		})
 
 		It("should not return an error if the Environment does not container UnstableConfigurationFields", func() {
@@ -352,5 +364,150 @@ var _ = Describe("Environment controller tests", func() {
 
 		})
 
+		It("should report the connection status of the GitOpsDeploymentManagedEnvironment, and the GitOpsDeployments that target the Environment", func() {
+
+			secret := corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-my-managed-env-secret",
+					Namespace: apiNamespace.Name,
+				},
+				Type: sharedutil.ManagedEnvironmentSecretType,
+				Data: map[string][]byte{
+					"kubeconfig": ([]byte)("{}"),
+				},
+			}
+			err := k8sClient.Create(ctx, &secret)
+			Expect(err).To(BeNil())
+
+			env := appstudioshared.Environment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-env",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudioshared.EnvironmentSpec{
+					Type:               appstudioshared.EnvironmentType_POC,
+					DisplayName:        "my-environment",
+					DeploymentStrategy: appstudioshared.DeploymentStrategy_Manual,
+					Configuration:      appstudioshared.EnvironmentConfiguration{},
+					UnstableConfigurationFields: &appstudioshared.UnstableEnvironmentConfiguration{
+						KubernetesClusterCredentials: appstudioshared.KubernetesClusterCredentials{
+							TargetNamespace:          "my-target-namespace",
+							APIURL:                   "https://my-api-url",
+							ClusterCredentialsSecret: secret.Name,
+						},
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, &env)
+			Expect(err).To(BeNil())
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: env.Name, Namespace: env.Namespace}}
+
+			By("reconciling, before the GitOps Service has verified the credentials of the managed environment")
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&env), &env)
+			Expect(err).To(BeNil())
+
+			managedEnv := generateEmptyManagedEnvironment(env.Name, env.Namespace)
+			Expect(env.Status.ManagedEnvironment).To(Equal(managedEnv.Name))
+			Expect(meta.FindStatusCondition(env.Status.Conditions, appstudioshared.EnvironmentCondition_ErrorOccurred)).To(BeNil())
+
+			condition := meta.FindStatusCondition(env.Status.Conditions, appstudioshared.EnvironmentCondition_ConnectionInitializationSucceeded)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(condition.Reason).To(Equal(appstudioshared.EnvironmentReason_ConnectionPending))
+
+			By("simulating the GitOps Service reporting that the credentials were verified")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&managedEnv), &managedEnv)
+			Expect(err).To(BeNil())
+			meta.SetStatusCondition(&managedEnv.Status.Conditions, metav1.Condition{
+				Type:    managedgitopsv1alpha1.ManagedEnvironmentConditionConnectionInitializationSucceeded,
+				Status:  metav1.ConditionTrue,
+				Reason:  managedgitopsv1alpha1.ManagedEnvironmentReasonSucceeded,
+				Message: "connected",
+			})
+			err = k8sClient.Status().Update(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			By("creating a Binding and a GitOpsDeployment that target the Environment, and one that does not")
+			binding := appstudioshared.ApplicationSnapshotEnvironmentBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-binding",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudioshared.ApplicationSnapshotEnvironmentBindingSpec{
+					Application: "my-app",
+					Environment: env.Name,
+					Snapshot:    "my-snapshot",
+				},
+				Status: appstudioshared.ApplicationSnapshotEnvironmentBindingStatus{
+					GitOpsDeployments: []appstudioshared.BindingStatusGitOpsDeployment{
+						{ComponentName: "component-a", GitOpsDeployment: "binding-gitops-deployment"},
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, &binding)
+			Expect(err).To(BeNil())
+
+			for _, gitopsDeployment := range []managedgitopsv1alpha1.GitOpsDeployment{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "targets-environment", Namespace: apiNamespace.Name},
+					Spec: managedgitopsv1alpha1.GitOpsDeploymentSpec{
+						Destination: managedgitopsv1alpha1.ApplicationDestination{Environment: managedEnv.Name},
+						Type:        managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "targets-workspace", Namespace: apiNamespace.Name},
+					Spec: managedgitopsv1alpha1.GitOpsDeploymentSpec{
+						Type: managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated,
+					},
+				},
+			} {
+				gitopsDeployment := gitopsDeployment
+				err = k8sClient.Create(ctx, &gitopsDeployment)
+				Expect(err).To(BeNil())
+			}
+
+			By("reconciling again, and ensuring the status reflects the connection status and the GitOpsDeployments")
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&env), &env)
+			Expect(err).To(BeNil())
+
+			condition = meta.FindStatusCondition(env.Status.Conditions, appstudioshared.EnvironmentCondition_ConnectionInitializationSucceeded)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(managedgitopsv1alpha1.ManagedEnvironmentReasonSucceeded))
+
+			Expect(env.Status.GitOpsDeployments).To(Equal([]string{"binding-gitops-deployment", "targets-environment"}))
+
+			By("ensuring that a change to a GitOpsDeployment that targets the Environment reconciles the Environment")
+			gitopsDeployment := &managedgitopsv1alpha1.GitOpsDeployment{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "targets-environment", Namespace: apiNamespace.Name}, gitopsDeployment)
+			Expect(err).To(BeNil())
+			Expect(reconciler.findEnvironmentForGitOpsDeployment(gitopsDeployment)).To(Equal([]ctrl.Request{req}))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "targets-workspace", Namespace: apiNamespace.Name}, gitopsDeployment)
+			Expect(err).To(BeNil())
+			Expect(reconciler.findEnvironmentForGitOpsDeployment(gitopsDeployment)).To(BeEmpty())
+		})
+
+		It("should only change the name of the generated managed environment if it would not be a valid name", func() {
+
+			By("keeping the existing name, when it is valid")
+			envName := strings.Repeat("e", 233)
+			Expect(generateEmptyManagedEnvironment(envName, "my-namespace").Name).To(Equal("managed-environment-" + envName))
+
+			By("generating a valid name, when the existing name would be too long")
+			envName = strings.Repeat("e", 234)
+			managedEnvName := generateEmptyManagedEnvironment(envName, "my-namespace").Name
+			Expect(managedEnvName).To(HavePrefix("managed-environment-eee"))
+			Expect(validation.IsDNS1123Subdomain(managedEnvName)).To(BeEmpty())
+		})
+
 	})
 })
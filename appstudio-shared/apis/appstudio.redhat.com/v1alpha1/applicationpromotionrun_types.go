@@ -39,6 +39,12 @@ type ApplicationPromotionRunSpec struct {
 	// AutomatedPromotion is for fields specific to automated promotion
 	// Only one field should be defined: either 'manualPromotion' or 'automatedPromotion', but not both.
 	AutomatedPromotion AutomatedPromotionConfiguration `json:"automatedPromotion,omitempty"`
+
+	// Timeout is the maximum amount of time the promotion may take, measured from when the promotion becomes active.
+	// If the promotion has not completed by then, it is marked as complete, with a result of 'Failure'.
+	// If not specified, a default of 30 minutes is used.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
 }
 
 // ManualPromotionConfiguration defines promotion parameters specific to manual promotion: the target environment to promote to.
@@ -49,6 +55,9 @@ type ManualPromotionConfiguration struct {
 
 // AutomatedPromotionConfiguration defines promotion parameters specific to automated promotion: the initial environment
 // (in the promotion graph) to begin promoting on.
+//
+// Once the promotion to an environment succeeds, the Environments which reference it via 'parentEnvironment' (and which
+// have an 'AppStudioAutomated' deployment strategy) are promoted to, one at a time. The promotion halts on the first failure.
 type AutomatedPromotionConfiguration struct {
 	// InitialEnvironment: start iterating through the digraph, beginning with the value specified in 'initialEnvironment'
 	InitialEnvironment string `json:"initialEnvironment"`
@@ -71,6 +80,15 @@ type ApplicationPromotionRunStatus struct {
 	// - For an automated promotion, there can be multiple active bindings at a time (one for each env at a particular tree depth)
 	// - For a manual promotion, there will be only one.
 	ActiveBindings []string `json:"activeBindings,omitempty"`
+
+	// PromotionStartTime is the time at which the promotion started, once no other PromotionRun was active for the Application:
+	// the promotion timeout is measured from this time.
+	PromotionStartTime *metav1.Time `json:"promotionStartTime,omitempty"`
+
+	// Conditions represent the latest available observations for the PromotionRun, for example, errors that
+	// prevented the promotion from progressing.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // PromotionRunState defines the 3 states of an ApplicationPromotion resource.
@@ -118,6 +136,25 @@ const (
 	ApplicationPromotionRunEnvironmentStatus_Failed     PromotionRunEnvironmentStatusField = "Failed"
 )
 
+const (
+	// PromotionRunCondition_ErrorOccurred is the condition type used to report errors that prevent a PromotionRun from progressing.
+	// The condition is set to 'False' (with a 'Resolved' reason) once the error no longer occurs.
+	PromotionRunCondition_ErrorOccurred = "ErrorOccurred"
+)
+
+// Reasons used by the ErrorOccurred condition of ApplicationPromotionRun
+const (
+	PromotionRunReason_InvalidSpec           = "InvalidSpec"
+	PromotionRunReason_ActivePromotionExists = "ActivePromotionExists"
+	PromotionRunReason_BindingNotFound       = "BindingNotFound"
+	PromotionRunReason_SnapshotNotFound      = "SnapshotNotFound"
+	PromotionRunReason_InvalidSnapshot       = "InvalidSnapshot"
+	PromotionRunReason_BindingChanged        = "BindingChanged"
+	PromotionRunReason_EnvironmentNotFound   = "EnvironmentNotFound"
+	PromotionRunReason_PromotionFailed       = "PromotionFailed"
+	PromotionRunReason_Timeout               = "Timeout"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
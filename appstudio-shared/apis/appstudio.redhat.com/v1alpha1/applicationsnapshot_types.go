@@ -73,6 +73,21 @@ type ApplicationSnapshotStatus struct {
 	Conditions []metav1.Condition `json:"conditions"`
 }
 
+const (
+	// ApplicationSnapshotCondition_Valid is the condition type used to report whether the Snapshot passed validation:
+	// the Application exists, component names are unique, and each container image is a well-formed, digest-pinned reference.
+	// Bindings and PromotionRuns will not deploy a Snapshot whose 'Valid' condition is 'False'.
+	ApplicationSnapshotCondition_Valid = "Valid"
+)
+
+// Reasons used by the Valid condition of ApplicationSnapshot
+const (
+	ApplicationSnapshotReason_Valid                  = "Valid"
+	ApplicationSnapshotReason_ApplicationNotFound    = "ApplicationNotFound"
+	ApplicationSnapshotReason_DuplicateComponentName = "DuplicateComponentName"
+	ApplicationSnapshotReason_InvalidContainerImage  = "InvalidContainerImage"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
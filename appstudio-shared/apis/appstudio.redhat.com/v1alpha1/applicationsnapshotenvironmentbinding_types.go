@@ -99,6 +99,17 @@ type BindingComponentGitOpsRepository struct {
 	// in the overlays/<environment> dir, for example, 'deployment-patch.yaml'. This is stored to differentiate between
 	// application-service controller generated resources vs resources added by a user
 	GeneratedResources []string `json:"generatedResources"`
+
+	// Snapshot is the name of the Snapshot whose container image (for this component) was most recently
+	// written to the GitOps repository by the application service controller.
+	Snapshot string `json:"snapshot,omitempty"`
+
+	// CommitIDs contains the IDs of the GitOps repository commits (on Branch) that include the container image of
+	// 'Snapshot' for this component, oldest first. The list is reset whenever 'Snapshot' changes.
+	// A GitOpsDeployment that has synced to any of these revisions is known to be deploying the Snapshot.
+	// This status is updated by the Application Service controller. If no commits are recorded for the component,
+	// PromotionRuns wait for them to be recorded.
+	CommitIDs []string `json:"commitIDs,omitempty"`
 }
 
 // ComponentStatus contains the status of the components
@@ -125,8 +136,31 @@ type ApplicationSnapshotEnvironmentBindingStatus struct {
 	// Condition describes operations on the GitOps repository, for example, if there were issues with generating/processing the repository.
 	// This status is updated by the Application Service controller.
 	GitOpsRepoConditions []metav1.Condition `json:"gitopsRepoConditions,omitempty"`
+
+	// Conditions describes why the binding is not (or not yet) producing GitOpsDeployments, for example, if the
+	// Environment could not be found, or if the component status has not yet been populated.
+	// This status is updated by the GitOps Service.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// BindingStatusCondition_ErrorOccurred is the condition type used to report issues that prevent the Binding from
+	// producing the expected GitOpsDeployments. The condition is set to 'False' (with a 'Resolved' reason) once the
+	// issue no longer occurs.
+	BindingStatusCondition_ErrorOccurred = "ErrorOccurred"
+)
+
+// Reasons used by the ErrorOccurred condition of ApplicationSnapshotEnvironmentBinding
+const (
+	BindingReason_EnvironmentNotFound     = "EnvironmentNotFound"
+	BindingReason_GitOpsRepositoryError   = "GitOpsRepositoryError"
+	BindingReason_ComponentStatusMissing  = "ComponentStatusMissing"
+	BindingReason_DuplicateComponents     = "DuplicateComponents"
+	BindingReason_MissingTargetNamespace  = "MissingTargetNamespace"
+	BindingReason_InvalidSnapshot         = "InvalidSnapshot"
+	BindingReason_GitOpsDeploymentFailure = "GitOpsDeploymentFailure"
+)
+
 // BindingStatusGitOpsDeployment describes an individual reference
 // to a GitOpsDeployment resources that is used to deploy this binding.
 //
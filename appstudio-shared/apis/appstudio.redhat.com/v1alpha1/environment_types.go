@@ -113,8 +113,36 @@ type EnvironmentConfiguration struct {
 
 // EnvironmentStatus defines the observed state of Environment
 type EnvironmentStatus struct {
+
+	// Conditions describe issues that occurred while processing the Environment ('ErrorOccurred'), and whether the
+	// GitOps Service was able to connect to the target cluster of the Environment ('ConnectionInitializationSucceeded').
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ManagedEnvironment is the name of the GitOpsDeploymentManagedEnvironment that was generated for the Environment,
+	// if the Environment defines the Kubernetes cluster credentials of a target cluster.
+	ManagedEnvironment string `json:"managedEnvironment,omitempty"`
+
+	// GitOpsDeployments is the list of the names of the GitOpsDeployments that currently target the Environment.
+	GitOpsDeployments []string `json:"gitopsDeployments,omitempty"`
 }
 
+// Conditions used by the Environment status
+const (
+	EnvironmentCondition_ErrorOccurred = "ErrorOccurred"
+
+	// EnvironmentCondition_ConnectionInitializationSucceeded reflects the corresponding condition of the
+	// GitOpsDeploymentManagedEnvironment of the Environment: it is True if the credentials of the target cluster
+	// were verified by the GitOps Service.
+	EnvironmentCondition_ConnectionInitializationSucceeded = "ConnectionInitializationSucceeded"
+)
+
+// Reasons used by the conditions of Environment
+const (
+	EnvironmentReason_SecretNotFound          = "SecretNotFound"
+	EnvironmentReason_ManagedEnvironmentError = "ManagedEnvironmentError"
+	EnvironmentReason_ConnectionPending       = "ConnectionPending"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
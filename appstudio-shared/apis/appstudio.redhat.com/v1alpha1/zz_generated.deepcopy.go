@@ -33,7 +33,7 @@ func (in *ApplicationPromotionRun) DeepCopyInto(out *ApplicationPromotionRun) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -92,6 +92,11 @@ func (in *ApplicationPromotionRunSpec) DeepCopyInto(out *ApplicationPromotionRun
 	*out = *in
 	out.ManualPromotion = in.ManualPromotion
 	out.AutomatedPromotion = in.AutomatedPromotion
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationPromotionRunSpec.
@@ -117,6 +122,17 @@ func (in *ApplicationPromotionRunStatus) DeepCopyInto(out *ApplicationPromotionR
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.PromotionStartTime != nil {
+		in, out := &in.PromotionStartTime, &out.PromotionStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationPromotionRunStatus.
@@ -274,6 +290,13 @@ func (in *ApplicationSnapshotEnvironmentBindingStatus) DeepCopyInto(out *Applica
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSnapshotEnvironmentBindingStatus.
@@ -425,6 +448,11 @@ func (in *BindingComponentGitOpsRepository) DeepCopyInto(out *BindingComponentGi
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.CommitIDs != nil {
+		in, out := &in.CommitIDs, &out.CommitIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingComponentGitOpsRepository.
@@ -489,7 +517,7 @@ func (in *Environment) DeepCopyInto(out *Environment) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Environment.
@@ -591,6 +619,18 @@ func (in *EnvironmentSpec) DeepCopy() *EnvironmentSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvironmentStatus) DeepCopyInto(out *EnvironmentStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GitOpsDeployments != nil {
+		in, out := &in.GitOpsDeployments, &out.GitOpsDeployments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentStatus.
@@ -22,7 +22,16 @@ import (
 
 // GitOpsDeploymentSpec defines the desired state of GitOpsDeployment
 type GitOpsDeploymentSpec struct {
-	Source ApplicationSource `json:"source"`
+	// Source is the location of the application's manifests (or Helm chart).
+	// Either Source or Sources must be specified.
+	Source ApplicationSource `json:"source,omitempty"`
+
+	// Sources is a list of the locations of the application's manifests (or Helm charts), for applications that are
+	// composed from multiple sources: for example, a Helm chart from one repository with a values file from another.
+	// If Sources is specified, Source is ignored.
+	//
+	// Note: Multiple sources require Argo CD v2.6+, which supports 'spec.sources' of Argo CD Applications.
+	Sources []ApplicationSource `json:"sources,omitempty"`
 
 	// Destination is a reference to a target namespace/cluster to deploy to.
 	// This field may be empty: if it is empty, it is assumed that the destination
@@ -37,8 +46,97 @@ type GitOpsDeploymentSpec struct {
 	// Note: This is somewhat of a placeholder for more advanced logic that can be implemented in the future.
 	// For an example of this type of logic, see the 'syncPolicy' field of Argo CD Application.
 	Type string `json:"type"`
+
+	// SyncPolicy controls how Argo CD synchronizes the resources of the GitOpsDeployment.
+	// If not specified, resources are both pruned and self-healed for 'automated' GitOpsDeployments.
+	SyncPolicy *SyncPolicy `json:"syncPolicy,omitempty"`
+
+	// IgnoreDifferences is a list of resources, and fields of those resources, which should be ignored when comparing
+	// the desired state of the GitOpsDeployment with the live state of the cluster. For example, this may be used to
+	// ignore the replicas of a Deployment that is scaled by a HorizontalPodAutoscaler, or sidecar containers that are
+	// injected by an admission webhook.
+	IgnoreDifferences []ResourceIgnoreDifferences `json:"ignoreDifferences,omitempty"`
+
+	// Suspend, if true, freezes the GitOpsDeployment: Argo CD will neither automatically sync nor self-heal its
+	// resources, even if the GitOpsDeployment is of type 'automated'. The GitOpsDeployment, its resources, and the
+	// corresponding Argo CD Application are otherwise left intact. Syncs may still be triggered via
+	// GitOpsDeploymentSyncRun.
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// ResourceIgnoreDifferences selects the resources (by group/kind/name/namespace), and the fields of those resources
+// (by JSON pointer or jq path expression), whose differences should be ignored
+type ResourceIgnoreDifferences struct {
+	// Group is the API group of the resources. The empty string selects the core API group.
+	Group string `json:"group,omitempty"`
+	// Kind is the kind of the resources
+	Kind string `json:"kind"`
+	// Name is the name of the resource. If not specified, all resources of the given group/kind are selected.
+	Name string `json:"name,omitempty"`
+	// Namespace is the namespace of the resource. If not specified, resources in all namespaces are selected.
+	Namespace string `json:"namespace,omitempty"`
+
+	// JSONPointers is a list of JSON pointers (RFC 6901) to the fields to ignore, for example '/spec/replicas'
+	JSONPointers []string `json:"jsonPointers,omitempty"`
+	// JQPathExpressions is a list of jq path expressions to the fields to ignore, for example
+	// '.spec.template.spec.initContainers[] | select(.name == "injected-init-container")'
+	JQPathExpressions []string `json:"jqPathExpressions,omitempty"`
+}
+
+// SyncPolicy controls how Argo CD synchronizes the resources of a GitOpsDeployment
+type SyncPolicy struct {
+	// Prune specifies whether resources that are no longer defined in the source are deleted from the cluster,
+	// as part of an automated sync. This is only applicable to 'automated' GitOpsDeployments. Defaults to true.
+	//
+	// To prevent specific resources from ever being pruned, annotate them with
+	// 'argocd.argoproj.io/sync-options: Prune=false'.
+	Prune *bool `json:"prune,omitempty"`
+
+	// SelfHeal specifies whether resources that are modified in the cluster are reverted to their desired state,
+	// as part of an automated sync. This is only applicable to 'automated' GitOpsDeployments. Defaults to true.
+	SelfHeal *bool `json:"selfHeal,omitempty"`
+
+	// SyncOptions is a list of Argo CD sync options which are applied to every sync of the GitOpsDeployment.
+	// For example: 'CreateNamespace=true', 'ServerSideApply=true', or 'PruneLast=true'.
+	// See `SyncOption_*` for commonly used values.
+	SyncOptions []string `json:"syncOptions,omitempty"`
+
+	// Retry controls the retry behaviour of failed (automated) syncs
+	Retry *RetryStrategy `json:"retry,omitempty"`
 }
 
+// RetryStrategy controls the retry behaviour of failed syncs
+type RetryStrategy struct {
+	// Limit is the maximum number of attempts for retrying a failed sync. If set to 0, no retries will be performed.
+	Limit int64 `json:"limit,omitempty"`
+
+	// Backoff controls how to backoff on subsequent retries of failed syncs
+	Backoff *Backoff `json:"backoff,omitempty"`
+}
+
+// Backoff is the backoff strategy to use on subsequent retries of failed syncs
+type Backoff struct {
+	// Duration is the amount to back off. Default unit is seconds, but could also be a duration (e.g. "2m", "1h")
+	Duration string `json:"duration,omitempty"`
+
+	// Factor is a factor to multiply the base duration after each failed retry
+	Factor *int64 `json:"factor,omitempty"`
+
+	// MaxDuration is the maximum amount of time allowed for the backoff strategy
+	MaxDuration string `json:"maxDuration,omitempty"`
+}
+
+const (
+	// SyncOption_CreateNamespace will create the destination namespace of the GitOpsDeployment, if it does not exist.
+	SyncOption_CreateNamespace = "CreateNamespace=true"
+
+	// SyncOption_ServerSideApply will use Kubernetes server-side apply to apply resources (requires Argo CD v2.5+).
+	SyncOption_ServerSideApply = "ServerSideApply=true"
+
+	// SyncOption_PruneLast will prune resources only after all other resources have been synced and are healthy.
+	SyncOption_PruneLast = "PruneLast=true"
+)
+
 // ApplicationSource contains all required information about the source of an application
 type ApplicationSource struct {
 	// RepoURL is the URL to the repository (Git or Helm) that contains the application manifests
@@ -49,6 +147,96 @@ type ApplicationSource struct {
 	// In case of Git, this can be commit, tag, or branch. If omitted, will equal to HEAD.
 	// In case of Helm, this is a semver tag for the Chart's version.
 	TargetRevision string `json:"targetRevision,omitempty"`
+
+	// Chart is a Helm chart name, and must be specified for applications sourced from a Helm repo.
+	// The version of the chart is specified by TargetRevision.
+	Chart string `json:"chart,omitempty"`
+
+	// Helm holds options that are specific to sources rendered by Helm
+	Helm *ApplicationSourceHelm `json:"helm,omitempty"`
+
+	// Kustomize holds options that are specific to sources rendered by kustomize
+	Kustomize *ApplicationSourceKustomize `json:"kustomize,omitempty"`
+
+	// Ref is a name for this source, which can be used to refer to the files of this source from the Helm value
+	// files of another source, e.g. '$values/environments/prod/values.yaml' for a Ref of 'values'.
+	// This is only applicable to GitOpsDeployments with multiple sources.
+	Ref string `json:"ref,omitempty"`
+}
+
+// ApplicationSourceHelm holds options that are specific to sources rendered by Helm
+type ApplicationSourceHelm struct {
+	// ValueFiles is a list of Helm value files to use when generating a template.
+	// The paths are relative to the chart (or the path of the chart, within a Git repository).
+	ValueFiles []string `json:"valueFiles,omitempty"`
+
+	// Parameters is a list of Helm parameters which are passed to the helm template command upon manifest generation
+	Parameters []HelmParameter `json:"parameters,omitempty"`
+
+	// Values specifies Helm values to be passed to helm template, typically defined as a (YAML) block.
+	// Values take precedence over the values in ValueFiles, and Parameters take precedence over Values.
+	Values string `json:"values,omitempty"`
+}
+
+// HelmParameter is a parameter that's passed to helm template during manifest generation
+type HelmParameter struct {
+	// Name is the name of the Helm parameter
+	Name string `json:"name"`
+	// Value is the value for the Helm parameter
+	Value string `json:"value,omitempty"`
+	// ForceString determines whether to tell Helm to interpret booleans and numbers as strings
+	ForceString bool `json:"forceString,omitempty"`
+}
+
+// ApplicationSourceKustomize holds options that are specific to sources rendered by kustomize
+type ApplicationSourceKustomize struct {
+	// NamePrefix is a prefix that is prepended to the names of the resources rendered by kustomize
+	NamePrefix string `json:"namePrefix,omitempty"`
+
+	// NameSuffix is a suffix that is appended to the names of the resources rendered by kustomize
+	NameSuffix string `json:"nameSuffix,omitempty"`
+
+	// CommonLabels are additional labels that are added to the resources rendered by kustomize
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+
+	// CommonAnnotations are additional annotations that are added to the resources rendered by kustomize
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+
+	// Images is a list of kustomize image overrides, in the format of the 'kustomize edit set image' command.
+	// For example: 'quay.io/my-org/my-image:v2', or 'my-image=quay.io/my-org/my-image:v2'.
+	Images []string `json:"images,omitempty"`
+
+	// Version is the version of kustomize that is used to render the resources. The version must be
+	// configured in Argo CD. If not specified, the default version of kustomize in Argo CD is used.
+	Version string `json:"version,omitempty"`
+
+	// Patches is a list of kustomize patches, which are applied to the resources rendered from the source.
+	// For example, these are used to apply the replicas/resources/environment variables of an Environment
+	// to the resources of a component.
+	//
+	// Note: Patches require Argo CD v2.6+, which supports 'spec.source.kustomize.patches' of Argo CD Applications.
+	Patches []KustomizePatch `json:"patches,omitempty"`
+}
+
+// KustomizePatch is an inline kustomize patch: either a strategic merge patch, or a JSON 6902 patch
+type KustomizePatch struct {
+	// Patch is the content of the patch, in YAML or JSON format
+	Patch string `json:"patch"`
+
+	// Target selects the resources that the patch is applied to. If not specified, the target is
+	// determined from the contents of the (strategic merge) patch.
+	Target *KustomizeSelector `json:"target,omitempty"`
+}
+
+// KustomizeSelector selects the resources that a kustomize patch is applied to
+type KustomizeSelector struct {
+	Group              string `json:"group,omitempty"`
+	Version            string `json:"version,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+	Name               string `json:"name,omitempty"`
+	Namespace          string `json:"namespace,omitempty"`
+	LabelSelector      string `json:"labelSelector,omitempty"`
+	AnnotationSelector string `json:"annotationSelector,omitempty"`
 }
 
 // ApplicationDestination holds information about the application's destination
@@ -84,6 +272,101 @@ type GitOpsDeploymentStatus struct {
 
 	// List of Resource created by a deployment
 	Resources []ResourceStatus `json:"resources,omitempty" protobuf:"bytes,1,opt,name=resources"`
+
+	// OperationState contains information about the most recent sync operation of the GitOpsDeployment, including
+	// the result of the sync for each individual resource
+	OperationState *OperationState `json:"operationState,omitempty"`
+
+	// History contains information about the most recent successful syncs of the GitOpsDeployment, up to a maximum
+	// of 10 entries. The ID of an entry may be used to roll back to it, via a GitOpsDeploymentSyncRun.
+	History []RevisionHistory `json:"history,omitempty"`
+
+	// ReconciledAt indicates when the state of the GitOpsDeployment was last reconciled (compared against the
+	// latest revision of the source) by Argo CD
+	ReconciledAt *metav1.Time `json:"reconciledAt,omitempty"`
+
+	// Suspended is true if the GitOpsDeployment is suspended (see '.spec.suspend'): while suspended, Argo CD will
+	// not automatically sync the GitOpsDeployment.
+	Suspended bool `json:"suspended,omitempty"`
+}
+
+// OperationState contains information about a sync operation, and its result
+type OperationState struct {
+	// Phase is the current phase of the operation
+	Phase OperationPhase `json:"phase"`
+	// Message holds any pertinent messages when attempting to perform operation (typically errors).
+	Message string `json:"message,omitempty"`
+	// SyncResult is the result of the sync operation
+	SyncResult *SyncOperationResult `json:"syncResult,omitempty"`
+	// StartedAt contains time of operation start
+	StartedAt metav1.Time `json:"startedAt"`
+	// FinishedAt contains time of operation completion
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+	// RetryCount contains the number of times the operation was retried
+	RetryCount int64 `json:"retryCount,omitempty"`
+}
+
+// OperationPhase is the phase of a sync operation
+type OperationPhase string
+
+const (
+	OperationPhaseRunning     OperationPhase = "Running"
+	OperationPhaseTerminating OperationPhase = "Terminating"
+	OperationPhaseFailed      OperationPhase = "Failed"
+	OperationPhaseError       OperationPhase = "Error"
+	OperationPhaseSucceeded   OperationPhase = "Succeeded"
+)
+
+// SyncOperationResult is the result of a sync operation
+type SyncOperationResult struct {
+	// Resources contains a list of sync result items for each individual resource in a sync operation
+	Resources []ResourceResult `json:"resources,omitempty"`
+	// Revision holds the revision this sync operation was performed to
+	Revision string `json:"revision"`
+}
+
+// ResourceResult holds the result of a sync operation, for a specific resource (or hook)
+type ResourceResult struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Status holds the final result of the sync (for example, 'Synced' or 'SyncFailed'). Will be empty if the resource is
+	// yet to be applied/pruned, and is always empty for hooks
+	Status string `json:"status,omitempty"`
+	// Message contains an informational or error message for the last sync
+	Message string `json:"message,omitempty"`
+	// HookType specifies the type of the hook (for example, 'PreSync' or 'PostSync'). Empty for non-hook resources
+	HookType string `json:"hookType,omitempty"`
+	// HookPhase contains the state of any operation associated with this resource or hook
+	HookPhase OperationPhase `json:"hookPhase,omitempty"`
+	// SyncPhase indicates the particular phase of the sync that this result was acquired in
+	SyncPhase string `json:"syncPhase,omitempty"`
+}
+
+// RevisionHistory contains information about a previous sync of the GitOpsDeployment
+type RevisionHistory struct {
+	// ID is an auto incrementing identifier of the RevisionHistory
+	ID int64 `json:"id"`
+	// Revision holds the revision the sync was performed against
+	Revision string `json:"revision"`
+	// Source is the source that was used for the sync
+	Source ApplicationSource `json:"source,omitempty"`
+	// DeployStartedAt holds the time the sync operation started
+	DeployStartedAt *metav1.Time `json:"deployStartedAt,omitempty"`
+	// DeployedAt holds the time the sync operation completed
+	DeployedAt metav1.Time `json:"deployedAt"`
+	// InitiatedBy contains information about who initiated the sync, if known
+	InitiatedBy *OperationInitiator `json:"initiatedBy,omitempty"`
+}
+
+// OperationInitiator contains information about the initiator of a sync operation
+type OperationInitiator struct {
+	// Username contains the name of the user who started the sync
+	Username string `json:"username,omitempty"`
+	// Automated is true if the sync was initiated automatically by Argo CD (for example, by an automated sync policy)
+	Automated bool `json:"automated,omitempty"`
 }
 
 // HealthStatus contains information about the currently observed health state of an application or resource
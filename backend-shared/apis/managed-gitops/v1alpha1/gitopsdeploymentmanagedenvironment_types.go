@@ -24,12 +24,68 @@ import (
 type GitOpsDeploymentManagedEnvironmentSpec struct {
 	APIURL                   string `json:"apiURL"`
 	ClusterCredentialsSecret string `json:"credentialsSecret"`
+
+	// CABundle is a PEM-encoded bundle of CA certificates, used to verify the TLS certificate of the API server of the
+	// managed environment. If not specified, the certificate authority data of the matching cluster within the
+	// kubeconfig of the credentials Secret is used, if present. Otherwise, the system roots are used.
+	CABundle string `json:"caBundle,omitempty"`
+
+	// AllowInsecureSkipTLSVerify disables verification of the TLS certificate of the API server of the managed
+	// environment. This is insecure, and should only be enabled for development/testing clusters. TLS verification is
+	// also disabled if the matching cluster within the kubeconfig of the credentials Secret sets 'insecure-skip-tls-verify'.
+	AllowInsecureSkipTLSVerify bool `json:"allowInsecureSkipTLSVerify,omitempty"`
+
+	// Namespaces is an optional allow-list of namespaces on the managed environment. If specified, the GitOps Service
+	// is only granted access to these namespaces (via a Role/RoleBinding in each namespace), rather than cluster-wide
+	// access (via a ClusterRole/ClusterRoleBinding), and Argo CD will only deploy to these namespaces.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// CreateNewServiceAccount indicates whether the GitOps Service should use the credentials of the Secret to install
+	// a new ServiceAccount on the managed environment (with the permissions required by Argo CD), and then use the
+	// token of that ServiceAccount. If false, the credentials of the Secret are used directly.
+	// If not specified, a ServiceAccount is installed if the Secret contains a kubeconfig, and not otherwise.
+	CreateNewServiceAccount *bool `json:"createNewServiceAccount,omitempty"`
 }
 
 // GitOpsDeploymentManagedEnvironmentStatus defines the observed state of GitOpsDeploymentManagedEnvironment
 type GitOpsDeploymentManagedEnvironmentStatus struct {
+
+	// Conditions describe whether the GitOps Service was able to connect to the managed environment, using the
+	// credentials from the referenced Secret.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// Conditions used by the GitOpsDeploymentManagedEnvironment status
+const (
+	// ManagedEnvironmentConditionConnectionInitializationSucceeded is True if the GitOps Service was able to connect to
+	// the managed environment using the credentials of the GitOpsDeploymentManagedEnvironment, and False otherwise.
+	ManagedEnvironmentConditionConnectionInitializationSucceeded = "ConnectionInitializationSucceeded"
+
+	// ManagedEnvironmentConditionServiceAccountCleanupSucceeded is False if, while the GitOpsDeploymentManagedEnvironment
+	// is being deleted, the GitOps Service was unable to delete the ServiceAccount (and the related Secret and roles)
+	// that it installed on the managed environment.
+	ManagedEnvironmentConditionServiceAccountCleanupSucceeded = "ServiceAccountCleanupSucceeded"
+)
+
+// Reasons used by the ConnectionInitializationSucceeded condition of GitOpsDeploymentManagedEnvironment
+const (
+	ManagedEnvironmentReasonSucceeded                    = "Succeeded"
+	ManagedEnvironmentReasonUnableToInitializeConnection = "UnableToInitializeConnection"
+	ManagedEnvironmentReasonUnableToRetrieveSecret       = "UnableToRetrieveSecret"
+	ManagedEnvironmentReasonInvalidSecret                = "InvalidSecret"
+	ManagedEnvironmentReasonDatabaseError                = "DatabaseError"
+)
+
+// Reasons used by the ServiceAccountCleanupSucceeded condition of GitOpsDeploymentManagedEnvironment
+const (
+	ManagedEnvironmentReasonUnableToCleanUpServiceAccount = "UnableToCleanUpServiceAccount"
+)
+
+// ManagedEnvironmentServiceAccountCleanupFinalizer is added to a GitOpsDeploymentManagedEnvironment for which the GitOps
+// Service installed a ServiceAccount on the managed environment: it ensures that the ServiceAccount (and the related
+// Secret and roles) are deleted from the managed environment before the GitOpsDeploymentManagedEnvironment is deleted.
+const ManagedEnvironmentServiceAccountCleanupFinalizer = "managed-gitops.redhat.com/service-account-cleanup"
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
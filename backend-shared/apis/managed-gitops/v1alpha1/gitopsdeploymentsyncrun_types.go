@@ -24,11 +24,67 @@ import (
 type GitOpsDeploymentSyncRunSpec struct {
 	GitopsDeploymentName string `json:"gitopsDeploymentName"`
 	RevisionID           string `json:"revisionID,omitempty"`
+
+	// RollbackToHistoryID, if specified, rolls back the GitOpsDeployment to a previous sync: the revision (and source)
+	// of the entry with this ID in the '.status.history' field of the GitOpsDeployment is redeployed.
+	// - Rollback is only supported for GitOpsDeployments of type 'manual': otherwise Argo CD would immediately sync the
+	//   GitOpsDeployment back to the latest revision.
+	// - RevisionID must not be specified when RollbackToHistoryID is specified.
+	RollbackToHistoryID *int64 `json:"rollbackToHistoryID,omitempty"`
+
+	// Prune, if true, deletes resources that are no longer defined in the GitOps repository, as part of the sync.
+	Prune bool `json:"prune,omitempty"`
+
+	// DryRun, if true, performs the sync without applying any changes to the target environment: the result of the
+	// sync is reported in the status, as usual.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Force, if true, deletes and re-creates resources which could not otherwise be applied (for example, due to a
+	// change to an immutable field).
+	Force bool `json:"force,omitempty"`
+
+	// Resources, if specified, limits the sync to only the given resources of the GitOpsDeployment. Otherwise, all
+	// resources are synced.
+	Resources []SyncRunResource `json:"resources,omitempty"`
+
+	// Terminate, if true, cancels the sync operation, if it is in progress. The outcome is reported in the status.
+	// Once set, it may not be unset.
+	Terminate bool `json:"terminate,omitempty"`
+}
+
+// SyncRunResource identifies a resource of a GitOpsDeployment to sync
+type SyncRunResource struct {
+	// Group is the API group of the resource. The empty string selects the core API group.
+	Group string `json:"group,omitempty"`
+	// Kind is the kind of the resource
+	Kind string `json:"kind"`
+	// Name is the name of the resource
+	Name string `json:"name"`
+	// Namespace is the namespace of the resource, if it is namespace-scoped
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // GitOpsDeploymentSyncRunStatus defines the observed state of GitOpsDeploymentSyncRun
 type GitOpsDeploymentSyncRunStatus struct {
 	Conditions []GitOpsDeploymentSyncRunCondition `json:"conditions,omitempty"`
+
+	// Phase is the current phase of the sync operation. It is empty until the sync operation has started.
+	Phase OperationPhase `json:"phase,omitempty"`
+
+	// Message is a human-readable message describing the result of the sync operation
+	Message string `json:"message,omitempty"`
+
+	// StartedAt is the time at which the sync operation started
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// FinishedAt is the time at which the sync operation completed
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+
+	// Revision is the revision of the GitOps repository that was synced
+	Revision string `json:"revision,omitempty"`
+
+	// Resources is the result of the sync operation, for each resource (and hook) that was synced
+	Resources []ResourceResult `json:"resources,omitempty"`
 }
 
 //+kubebuilder:object:root=true
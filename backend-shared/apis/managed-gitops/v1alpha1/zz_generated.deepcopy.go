@@ -22,6 +22,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -43,6 +44,16 @@ func (in *ApplicationDestination) DeepCopy() *ApplicationDestination {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationSource) DeepCopyInto(out *ApplicationSource) {
 	*out = *in
+	if in.Helm != nil {
+		in, out := &in.Helm, &out.Helm
+		*out = new(ApplicationSourceHelm)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kustomize != nil {
+		in, out := &in.Kustomize, &out.Kustomize
+		*out = new(ApplicationSourceKustomize)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSource.
@@ -55,12 +66,98 @@ func (in *ApplicationSource) DeepCopy() *ApplicationSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSourceHelm) DeepCopyInto(out *ApplicationSourceHelm) {
+	*out = *in
+	if in.ValueFiles != nil {
+		in, out := &in.ValueFiles, &out.ValueFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]HelmParameter, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSourceHelm.
+func (in *ApplicationSourceHelm) DeepCopy() *ApplicationSourceHelm {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSourceHelm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSourceKustomize) DeepCopyInto(out *ApplicationSourceKustomize) {
+	*out = *in
+	if in.CommonLabels != nil {
+		in, out := &in.CommonLabels, &out.CommonLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CommonAnnotations != nil {
+		in, out := &in.CommonAnnotations, &out.CommonAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]KustomizePatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSourceKustomize.
+func (in *ApplicationSourceKustomize) DeepCopy() *ApplicationSourceKustomize {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSourceKustomize)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Backoff) DeepCopyInto(out *Backoff) {
+	*out = *in
+	if in.Factor != nil {
+		in, out := &in.Factor, &out.Factor
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Backoff.
+func (in *Backoff) DeepCopy() *Backoff {
+	if in == nil {
+		return nil
+	}
+	out := new(Backoff)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsDeployment) DeepCopyInto(out *GitOpsDeployment) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -139,8 +236,8 @@ func (in *GitOpsDeploymentManagedEnvironment) DeepCopyInto(out *GitOpsDeployment
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsDeploymentManagedEnvironment.
@@ -196,6 +293,16 @@ func (in *GitOpsDeploymentManagedEnvironmentList) DeepCopyObject() runtime.Objec
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsDeploymentManagedEnvironmentSpec) DeepCopyInto(out *GitOpsDeploymentManagedEnvironmentSpec) {
 	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CreateNewServiceAccount != nil {
+		in, out := &in.CreateNewServiceAccount, &out.CreateNewServiceAccount
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsDeploymentManagedEnvironmentSpec.
@@ -211,6 +318,13 @@ func (in *GitOpsDeploymentManagedEnvironmentSpec) DeepCopy() *GitOpsDeploymentMa
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsDeploymentManagedEnvironmentStatus) DeepCopyInto(out *GitOpsDeploymentManagedEnvironmentStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsDeploymentManagedEnvironmentStatus.
@@ -315,8 +429,27 @@ func (in *GitOpsDeploymentRepositoryCredentialStatus) DeepCopy() *GitOpsDeployme
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsDeploymentSpec) DeepCopyInto(out *GitOpsDeploymentSpec) {
 	*out = *in
-	out.Source = in.Source
+	in.Source.DeepCopyInto(&out.Source)
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]ApplicationSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	out.Destination = in.Destination
+	if in.SyncPolicy != nil {
+		in, out := &in.SyncPolicy, &out.SyncPolicy
+		*out = new(SyncPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IgnoreDifferences != nil {
+		in, out := &in.IgnoreDifferences, &out.IgnoreDifferences
+		*out = make([]ResourceIgnoreDifferences, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsDeploymentSpec.
@@ -348,6 +481,22 @@ func (in *GitOpsDeploymentStatus) DeepCopyInto(out *GitOpsDeploymentStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.OperationState != nil {
+		in, out := &in.OperationState, &out.OperationState
+		*out = new(OperationState)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]RevisionHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReconciledAt != nil {
+		in, out := &in.ReconciledAt, &out.ReconciledAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsDeploymentStatus.
@@ -365,7 +514,7 @@ func (in *GitOpsDeploymentSyncRun) DeepCopyInto(out *GitOpsDeploymentSyncRun) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -441,6 +590,16 @@ func (in *GitOpsDeploymentSyncRunList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsDeploymentSyncRunSpec) DeepCopyInto(out *GitOpsDeploymentSyncRunSpec) {
 	*out = *in
+	if in.RollbackToHistoryID != nil {
+		in, out := &in.RollbackToHistoryID, &out.RollbackToHistoryID
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]SyncRunResource, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsDeploymentSyncRunSpec.
@@ -463,6 +622,19 @@ func (in *GitOpsDeploymentSyncRunStatus) DeepCopyInto(out *GitOpsDeploymentSyncR
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceResult, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsDeploymentSyncRunStatus.
@@ -490,6 +662,56 @@ func (in *HealthStatus) DeepCopy() *HealthStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmParameter) DeepCopyInto(out *HelmParameter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmParameter.
+func (in *HelmParameter) DeepCopy() *HelmParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KustomizePatch) DeepCopyInto(out *KustomizePatch) {
+	*out = *in
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(KustomizeSelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KustomizePatch.
+func (in *KustomizePatch) DeepCopy() *KustomizePatch {
+	if in == nil {
+		return nil
+	}
+	out := new(KustomizePatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KustomizeSelector) DeepCopyInto(out *KustomizeSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KustomizeSelector.
+func (in *KustomizeSelector) DeepCopy() *KustomizeSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(KustomizeSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Operation) DeepCopyInto(out *Operation) {
 	*out = *in
@@ -517,6 +739,21 @@ func (in *Operation) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationInitiator) DeepCopyInto(out *OperationInitiator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationInitiator.
+func (in *OperationInitiator) DeepCopy() *OperationInitiator {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationInitiator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperationList) DeepCopyInto(out *OperationList) {
 	*out = *in
@@ -564,6 +801,31 @@ func (in *OperationSpec) DeepCopy() *OperationSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationState) DeepCopyInto(out *OperationState) {
+	*out = *in
+	if in.SyncResult != nil {
+		in, out := &in.SyncResult, &out.SyncResult
+		*out = new(SyncOperationResult)
+		(*in).DeepCopyInto(*out)
+	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.FinishedAt != nil {
+		in, out := &in.FinishedAt, &out.FinishedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationState.
+func (in *OperationState) DeepCopy() *OperationState {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationState)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperationStatus) DeepCopyInto(out *OperationStatus) {
 	*out = *in
@@ -579,6 +841,46 @@ func (in *OperationStatus) DeepCopy() *OperationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceIgnoreDifferences) DeepCopyInto(out *ResourceIgnoreDifferences) {
+	*out = *in
+	if in.JSONPointers != nil {
+		in, out := &in.JSONPointers, &out.JSONPointers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.JQPathExpressions != nil {
+		in, out := &in.JQPathExpressions, &out.JQPathExpressions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceIgnoreDifferences.
+func (in *ResourceIgnoreDifferences) DeepCopy() *ResourceIgnoreDifferences {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceIgnoreDifferences)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceResult) DeepCopyInto(out *ResourceResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceResult.
+func (in *ResourceResult) DeepCopy() *ResourceResult {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceStatus) DeepCopyInto(out *ResourceStatus) {
 	*out = *in
@@ -599,6 +901,122 @@ func (in *ResourceStatus) DeepCopy() *ResourceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryStrategy) DeepCopyInto(out *RetryStrategy) {
+	*out = *in
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(Backoff)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryStrategy.
+func (in *RetryStrategy) DeepCopy() *RetryStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevisionHistory) DeepCopyInto(out *RevisionHistory) {
+	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+	if in.DeployStartedAt != nil {
+		in, out := &in.DeployStartedAt, &out.DeployStartedAt
+		*out = (*in).DeepCopy()
+	}
+	in.DeployedAt.DeepCopyInto(&out.DeployedAt)
+	if in.InitiatedBy != nil {
+		in, out := &in.InitiatedBy, &out.InitiatedBy
+		*out = new(OperationInitiator)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionHistory.
+func (in *RevisionHistory) DeepCopy() *RevisionHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(RevisionHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncOperationResult) DeepCopyInto(out *SyncOperationResult) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceResult, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncOperationResult.
+func (in *SyncOperationResult) DeepCopy() *SyncOperationResult {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncOperationResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncPolicy) DeepCopyInto(out *SyncPolicy) {
+	*out = *in
+	if in.Prune != nil {
+		in, out := &in.Prune, &out.Prune
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SelfHeal != nil {
+		in, out := &in.SelfHeal, &out.SelfHeal
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SyncOptions != nil {
+		in, out := &in.SyncOptions, &out.SyncOptions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(RetryStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncPolicy.
+func (in *SyncPolicy) DeepCopy() *SyncPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncRunResource) DeepCopyInto(out *SyncRunResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncRunResource.
+func (in *SyncRunResource) DeepCopy() *SyncRunResource {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncRunResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncStatus) DeepCopyInto(out *SyncStatus) {
 	*out = *in
@@ -2,7 +2,6 @@ package db
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
 )
 
@@ -54,14 +53,8 @@ func (dbq *PostgreSQLDatabaseQueries) CreateApplicationState(ctx context.Context
 		return err
 	}
 
-	// Check if number of bytes in Array is more then the allowed limit
-	// validateFieldLength function is not modified as that is written for Strings
-	// and after adding check for byte array it would get messy. As of now This is the only place byte array has to be checked,
-	// if multiple places need this it new function can be created in utils.
-	noOfBytesInObj := binary.Size(obj.Resources)
-	maxSize := DbFieldMap["ApplicationStateResourcesLength"]
-	if noOfBytesInObj > maxSize {
-		return fmt.Errorf("Resources value exceeds maximum size: max: %d, actual: %d", maxSize, noOfBytesInObj)
+	if err := validateApplicationStateByteArrayLength(obj); err != nil {
+		return err
 	}
 
 	// Inserting ApplicationState object
@@ -93,14 +86,8 @@ func (dbq *PostgreSQLDatabaseQueries) UpdateApplicationState(ctx context.Context
 		return err
 	}
 
-	// Check if number of bytes in Array is more then the allowed limit
-	// validateFieldLength function is not modified as that is written for Strings
-	// and after adding check for byte array it would get messy. As of now This is the only place byte array has to be checked,
-	// if multiple places need this it new function can be created in utils.
-	noOfBytesInObj := binary.Size(obj.Resources)
-	maxSize := DbFieldMap["ApplicationStateResourcesLength"]
-	if noOfBytesInObj > maxSize {
-		return fmt.Errorf("Resources value exceeds maximum size: max: %d, actual: %d", maxSize, noOfBytesInObj)
+	if err := validateApplicationStateByteArrayLength(obj); err != nil {
+		return err
 	}
 
 	result, err := dbq.dbConnection.Model(obj).Context(ctx).
@@ -116,6 +103,16 @@ func (dbq *PostgreSQLDatabaseQueries) UpdateApplicationState(ctx context.Context
 	return nil
 }
 
+// validateApplicationStateByteArrayLength checks if the number of bytes in the byte array fields of the ApplicationState
+// is more than the allowed limit.
+func validateApplicationStateByteArrayLength(obj *ApplicationState) error {
+	return validateByteArrayFieldLength("ApplicationState", []byteArrayField{
+		{name: "Resources", value: obj.Resources},
+		{name: "Operation_state", value: obj.Operation_state},
+		{name: "History", value: obj.History},
+	})
+}
+
 func (dbq *PostgreSQLDatabaseQueries) GetApplicationStateById(ctx context.Context, obj *ApplicationState) error {
 
 	if err := validateQueryParamsEntity(obj, dbq); err != nil {
@@ -38,6 +38,8 @@ var _ = Describe("ApplicationStates Tests", func() {
 				Health:                          "Progressing",
 				Sync_Status:                     "Unknown",
 				Resources:                       make([]byte, 10),
+				Operation_state:                 make([]byte, 10),
+				History:                         make([]byte, 10),
 			}
 
 			err = dbq.CreateApplicationState(ctx, applicationState)
@@ -70,6 +72,20 @@ var _ = Describe("ApplicationStates Tests", func() {
 
 			err = dbq.CreateApplicationState(ctx, applicationState)
 			Expect(err).NotTo(BeNil())
+
+			applicationState.Resources = make([]byte, 10)
+			applicationState.Operation_state = make([]byte, 262145)
+
+			err = dbq.CreateApplicationState(ctx, applicationState)
+			Expect(err).NotTo(BeNil())
+			Expect(db.IsMaxLengthError(err)).To(BeTrue())
+
+			applicationState.Operation_state = make([]byte, 10)
+			applicationState.History = make([]byte, 262145)
+
+			err = dbq.CreateApplicationState(ctx, applicationState)
+			Expect(err).NotTo(BeNil())
+			Expect(db.IsMaxLengthError(err)).To(BeTrue())
 		})
 	})
 })
@@ -13,6 +13,10 @@ const (
 	ClusterCredentialsKubeConfigContextLength                               = 64
 	ClusterCredentialsServiceaccountBearerTokenLength                       = 2048
 	ClusterCredentialsServiceaccountNsLength                                = 128
+	ClusterCredentialsCaBundleLength                                        = 65000
+	ClusterCredentialsNamespacesLength                                      = 4096
+	ClusterCredentialsClientCertDataLength                                  = 16384
+	ClusterCredentialsClientKeyDataLength                                   = 16384
 	GitopsEngineClusterGitopsengineclusterIDLength                          = 48
 	GitopsEngineInstanceGitopsengineinstanceIDLength                        = 48
 	GitopsEngineInstanceNamespaceNameLength                                 = 48
@@ -36,7 +40,7 @@ const (
 	OperationHumanReadableStateLength                                       = 1024
 	ApplicationApplicationIDLength                                          = 48
 	ApplicationNameLength                                                   = 256
-	ApplicationSpecFieldLength                                              = 16384
+	ApplicationSpecFieldLength                                              = 65536
 	ApplicationEngineInstanceInstIDLength                                   = 48
 	ApplicationManagedEnvironmentIDLength                                   = 48
 	ApplicationStateApplicationstateApplicationIDLength                     = 48
@@ -65,6 +69,7 @@ const (
 	SyncOperationDeploymentNameLength                                       = 256
 	SyncOperationRevisionLength                                             = 256
 	SyncOperationDesiredStateLength                                         = 16
+	SyncOperationResourcesLength                                            = 16384
 	RepositoryCredentialsRepositorycredentialsIDLength                      = 48
 	RepositoryCredentialsRepoCredUserIDLength                               = 48
 	RepositoryCredentialsRepoCredURLLength                                  = 512
@@ -111,6 +116,10 @@ var DbFieldMap = map[string]int{
 	"ClusterCredentialsKubeConfigContextLength":                               ClusterCredentialsKubeConfigContextLength,
 	"ClusterCredentialsServiceaccountBearerTokenLength":                       ClusterCredentialsServiceaccountBearerTokenLength,
 	"ClusterCredentialsServiceaccountNsLength":                                ClusterCredentialsServiceaccountNsLength,
+	"ClusterCredentialsCaBundleLength":                                        ClusterCredentialsCaBundleLength,
+	"ClusterCredentialsNamespacesLength":                                      ClusterCredentialsNamespacesLength,
+	"ClusterCredentialsClientCertDataLength":                                  ClusterCredentialsClientCertDataLength,
+	"ClusterCredentialsClientKeyDataLength":                                   ClusterCredentialsClientKeyDataLength,
 	"GitopsEngineClusterGitopsengineclusterIDLength":                          GitopsEngineClusterGitopsengineclusterIDLength,
 	"GitopsEngineInstanceGitopsengineinstanceIDLength":                        GitopsEngineInstanceGitopsengineinstanceIDLength,
 	"GitopsEngineInstanceNamespaceNameLength":                                 GitopsEngineInstanceNamespaceNameLength,
@@ -144,6 +153,8 @@ var DbFieldMap = map[string]int{
 	"ApplicationStateRevisionLength":                                          ApplicationStateRevisionLength,
 	"ApplicationStateSyncStatusLength":                                        ApplicationStateSyncStatusLength,
 	"ApplicationStateResourcesLength":                                         262144, /*Size is defined here because table doesn't have byte Array limit.*/
+	"ApplicationStateOperationStateLength":                                    262144, /*Size is defined here because table doesn't have byte Array limit.*/
+	"ApplicationStateHistoryLength":                                           262144, /*Size is defined here because table doesn't have byte Array limit.*/
 	"DeploymentToApplicationMappingDeploymenttoapplicationmappingUIDIDLength": DeploymentToApplicationMappingDeploymenttoapplicationmappingUIDIDLength,
 	"DeploymentToApplicationMappingNameLength":                                DeploymentToApplicationMappingNameLength,
 	"DeploymentToApplicationMappingDeploymentNameLength":                      DeploymentToApplicationMappingNameLength,
@@ -178,6 +189,8 @@ var DbFieldMap = map[string]int{
 	"SyncOperationDeploymentNameFieldLength":                                  SyncOperationDeploymentNameLength,
 	"SyncOperationRevisionLength":                                             SyncOperationRevisionLength,
 	"SyncOperationDesiredStateLength":                                         SyncOperationDesiredStateLength,
+	"SyncOperationResourcesLength":                                            SyncOperationResourcesLength,
+	"SyncOperationOperationStateLength":                                       262144, /*Size is defined here because table doesn't have byte Array limit.*/
 	"RepositoryCredentialsRepositorycredentialsIDLength":                      RepositoryCredentialsRepositorycredentialsIDLength,
 	"RepositoryCredentialsRepoCredUserIDLength":                               RepositoryCredentialsRepoCredUserIDLength,
 	"RepositoryCredentialsRepoCredURLLength":                                  RepositoryCredentialsRepoCredURLLength,
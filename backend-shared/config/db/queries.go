@@ -179,6 +179,7 @@ type ApplicationScopedQueries interface {
 
 	CreateSyncOperation(ctx context.Context, obj *SyncOperation) error
 	GetSyncOperationById(ctx context.Context, syncOperation *SyncOperation) error
+	UpdateSyncOperation(ctx context.Context, obj *SyncOperation) error
 	DeleteSyncOperationById(ctx context.Context, id string) (int, error)
 
 	CreateApplication(ctx context.Context, obj *Application) error
@@ -73,6 +73,10 @@ func (dbq *PostgreSQLDatabaseQueries) CreateSyncOperation(ctx context.Context, o
 		return err
 	}
 
+	if err := validateSyncOperationByteArrayLength(obj); err != nil {
+		return err
+	}
+
 	result, err := dbq.dbConnection.Model(obj).Context(ctx).Insert()
 	if err != nil {
 		return fmt.Errorf("error on inserting application: %v", err)
@@ -86,6 +90,50 @@ func (dbq *PostgreSQLDatabaseQueries) CreateSyncOperation(ctx context.Context, o
 
 }
 
+// UpdateSyncOperation updates all the fields of an existing SyncOperation row, for example, to set the state of the sync
+// operation once it has started.
+func (dbq *PostgreSQLDatabaseQueries) UpdateSyncOperation(ctx context.Context, obj *SyncOperation) error {
+
+	if err := validateQueryParamsEntity(obj, dbq); err != nil {
+		return err
+	}
+
+	if err := isEmptyValues("UpdateSyncOperation",
+		"SyncOperation_id", obj.SyncOperation_id,
+		"DeploymentNameField", obj.DeploymentNameField,
+		"Revision", obj.Revision,
+		"DesiredState", obj.DesiredState); err != nil {
+		return err
+	}
+
+	if err := validateFieldLength(obj); err != nil {
+		return err
+	}
+
+	if err := validateSyncOperationByteArrayLength(obj); err != nil {
+		return err
+	}
+
+	result, err := dbq.dbConnection.Model(obj).WherePK().Context(ctx).Update()
+	if err != nil {
+		return fmt.Errorf("error on updating sync operation: %v", err)
+	}
+
+	if result.RowsAffected() != 1 {
+		return fmt.Errorf("unexpected number of rows affected: %d", result.RowsAffected())
+	}
+
+	return nil
+}
+
+// validateSyncOperationByteArrayLength checks if the number of bytes in the byte array fields of the SyncOperation
+// is more than the allowed limit.
+func validateSyncOperationByteArrayLength(obj *SyncOperation) error {
+	return validateByteArrayFieldLength("SyncOperation", []byteArrayField{
+		{name: "Operation_state", value: obj.Operation_state},
+	})
+}
+
 func (dbq *PostgreSQLDatabaseQueries) DeleteSyncOperationById(ctx context.Context, id string) (int, error) {
 
 	if err := validateQueryParams(id, dbq); err != nil {
@@ -71,6 +71,25 @@ var _ = Describe("SyncOperation Tests", func() {
 			Expect(err).To(BeNil())
 			Expect(fetchRow).Should(Equal(insertRow))
 
+			By("updating the sync options and the operation state of the SyncOperation")
+			insertRow.Prune = true
+			insertRow.Dry_run = true
+			insertRow.Force = true
+			insertRow.Resources = `[{"kind":"Deployment","name":"my-deployment"}]`
+			insertRow.Operation_state = []byte("operation-state")
+			err = dbq.UpdateSyncOperation(ctx, &insertRow)
+			Expect(err).To(BeNil())
+
+			err = dbq.GetSyncOperationById(ctx, &fetchRow)
+			Expect(err).To(BeNil())
+			Expect(fetchRow).Should(Equal(insertRow))
+
+			By("verifying that an operation state which is too large is rejected")
+			tooLargeRow := insertRow
+			tooLargeRow.Operation_state = make([]byte, db.DbFieldMap["SyncOperationOperationStateLength"]+1)
+			err = dbq.UpdateSyncOperation(ctx, &tooLargeRow)
+			Expect(db.IsMaxLengthError(err)).To(Equal(true))
+
 			rowCount, err := dbq.DeleteSyncOperationById(ctx, insertRow.SyncOperation_id)
 			Expect(err).To(BeNil())
 			Expect(rowCount).Should(Equal(1))
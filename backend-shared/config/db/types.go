@@ -105,7 +105,28 @@ type ClusterCredentials struct {
 	Serviceaccount_bearer_token string `pg:"serviceaccount_bearer_token"`
 
 	// -- State 2) The namespace of the ServiceAccount
+	// -- (empty if the bearer token/client certificate were provided directly by the user, rather than being those of a
+	// -- ServiceAccount installed by the GitOps Service)
 	Serviceaccount_ns string `pg:"serviceaccount_ns"`
+
+	// -- State 2) The time at which the ServiceAccount bearer token expires
+	// -- (zero if the token does not expire, for example a token that was not requested via the TokenRequest API)
+	Serviceaccount_bearer_token_expiry time.Time `pg:"serviceaccount_bearer_token_expiry"`
+
+	// -- State 2) PEM-encoded client certificate and key, provided by the user to authenticate to the cluster
+	// -- (may be used instead of, or alongside, the bearer token)
+	Client_cert_data string `pg:"client_cert_data"`
+	Client_key_data  string `pg:"client_key_data"`
+
+	// -- PEM-encoded CA bundle, used to verify the TLS certificate of the API server of the cluster.
+	// -- If empty, the system roots of the host are used.
+	Ca_bundle string `pg:"ca_bundle"`
+
+	// -- If true, the TLS certificate of the API server of the cluster will not be verified.
+	Allow_insecure_skip_tls_verify bool `pg:"allow_insecure_skip_tls_verify"`
+
+	// -- Comma-separated list of namespaces that the credentials are limited to: if empty, the credentials are cluster-scoped.
+	Namespaces string `pg:"namespaces"`
 }
 
 // ClusterUser is an individual user/customer
@@ -270,6 +291,16 @@ type ApplicationState struct {
 
 	Resources []byte `pg:"resources"`
 
+	// -- Compressed YAML of the Argo CD Application CR's .status.operationState field
+	Operation_state []byte `pg:"operation_state"`
+
+	// -- Compressed YAML of the sync history of the Application: this is based on the Argo CD Application CR's
+	// -- .status.history field, plus the initiator of each sync. It is bounded to the most recent 10 syncs.
+	History []byte `pg:"history"`
+
+	// -- Argo CD Application CR's .status.reconciledAt field
+	Reconciled_at time.Time `pg:"reconciled_at"`
+
 	// -- human_readable_health ( 512 ) NOT NULL,
 	// -- human_readable_sync ( 512 ) NOT NULL,
 	// -- human_readable_state ( 512 ) NOT NULL,
@@ -397,6 +428,22 @@ type SyncOperation struct {
 	Revision string `pg:"revision"`
 
 	DesiredState string `pg:"desired_state"`
+
+	// -- The ID of the entry of the sync history of the Application to roll back to, if this SyncOperation is a rollback
+	// -- (nil otherwise). See the 'rollbackToHistoryID' field of the GitOpsDeploymentSyncRun CR.
+	Rollback_history_id *int64 `pg:"rollback_history_id"`
+
+	// -- The 'prune', 'dryRun' and 'force' fields of the GitOpsDeploymentSyncRun CR: the options of the sync.
+	Prune   bool `pg:"prune"`
+	Dry_run bool `pg:"dry_run"`
+	Force   bool `pg:"force"`
+
+	// -- The 'resources' field of the GitOpsDeploymentSyncRun CR, as JSON: if non-empty, only these resources are synced.
+	Resources string `pg:"resources"`
+
+	// -- The state of the sync operation, as reported by Argo CD once it has started: this is a compressed YAML
+	// -- representation of the Argo CD Application's .status.operationState field, for the sync operation.
+	Operation_state []byte `pg:"operation_state"`
 }
 
 // TODO: GITOPSRVCE-67 - DEBT - Add comment.
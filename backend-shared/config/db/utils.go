@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"os"
 	"reflect"
@@ -214,6 +215,29 @@ func validateFieldLength(obj interface{}) error {
 	return nil
 }
 
+// byteArrayField is a byte array field of a database entity: see validateByteArrayFieldLength
+type byteArrayField struct {
+	name  string
+	value []byte
+}
+
+// validateByteArrayFieldLength checks if the number of bytes in the byte array fields of a database entity (of type
+// 'typeName') is more than the allowed limit.
+//   - validateFieldLength function is not modified as that is written for Strings, and after adding check for byte array
+//     it would get messy.
+func validateByteArrayFieldLength(typeName string, fields []byteArrayField) error {
+
+	for _, field := range fields {
+		noOfBytesInObj := binary.Size(field.value)
+		maxSize := DbFieldMap[ConvertSnakeCaseToCamelCase(typeName+"_"+field.name+"_Length")]
+		if noOfBytesInObj > maxSize {
+			return fmt.Errorf("%s value exceeds maximum size: max: %d, actual: %d", field.name, maxSize, noOfBytesInObj)
+		}
+	}
+
+	return nil
+}
+
 func IsMaxLengthError(err error) bool {
 	if err != nil {
 		return strings.Contains(err.Error(), "value exceeds maximum size")
@@ -26,7 +26,7 @@ type FauxTypeMeta struct {
 // ApplicationSpec represents desired application state. Contains link to repository with application definition and additional parameters link definition revision.
 type FauxApplicationSpec struct {
 	// Source is a reference to the location of the application's manifests or chart
-	Source ApplicationSource `json:"source" protobuf:"bytes,1,opt,name=source"`
+	Source ApplicationSource `json:"source" yaml:"source,omitempty" protobuf:"bytes,1,opt,name=source"`
 	// Destination is a reference to the target Kubernetes server and namespace
 	Destination ApplicationDestination `json:"destination" protobuf:"bytes,2,name=destination"`
 	// Project is a reference to the project this application belongs to.
@@ -34,6 +34,20 @@ type FauxApplicationSpec struct {
 	Project string `json:"project" protobuf:"bytes,3,name=project"`
 	// SyncPolicy controls when and how a sync will be performed
 	SyncPolicy *SyncPolicy `json:"syncPolicy,omitempty" protobuf:"bytes,4,name=syncPolicy"`
+	// Sources is a reference to the location of the application's manifests or chart
+	Sources ApplicationSources `json:"sources,omitempty" yaml:"sources,omitempty" protobuf:"bytes,8,opt,name=sources"`
+	// IgnoreDifferences is a list of resources and their fields which should be ignored during comparison
+	IgnoreDifferences []ResourceIgnoreDifferences `json:"ignoreDifferences,omitempty" yaml:"ignoreDifferences,omitempty" protobuf:"bytes,5,name=ignoreDifferences"`
+}
+
+// ResourceIgnoreDifferences contains resource filter and list of json paths which should be ignored during comparison with live state.
+type ResourceIgnoreDifferences struct {
+	Group             string   `json:"group,omitempty" yaml:"group,omitempty" protobuf:"bytes,1,opt,name=group"`
+	Kind              string   `json:"kind" yaml:"kind" protobuf:"bytes,2,opt,name=kind"`
+	Name              string   `json:"name,omitempty" yaml:"name,omitempty" protobuf:"bytes,3,opt,name=name"`
+	Namespace         string   `json:"namespace,omitempty" yaml:"namespace,omitempty" protobuf:"bytes,4,opt,name=namespace"`
+	JSONPointers      []string `json:"jsonPointers,omitempty" yaml:"jsonPointers,omitempty" protobuf:"bytes,5,opt,name=jsonPointers"`
+	JQPathExpressions []string `json:"jqPathExpressions,omitempty" yaml:"jqPathExpressions,omitempty" protobuf:"bytes,6,opt,name=jqPathExpressions"`
 }
 
 // ApplicationSource contains all required information about the source of an application
@@ -49,6 +63,93 @@ type ApplicationSource struct {
 	// In case of Git, this can be commit, tag, or branch. If omitted, will equal to HEAD.
 	// In case of Helm, this is a semver tag for the Chart's version.
 	TargetRevision string `json:"targetRevision,omitempty" protobuf:"bytes,4,opt,name=targetRevision"`
+
+	// Helm holds helm specific options
+	Helm *ApplicationSourceHelm `json:"helm,omitempty" yaml:"helm,omitempty" protobuf:"bytes,7,opt,name=helm"`
+
+	// Kustomize holds kustomize specific options
+	Kustomize *ApplicationSourceKustomize `json:"kustomize,omitempty" yaml:"kustomize,omitempty" protobuf:"bytes,8,opt,name=kustomize"`
+
+	// Chart is a Helm chart name, and must be specified for applications sourced from a Helm repo.
+	Chart string `json:"chart,omitempty" yaml:"chart,omitempty" protobuf:"bytes,12,opt,name=chart"`
+
+	// Ref is reference to another source within sources field. This field will not be used if used with a `source` tag.
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty" protobuf:"bytes,13,opt,name=ref"`
+}
+
+// ApplicationSources contains list of required information about the sources of an application
+type ApplicationSources []ApplicationSource
+
+// ApplicationSourceHelm holds helm specific options
+type ApplicationSourceHelm struct {
+	// ValueFiles is a list of Helm value files to use when generating a template
+	ValueFiles []string `json:"valueFiles,omitempty" yaml:"valueFiles,omitempty" protobuf:"bytes,1,opt,name=valueFiles"`
+	// Parameters is a list of Helm parameters which are passed to the helm template command upon manifest generation
+	Parameters []HelmParameter `json:"parameters,omitempty" yaml:"parameters,omitempty" protobuf:"bytes,2,opt,name=parameters"`
+	// ReleaseName is the Helm release name to use. If omitted it will use the application name
+	ReleaseName string `json:"releaseName,omitempty" yaml:"releaseName,omitempty" protobuf:"bytes,3,opt,name=releaseName"`
+	// Values specifies Helm values to be passed to helm template, typically defined as a block
+	Values string `json:"values,omitempty" yaml:"values,omitempty" protobuf:"bytes,4,opt,name=values"`
+}
+
+// HelmParameter is a parameter that's passed to helm template during manifest generation
+type HelmParameter struct {
+	// Name is the name of the Helm parameter
+	Name string `json:"name,omitempty" yaml:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	// Value is the value for the Helm parameter
+	Value string `json:"value,omitempty" yaml:"value,omitempty" protobuf:"bytes,2,opt,name=value"`
+	// ForceString determines whether to tell Helm to interpret booleans and numbers as strings
+	ForceString bool `json:"forceString,omitempty" yaml:"forceString,omitempty" protobuf:"bytes,3,opt,name=forceString"`
+}
+
+// ApplicationSourceKustomize holds options specific to an Application source specific to Kustomize
+type ApplicationSourceKustomize struct {
+	// NamePrefix is a prefix appended to resources for Kustomize apps
+	NamePrefix string `json:"namePrefix,omitempty" yaml:"namePrefix,omitempty" protobuf:"bytes,1,opt,name=namePrefix"`
+	// NameSuffix is a suffix appended to resources for Kustomize apps
+	NameSuffix string `json:"nameSuffix,omitempty" yaml:"nameSuffix,omitempty" protobuf:"bytes,2,opt,name=nameSuffix"`
+	// Images is a list of Kustomize image override specifications
+	Images KustomizeImages `json:"images,omitempty" yaml:"images,omitempty" protobuf:"bytes,3,opt,name=images"`
+	// CommonLabels is a list of additional labels to add to rendered manifests
+	CommonLabels map[string]string `json:"commonLabels,omitempty" yaml:"commonLabels,omitempty" protobuf:"bytes,4,opt,name=commonLabels"`
+	// Version controls which version of Kustomize to use for rendering manifests
+	Version string `json:"version,omitempty" yaml:"version,omitempty" protobuf:"bytes,5,opt,name=version"`
+	// CommonAnnotations is a list of additional annotations to add to rendered manifests
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty" yaml:"commonAnnotations,omitempty" protobuf:"bytes,6,opt,name=commonAnnotations"`
+	// Patches is a list of Kustomize patches
+	Patches KustomizePatches `json:"patches,omitempty" yaml:"patches,omitempty" protobuf:"bytes,12,opt,name=patches"`
+}
+
+// KustomizeImage represents a Kustomize image definition in the format [old_image_name=]<image_name>:<image_tag>
+type KustomizeImage string
+
+type KustomizeImages []KustomizeImage
+
+type KustomizePatches []KustomizePatch
+
+type KustomizePatch struct {
+	Path    string             `json:"path,omitempty" yaml:"path,omitempty" protobuf:"bytes,1,opt,name=path"`
+	Patch   string             `json:"patch,omitempty" yaml:"patch,omitempty" protobuf:"bytes,2,opt,name=patch"`
+	Target  *KustomizeSelector `json:"target,omitempty" yaml:"target,omitempty" protobuf:"bytes,3,opt,name=target"`
+	Options map[string]bool    `json:"options,omitempty" yaml:"options,omitempty" protobuf:"bytes,4,opt,name=options"`
+}
+
+type KustomizeSelector struct {
+	KustomizeResId     `json:",inline,omitempty" yaml:",inline,omitempty" protobuf:"bytes,1,opt,name=resId"`
+	AnnotationSelector string `json:"annotationSelector,omitempty" yaml:"annotationSelector,omitempty" protobuf:"bytes,2,opt,name=annotationSelector"`
+	LabelSelector      string `json:"labelSelector,omitempty" yaml:"labelSelector,omitempty" protobuf:"bytes,3,opt,name=labelSelector"`
+}
+
+type KustomizeResId struct {
+	KustomizeGvk `json:",inline,omitempty" yaml:",inline,omitempty" protobuf:"bytes,1,opt,name=gvk"`
+	Name         string `json:"name,omitempty" yaml:"name,omitempty" protobuf:"bytes,2,opt,name=name"`
+	Namespace    string `json:"namespace,omitempty" yaml:"namespace,omitempty" protobuf:"bytes,3,opt,name=namespace"`
+}
+
+type KustomizeGvk struct {
+	Group   string `json:"group,omitempty" yaml:"group,omitempty" protobuf:"bytes,1,opt,name=group"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty" protobuf:"bytes,2,opt,name=version"`
+	Kind    string `json:"kind,omitempty" yaml:"kind,omitempty" protobuf:"bytes,3,opt,name=kind"`
 }
 
 // ApplicationDestination holds information about the application's destination
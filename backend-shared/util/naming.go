@@ -0,0 +1,74 @@
+package util
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// resourceNameHashLength is the number of characters of the (hex-encoded) SHA-256 hash that are appended to a name
+// by GenerateValidResourceName, when the name must be shortened or sanitized.
+const resourceNameHashLength = 8
+
+// GenerateValidResourceName returns a name, composed of 'name' followed by 'suffix', that is a valid Kubernetes
+// resource name (a DNS-1123 subdomain) of at most 'maxLength' characters.
+//
+// If 'name'+'suffix' is already valid, it is returned unchanged. Otherwise, invalid characters are replaced with '-',
+// 'name' is truncated to as many characters as fit, and a short hash of the original 'name' is appended to it (before
+// 'suffix'). This keeps the result readable, while ensuring it is deterministic, valid, and unlikely to collide with
+// the name generated for a different value.
+//
+// A 'maxLength' of 0 (or greater than the Kubernetes maximum of 253) will use the Kubernetes maximum.
+func GenerateValidResourceName(name string, suffix string, maxLength int) string {
+
+	if maxLength <= 0 || maxLength > validation.DNS1123SubdomainMaxLength {
+		maxLength = validation.DNS1123SubdomainMaxLength
+	}
+
+	fullName := name + suffix
+
+	if len(fullName) <= maxLength && len(validation.IsDNS1123Subdomain(fullName)) == 0 {
+		return fullName
+	}
+
+	hashValStr := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))[0:resourceNameHashLength]
+
+	sanitizedSuffix := sanitizeResourceNameSuffix(suffix)
+
+	// Keep as much of the (sanitized) name as fits, alongside the hash and suffix.
+	prefix := strings.Trim(sanitizeResourceNameSuffix(name), "-")
+	if maxPrefixLength := maxLength - len(sanitizedSuffix) - len(hashValStr) - 1; len(prefix) > maxPrefixLength {
+		if maxPrefixLength < 0 {
+			maxPrefixLength = 0
+		}
+		prefix = strings.TrimRight(prefix[0:maxPrefixLength], "-")
+	}
+
+	res := hashValStr + sanitizedSuffix
+	if prefix != "" {
+		res = prefix + "-" + res
+	}
+
+	if len(res) > maxLength {
+		res = strings.TrimRight(res[0:maxLength], "-")
+	}
+
+	return res
+}
+
+// sanitizeResourceNameSuffix lowercases the suffix, replaces any character that is not a lowercase alphanumeric
+// character or '-' with a '-', and removes trailing '-' characters (as a Kubernetes name must end with an
+// alphanumeric character).
+func sanitizeResourceNameSuffix(suffix string) string {
+
+	res := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(suffix))
+
+	return strings.TrimRight(res, "-")
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var _ = Describe("GenerateValidResourceName tests", func() {
+
+	DescribeTable("should always generate a valid, deterministic resource name",
+		func(name string, suffix string, maxLength int, expected string) {
+
+			res := GenerateValidResourceName(name, suffix, maxLength)
+			Expect(res).To(Equal(expected))
+
+			By("ensuring the result is a valid Kubernetes resource name, within the maximum length")
+			Expect(validation.IsDNS1123Subdomain(res)).To(BeEmpty())
+			if maxLength > 0 {
+				Expect(len(res)).To(BeNumerically("<=", maxLength))
+			}
+
+			By("ensuring the result is deterministic")
+			Expect(GenerateValidResourceName(name, suffix, maxLength)).To(Equal(res))
+		},
+		Entry("a valid name is unchanged", "my-app", "", 63, "my-app"),
+		Entry("a valid name and suffix are unchanged", "my-app", "-deployment", 63, "my-app-deployment"),
+		Entry("a valid name of exactly the maximum length is unchanged",
+			strings.Repeat("a", 52), "-deployment", 63, strings.Repeat("a", 52)+"-deployment"),
+		Entry("a name that exceeds the maximum length is truncated, and a hash is appended",
+			strings.Repeat("a", 53), "-deployment", 63, strings.Repeat("a", 43)+"-abe346a7-deployment"),
+		Entry("a name with uppercase characters is lowercased, and a hash is appended", "My-App", "-deployment", 63,
+			"my-app-8c91cc8c-deployment"),
+		Entry("a name with invalid characters is sanitized, and a hash is appended", "my_app!", "", 63,
+			"my-app-0f0cdd35"),
+		Entry("an empty name is replaced with a hash", "", "", 63, "e3b0c442"),
+		Entry("invalid characters in the suffix are replaced", "my_app", "-My_Deployment.", 63,
+			"my-app-3fe58225-my-deployment"),
+		Entry("the name is truncated if the name, hash and suffix exceed the maximum length", "my_app", "-deployment", 24,
+			"my-a-3fe58225-deployment"),
+		Entry("the result is truncated if the hash and suffix alone exceed the maximum length", "my_app",
+			"-"+strings.Repeat("d", 40), 40, "3fe58225-"+strings.Repeat("d", 31)),
+		Entry("a maximum length of 0 uses the Kubernetes maximum", strings.Repeat("a", 253), "", 0, strings.Repeat("a", 253)),
+		Entry("a name that exceeds the Kubernetes maximum is truncated, and a hash is appended", strings.Repeat("a", 254), "", 0,
+			strings.Repeat("a", 244)+"-136496c2"),
+	)
+})
@@ -70,6 +70,7 @@ func CreateOperation(ctx context.Context, waitForOperation bool, dbOperationPara
 		Last_state_update:       time.Now(),
 		State:                   db.OperationState_Waiting,
 		Human_readable_state:    "",
+		GC_expiration_time:      dbOperationParam.GC_expiration_time,
 	}
 
 	if err := dbQueries.CreateOperation(ctx, &dbOperation, clusterUserID); err != nil {
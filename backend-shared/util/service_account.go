@@ -4,15 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,6 +26,8 @@ const (
 	ArgoCDManagerServiceAccountPrefix         = "argocd-manager-"
 	ArgoCDManagerClusterRoleNamePrefix        = "argocd-manager-cluster-role-"
 	ArgoCDManagerClusterRoleBindingNamePrefix = "argocd-manager-cluster-role-binding-"
+	ArgoCDManagerRoleNamePrefix               = "argocd-manager-role-"
+	ArgoCDManagerRoleBindingNamePrefix        = "argocd-manager-role-binding-"
 )
 
 var (
@@ -68,7 +73,10 @@ func GenerateServiceAccountName(uuid string) string {
 	return ArgoCDManagerServiceAccountPrefix + uuid
 }
 
-func InstallServiceAccount(ctx context.Context, k8sClient client.Client, uuid string, serviceAccountNS string, log logr.Logger) (string, *corev1.ServiceAccount, error) {
+// InstallServiceAccount creates (or updates) a ServiceAccount for Argo CD to use, and returns its bearer token.
+// If 'namespaces' is empty, the ServiceAccount is granted cluster-wide access via a ClusterRole/ClusterRoleBinding.
+// Otherwise, it is only granted access to those namespaces, via a Role/RoleBinding in each.
+func InstallServiceAccount(ctx context.Context, k8sClient client.Client, uuid string, serviceAccountNS string, namespaces []string, log logr.Logger) (string, *corev1.ServiceAccount, error) {
 
 	serviceAccountName := GenerateServiceAccountName(uuid)
 
@@ -77,8 +85,22 @@ func InstallServiceAccount(ctx context.Context, k8sClient client.Client, uuid st
 		return "", nil, fmt.Errorf("unable to create or update service account: %v", serviceAccountName)
 	}
 
-	if err := createOrUpdateClusterRoleAndRoleBinding(ctx, uuid, k8sClient, serviceAccountName, serviceAccountNS); err != nil {
-		return "", nil, fmt.Errorf("unable to create or update role and cluster role binding: %v", err)
+	if len(namespaces) == 0 {
+		if err := createOrUpdateClusterRoleAndRoleBinding(ctx, uuid, k8sClient, serviceAccountName, serviceAccountNS); err != nil {
+			return "", nil, fmt.Errorf("unable to create or update role and cluster role binding: %v", err)
+		}
+	} else {
+		for _, namespace := range namespaces {
+			if err := createOrUpdateRoleAndRoleBinding(ctx, uuid, k8sClient, serviceAccountName, serviceAccountNS, namespace); err != nil {
+				return "", nil, fmt.Errorf("unable to create or update role and role binding in namespace '%s': %v", namespace, err)
+			}
+		}
+
+		// Ensure the service account is no longer granted cluster-wide access, for example if the managed environment
+		// was previously cluster-scoped.
+		if err := DeleteClusterRoleAndRoleBinding(ctx, uuid, k8sClient, log); err != nil {
+			return "", nil, err
+		}
 	}
 
 	token, err := getOrCreateServiceAccountBearerToken(ctx, k8sClient, serviceAccountName, serviceAccountNS)
@@ -258,6 +280,104 @@ func addSecretToServiceAccount(ctx context.Context, k8sClient client.Client, sec
 	}
 }
 
+// RequestServiceAccountToken requests a new bearer token for the ServiceAccount installed by InstallServiceAccount, via the
+// TokenRequest API. The token expires after (approximately) 'expiration': the expiry time of the token is returned alongside it.
+func RequestServiceAccountToken(ctx context.Context, clientset kubernetes.Interface, uuid string, serviceAccountNS string,
+	expiration time.Duration) (string, time.Time, error) {
+
+	serviceAccountName := GenerateServiceAccountName(uuid)
+
+	expirationSeconds := int64(expiration.Seconds())
+
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+
+	tokenRequest, err := clientset.CoreV1().ServiceAccounts(serviceAccountNS).CreateToken(ctx, serviceAccountName, tokenRequest, metav1.CreateOptions{})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("unable to request token for service account '%s': %v", serviceAccountName, err)
+	}
+
+	if tokenRequest.Status.Token == "" {
+		return "", time.Time{}, fmt.Errorf("token request for service account '%s' did not return a token", serviceAccountName)
+	}
+
+	return tokenRequest.Status.Token, tokenRequest.Status.ExpirationTimestamp.Time, nil
+}
+
+// DeleteServiceAccountTokenSecrets revokes the long-lived bearer tokens of the ServiceAccount installed by
+// InstallServiceAccount, by deleting its token Secrets (and removing the references to them from the ServiceAccount).
+//
+// Note: tokens requested via the TokenRequest API are not stored in Secrets, and so are not revoked by this function:
+// they are instead revoked when they expire.
+func DeleteServiceAccountTokenSecrets(ctx context.Context, k8sClient client.Client, uuid string, serviceAccountNS string, log logr.Logger) error {
+
+	serviceAccountName := GenerateServiceAccountName(uuid)
+
+	secretList := corev1.SecretList{}
+	if err := k8sClient.List(ctx, &secretList, client.InNamespace(serviceAccountNS)); err != nil {
+		return fmt.Errorf("unable to list secrets in namespace '%s': %v", serviceAccountNS, err)
+	}
+
+	deletedSecrets := map[string]bool{}
+
+	for idx := range secretList.Items {
+		secret := secretList.Items[idx]
+
+		if secret.Type != corev1.SecretTypeServiceAccountToken || secret.Annotations[corev1.ServiceAccountNameKey] != serviceAccountName {
+			continue
+		}
+
+		if err := k8sClient.Delete(ctx, &secret); err != nil {
+			if !apierr.IsNotFound(err) {
+				return fmt.Errorf("unable to delete token secret '%s' of service account '%s': %v", secret.Name, serviceAccountName, err)
+			}
+		} else {
+			LogAPIResourceChangeEvent(secret.Namespace, secret.Name, secret, ResourceDeleted, log)
+		}
+		deletedSecrets[secret.Name] = true
+	}
+
+	if len(deletedSecrets) == 0 {
+		return nil
+	}
+
+	// Remove the references to the deleted Secrets from the ServiceAccount
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceAccountName,
+			Namespace: serviceAccountNS,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(serviceAccount), serviceAccount); err != nil {
+		if apierr.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to retrieve service account '%s': %v", serviceAccountName, err)
+	}
+
+	secrets := []corev1.ObjectReference{}
+	for _, secretRef := range serviceAccount.Secrets {
+		if !deletedSecrets[secretRef.Name] {
+			secrets = append(secrets, secretRef)
+		}
+	}
+
+	if len(secrets) == len(serviceAccount.Secrets) {
+		return nil
+	}
+
+	serviceAccount.Secrets = secrets
+	if err := k8sClient.Update(ctx, serviceAccount); err != nil {
+		return fmt.Errorf("unable to update service account '%s': %v", serviceAccountName, err)
+	}
+	LogAPIResourceChangeEvent(serviceAccount.Namespace, serviceAccount.Name, serviceAccount, ResourceModified, log)
+
+	return nil
+}
+
 func createOrUpdateClusterRoleAndRoleBinding(ctx context.Context, uuid string, k8sClient client.Client,
 	serviceAccountName string, serviceAccountNamespace string) error {
 	log := log.FromContext(ctx)
@@ -327,6 +447,197 @@ func createOrUpdateClusterRoleAndRoleBinding(ctx context.Context, uuid string, k
 	return nil
 }
 
+// createOrUpdateRoleAndRoleBinding grants the service account access to 'namespace', via a Role and RoleBinding.
+func createOrUpdateRoleAndRoleBinding(ctx context.Context, uuid string, k8sClient client.Client,
+	serviceAccountName string, serviceAccountNamespace string, namespace string) error {
+	log := log.FromContext(ctx)
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ArgoCDManagerRoleNamePrefix + uuid,
+			Namespace: namespace,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(role), role); err != nil {
+
+		if !apierr.IsNotFound(err) {
+			return fmt.Errorf("unable to get role: %v", err)
+		}
+
+		role.Rules = ArgoCDManagerNamespacePolicyRules
+		if err := k8sClient.Create(ctx, role); err != nil {
+			return fmt.Errorf("unable to create role: %v", err)
+		}
+		LogAPIResourceChangeEvent(role.Namespace, role.Name, role, ResourceCreated, log)
+
+	} else {
+		role.Rules = ArgoCDManagerNamespacePolicyRules
+		if err := k8sClient.Update(ctx, role); err != nil {
+			return fmt.Errorf("unable to update role: %v", err)
+		}
+		LogAPIResourceChangeEvent(role.Namespace, role.Name, role, ResourceModified, log)
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ArgoCDManagerRoleBindingNamePrefix + uuid,
+			Namespace: namespace,
+		},
+	}
+	update := true
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(roleBinding), roleBinding); err != nil {
+		if !apierr.IsNotFound(err) {
+			return fmt.Errorf("unable to get role binding: %v", err)
+		}
+		update = false
+	}
+
+	roleBinding.RoleRef = rbacv1.RoleRef{
+		APIGroup: "rbac.authorization.k8s.io",
+		Kind:     "Role",
+		Name:     role.Name,
+	}
+
+	roleBinding.Subjects = []rbacv1.Subject{{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      serviceAccountName,
+		Namespace: serviceAccountNamespace,
+	}}
+
+	if update {
+		if err := k8sClient.Update(ctx, roleBinding); err != nil {
+			return fmt.Errorf("unable to update role binding: %v", err)
+		}
+		LogAPIResourceChangeEvent(roleBinding.Namespace, roleBinding.Name, roleBinding, ResourceModified, log)
+	} else {
+		if err := k8sClient.Create(ctx, roleBinding); err != nil {
+			return fmt.Errorf("unable to create role binding: %v", err)
+		}
+		LogAPIResourceChangeEvent(roleBinding.Namespace, roleBinding.Name, roleBinding, ResourceCreated, log)
+	}
+
+	return nil
+}
+
+// DeleteClusterRoleAndRoleBinding deletes the ClusterRole/ClusterRoleBinding that grant cluster-wide access to the
+// service account of the given uuid, if they exist.
+//
+// Note: the credentials of a namespace-scoped managed environment may not have access to cluster-scoped resources:
+// in this case, it is not possible for the ClusterRole/ClusterRoleBinding to have been created with those credentials,
+// so the resulting 'forbidden' error is ignored.
+func DeleteClusterRoleAndRoleBinding(ctx context.Context, uuid string, k8sClient client.Client, log logr.Logger) error {
+
+	objects := []client.Object{
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ArgoCDManagerClusterRoleBindingNamePrefix + uuid,
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ArgoCDManagerClusterRoleNamePrefix + uuid,
+			},
+		},
+	}
+
+	for _, obj := range objects {
+		if err := k8sClient.Delete(ctx, obj); err != nil {
+			if apierr.IsNotFound(err) {
+				continue
+			}
+			if apierr.IsForbidden(err) {
+				log.Info("unable to delete cluster-scoped resource, due to insufficient permissions", "name", obj.GetName(), "error", err.Error())
+				continue
+			}
+			return fmt.Errorf("unable to delete '%s': %v", obj.GetName(), err)
+		}
+		LogAPIResourceChangeEvent(obj.GetNamespace(), obj.GetName(), obj, ResourceDeleted, log)
+	}
+
+	return nil
+}
+
+// DeleteRoleAndRoleBinding deletes the Role/RoleBinding that grant the service account of the given uuid access to
+// 'namespace', if they exist.
+func DeleteRoleAndRoleBinding(ctx context.Context, uuid string, k8sClient client.Client, namespace string, log logr.Logger) error {
+
+	objects := []client.Object{
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ArgoCDManagerRoleBindingNamePrefix + uuid,
+				Namespace: namespace,
+			},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ArgoCDManagerRoleNamePrefix + uuid,
+				Namespace: namespace,
+			},
+		},
+	}
+
+	for _, obj := range objects {
+		if err := k8sClient.Delete(ctx, obj); err != nil {
+			if apierr.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("unable to delete '%s' in namespace '%s': %w", obj.GetName(), namespace, err)
+		}
+		LogAPIResourceChangeEvent(obj.GetNamespace(), obj.GetName(), obj, ResourceDeleted, log)
+	}
+
+	return nil
+}
+
+// UninstallServiceAccount deletes the ServiceAccount installed by InstallServiceAccount, along with its token Secrets,
+// its ClusterRole/ClusterRoleBinding, and its Role/RoleBinding in each of 'namespaces'. Resources that don't exist are ignored.
+//
+// Deletion of each resource is attempted, even if a previous deletion failed: the returned error describes all the
+// resources that could not be deleted.
+func UninstallServiceAccount(ctx context.Context, k8sClient client.Client, uuid string, serviceAccountNS string, namespaces []string, log logr.Logger) error {
+
+	serviceAccountName := GenerateServiceAccountName(uuid)
+
+	failures := []string{}
+
+	deleteObject := func(obj client.Object) {
+		if err := k8sClient.Delete(ctx, obj); err != nil {
+			if !apierr.IsNotFound(err) {
+				failures = append(failures, fmt.Sprintf("unable to delete '%s': %v", obj.GetName(), err))
+			}
+			return
+		}
+		LogAPIResourceChangeEvent(obj.GetNamespace(), obj.GetName(), obj, ResourceDeleted, log)
+	}
+
+	for _, namespace := range namespaces {
+		if err := DeleteRoleAndRoleBinding(ctx, uuid, k8sClient, namespace, log); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if err := DeleteClusterRoleAndRoleBinding(ctx, uuid, k8sClient, log); err != nil {
+		failures = append(failures, err.Error())
+	}
+
+	if err := DeleteServiceAccountTokenSecrets(ctx, k8sClient, uuid, serviceAccountNS, log); err != nil {
+		failures = append(failures, err.Error())
+	}
+
+	deleteObject(&corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceAccountName,
+			Namespace: serviceAccountNS,
+		},
+	})
+
+	if len(failures) > 0 {
+		return fmt.Errorf("unable to uninstall service account '%s': %s", serviceAccountName, strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
 func generateClientFromClusterServiceAccount(configParam *rest.Config, bearerToken string) (client.Client, error) {
 
 	newConfig := *configParam
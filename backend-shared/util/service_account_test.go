@@ -4,14 +4,23 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -120,9 +129,9 @@ var _ = Describe("Service Account Tests", func() {
 		When("Test Bearer Token", func() {
 
 			It("Should pass.", func() {
-				// namespaces := []string{}
+				namespaces := []string{}
 				uuid := "my-uuid"
-				token, sa, err := InstallServiceAccount(ctx, k8sClient, uuid, "kube-system" /*namespaces,*/, log)
+				token, sa, err := InstallServiceAccount(ctx, k8sClient, uuid, "kube-system", namespaces, log)
 				Expect(err).To(BeNil())
 				Expect(token).ToNot(BeEmpty())
 				Expect(sa).ToNot(BeNil())
@@ -151,7 +160,7 @@ var _ = Describe("Service Account Tests", func() {
 
 				By("check if a new token secret is created")
 				if secret == nil {
-					token, sa, err := InstallServiceAccount(ctx, k8sClient, uuid, serviceAccountNS, log)
+					token, sa, err := InstallServiceAccount(ctx, k8sClient, uuid, serviceAccountNS, nil, log)
 					Expect(err).To(BeNil())
 					Expect(token).ToNot(BeEmpty())
 					Expect(sa).ToNot(BeNil())
@@ -177,4 +186,241 @@ var _ = Describe("Service Account Tests", func() {
 			})
 		})
 	})
+
+	Context("Namespace-scoped service account permissions test", func() {
+
+		ctx := context.Background()
+		log := log.FromContext(ctx)
+
+		const (
+			uuid               = "my-uuid"
+			serviceAccountName = ArgoCDManagerServiceAccountPrefix + uuid
+			serviceAccountNS   = "kube-system"
+		)
+
+		var k8sClient client.Client
+
+		BeforeEach(func() {
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+		})
+
+		It("should grant the service account access to a namespace via a Role and RoleBinding", func() {
+
+			err := createOrUpdateRoleAndRoleBinding(ctx, uuid, k8sClient, serviceAccountName, serviceAccountNS, "my-namespace")
+			Expect(err).To(BeNil())
+
+			role := &rbacv1.Role{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Namespace: "my-namespace", Name: ArgoCDManagerRoleNamePrefix + uuid}, role)
+			Expect(err).To(BeNil())
+			Expect(role.Rules).To(Equal(ArgoCDManagerNamespacePolicyRules))
+
+			roleBinding := &rbacv1.RoleBinding{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Namespace: "my-namespace", Name: ArgoCDManagerRoleBindingNamePrefix + uuid}, roleBinding)
+			Expect(err).To(BeNil())
+			Expect(roleBinding.RoleRef.Kind).To(Equal("Role"))
+			Expect(roleBinding.RoleRef.Name).To(Equal(role.Name))
+			Expect(roleBinding.Subjects).To(Equal([]rbacv1.Subject{{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      serviceAccountName,
+				Namespace: serviceAccountNS,
+			}}))
+
+			By("calling it again, to verify that existing resources are updated")
+			err = createOrUpdateRoleAndRoleBinding(ctx, uuid, k8sClient, serviceAccountName, serviceAccountNS, "my-namespace")
+			Expect(err).To(BeNil())
+
+			By("verifying that no cluster-wide access was granted")
+			clusterRoleBindings := rbacv1.ClusterRoleBindingList{}
+			err = k8sClient.List(ctx, &clusterRoleBindings)
+			Expect(err).To(BeNil())
+			Expect(clusterRoleBindings.Items).To(BeEmpty())
+		})
+
+		It("should delete the ClusterRole and ClusterRoleBinding of the service account, and tolerate them not existing", func() {
+
+			err := createOrUpdateClusterRoleAndRoleBinding(ctx, uuid, k8sClient, serviceAccountName, serviceAccountNS)
+			Expect(err).To(BeNil())
+
+			err = DeleteClusterRoleAndRoleBinding(ctx, uuid, k8sClient, log)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: ArgoCDManagerClusterRoleBindingNamePrefix + uuid}, &rbacv1.ClusterRoleBinding{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: ArgoCDManagerClusterRoleNamePrefix + uuid}, &rbacv1.ClusterRole{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			err = DeleteClusterRoleAndRoleBinding(ctx, uuid, k8sClient, log)
+			Expect(err).To(BeNil())
+		})
+		It("should delete the Role and RoleBinding of the service account in a namespace, and tolerate them not existing", func() {
+
+			for _, namespace := range []string{"my-namespace", "my-other-namespace"} {
+				err := createOrUpdateRoleAndRoleBinding(ctx, uuid, k8sClient, serviceAccountName, serviceAccountNS, namespace)
+				Expect(err).To(BeNil())
+			}
+
+			err := DeleteRoleAndRoleBinding(ctx, uuid, k8sClient, "my-namespace", log)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: ArgoCDManagerRoleBindingNamePrefix + uuid, Namespace: "my-namespace"}, &rbacv1.RoleBinding{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: ArgoCDManagerRoleNamePrefix + uuid, Namespace: "my-namespace"}, &rbacv1.Role{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			By("verifying that the Role and RoleBinding in the other namespace are untouched")
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: ArgoCDManagerRoleBindingNamePrefix + uuid, Namespace: "my-other-namespace"}, &rbacv1.RoleBinding{})
+			Expect(err).To(BeNil())
+
+			err = DeleteRoleAndRoleBinding(ctx, uuid, k8sClient, "my-namespace", log)
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Context("Service account token rotation test", func() {
+
+		ctx := context.Background()
+		log := log.FromContext(ctx)
+
+		const (
+			uuid               = "my-uuid"
+			serviceAccountName = ArgoCDManagerServiceAccountPrefix + uuid
+			serviceAccountNS   = "kube-system"
+		)
+
+		It("should request a token for the service account, with the given expiration, via the TokenRequest API", func() {
+
+			expirationTimestamp := metav1.NewTime(time.Now().Add(time.Hour).Truncate(time.Second))
+
+			clientset := kubefake.NewSimpleClientset()
+			clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+
+				createAction, ok := action.(k8stesting.CreateAction)
+				Expect(ok).To(BeTrue())
+				Expect(createAction.GetSubresource()).To(Equal("token"))
+				Expect(createAction.GetNamespace()).To(Equal(serviceAccountNS))
+
+				tokenRequest, ok := createAction.GetObject().(*authenticationv1.TokenRequest)
+				Expect(ok).To(BeTrue())
+				Expect(tokenRequest.Spec.ExpirationSeconds).ToNot(BeNil())
+				Expect(*tokenRequest.Spec.ExpirationSeconds).To(Equal(int64(3600)))
+
+				tokenRequest.Status = authenticationv1.TokenRequestStatus{
+					Token:               "my-token",
+					ExpirationTimestamp: expirationTimestamp,
+				}
+				return true, tokenRequest, nil
+			})
+
+			token, expiry, err := RequestServiceAccountToken(ctx, clientset, uuid, serviceAccountNS, time.Hour)
+			Expect(err).To(BeNil())
+			Expect(token).To(Equal("my-token"))
+			Expect(expiry.Equal(expirationTimestamp.Time)).To(BeTrue())
+		})
+
+		It("should delete the token secrets of the service account, and remove them from the service account", func() {
+
+			newTokenSecret := func(name string, serviceAccount string) *corev1.Secret {
+				return &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        name,
+						Namespace:   serviceAccountNS,
+						Annotations: map[string]string{corev1.ServiceAccountNameKey: serviceAccount},
+					},
+					Type: corev1.SecretTypeServiceAccountToken,
+				}
+			}
+
+			tokenSecret := newTokenSecret(serviceAccountName+"-token", serviceAccountName)
+			otherTokenSecret := newTokenSecret("other-service-account-token", "other-service-account")
+
+			serviceAccount := &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serviceAccountName,
+					Namespace: serviceAccountNS,
+				},
+				Secrets: []corev1.ObjectReference{
+					{Name: tokenSecret.Name, Namespace: serviceAccountNS},
+					{Name: "my-other-secret", Namespace: serviceAccountNS},
+				},
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(serviceAccount, tokenSecret, otherTokenSecret).Build()
+
+			err := DeleteServiceAccountTokenSecrets(ctx, k8sClient, uuid, serviceAccountNS, log)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(tokenSecret), tokenSecret)
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			By("verifying that token secrets of other service accounts are not deleted")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(otherTokenSecret), otherTokenSecret)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(serviceAccount), serviceAccount)
+			Expect(err).To(BeNil())
+			Expect(serviceAccount.Secrets).To(Equal([]corev1.ObjectReference{{Name: "my-other-secret", Namespace: serviceAccountNS}}))
+
+			By("calling it again, to verify that it succeeds when there are no token secrets")
+			err = DeleteServiceAccountTokenSecrets(ctx, k8sClient, uuid, serviceAccountNS, log)
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Context("Uninstall service account test", func() {
+
+		ctx := context.Background()
+		log := log.FromContext(ctx)
+
+		const (
+			uuid               = "my-uuid"
+			serviceAccountName = ArgoCDManagerServiceAccountPrefix + uuid
+			serviceAccountNS   = "kube-system"
+		)
+
+		It("should delete the service account, its token secret, and the roles and bindings that grant it access", func() {
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+			_, err := getOrCreateServiceAccount(ctx, k8sClient, serviceAccountName, serviceAccountNS, log)
+			Expect(err).To(BeNil())
+
+			tokenSecret, err := createServiceAccountTokenSecret(ctx, k8sClient, serviceAccountName, serviceAccountNS)
+			Expect(err).To(BeNil())
+
+			err = createOrUpdateClusterRoleAndRoleBinding(ctx, uuid, k8sClient, serviceAccountName, serviceAccountNS)
+			Expect(err).To(BeNil())
+
+			namespaces := []string{"my-namespace", "my-other-namespace"}
+			for _, namespace := range namespaces {
+				err = createOrUpdateRoleAndRoleBinding(ctx, uuid, k8sClient, serviceAccountName, serviceAccountNS, namespace)
+				Expect(err).To(BeNil())
+			}
+
+			err = UninstallServiceAccount(ctx, k8sClient, uuid, serviceAccountNS, namespaces, log)
+			Expect(err).To(BeNil())
+
+			By("verifying that all the resources have been deleted")
+			objects := []client.Object{
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName, Namespace: serviceAccountNS}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: tokenSecret.Name, Namespace: serviceAccountNS}},
+				&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: ArgoCDManagerClusterRoleNamePrefix + uuid}},
+				&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: ArgoCDManagerClusterRoleBindingNamePrefix + uuid}},
+			}
+			for _, namespace := range namespaces {
+				objects = append(objects,
+					&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: ArgoCDManagerRoleNamePrefix + uuid, Namespace: namespace}},
+					&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: ArgoCDManagerRoleBindingNamePrefix + uuid, Namespace: namespace}})
+			}
+			for _, obj := range objects {
+				err = k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+				Expect(apierr.IsNotFound(err)).To(BeTrue(), "resource '%s' should be deleted", obj.GetName())
+			}
+
+			By("calling it again, to verify that it succeeds when the resources no longer exist")
+			err = UninstallServiceAccount(ctx, k8sClient, uuid, serviceAccountNS, namespaces, log)
+			Expect(err).To(BeNil())
+		})
+	})
 })
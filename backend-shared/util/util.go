@@ -21,6 +21,17 @@ const (
 	ManagedEnvironmentSecretType = "managed-gitops.redhat.com/managed-environment"
 )
 
+// Fields of a managed environment Secret: the Secret must contain either a kubeconfig, or a bearer token and/or a
+// client certificate and key.
+const (
+	ManagedEnvironmentSecretKubeConfigKey = "kubeconfig"
+	ManagedEnvironmentSecretTokenKey      = "token"
+	ManagedEnvironmentSecretClientCertKey = "tls.crt"
+	ManagedEnvironmentSecretClientKeyKey  = "tls.key"
+	// ManagedEnvironmentSecretCACertKey is an optional CA bundle, used when the Secret does not contain a kubeconfig
+	ManagedEnvironmentSecretCACertKey = "ca.crt"
+)
+
 // ExponentialBackoff: the more times in a row something fails, the longer we wait.
 type ExponentialBackoff struct {
 	Factor float64
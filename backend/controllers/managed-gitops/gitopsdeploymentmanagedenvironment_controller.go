@@ -34,6 +34,7 @@ import (
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/eventlooptypes"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/preprocess_event_loop"
+	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/shared_resource_loop"
 )
 
 // GitOpsDeploymentManagedEnvironmentReconciler reconciles a GitOpsDeploymentManagedEnvironment object
@@ -66,6 +67,8 @@ func (r *GitOpsDeploymentManagedEnvironmentReconciler) Reconcile(ctx context.Con
 
 	requestsToProcess := []ctrl.Request{}
 
+	result := ctrl.Result{}
+
 	// Attempt to retrieve the request as a Secret; if it's not a secret, just pass the event as is.
 	secret := &corev1.Secret{}
 	if err := r.Client.Get(ctx, req.NamespacedName, secret); err == nil && secret != nil {
@@ -93,6 +96,13 @@ func (r *GitOpsDeploymentManagedEnvironmentReconciler) Reconcile(ctx context.Con
 	} else {
 		// If it's not a Secret, it's a GitOpsDeploymentManagedEnvironment, so just add it to the request list
 		requestsToProcess = append(requestsToProcess, req)
+
+		// Periodically requeue existing managed environments, so that their credentials are re-verified, and the
+		// tokens of their service accounts are rotated.
+		managedEnv := &managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{}
+		if err := r.Client.Get(ctx, req.NamespacedName, managedEnv); err == nil {
+			result.RequeueAfter = shared_resource_loop.ServiceAccountTokenRotationInterval
+		}
 	}
 
 	for idx := range requestsToProcess {
@@ -101,7 +111,7 @@ func (r *GitOpsDeploymentManagedEnvironmentReconciler) Reconcile(ctx context.Con
 
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 type PreprocessEventLoopProcessor interface {
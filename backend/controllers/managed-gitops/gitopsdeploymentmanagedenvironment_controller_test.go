@@ -24,6 +24,7 @@ import (
 	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/shared_resource_loop"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -164,12 +165,24 @@ var _ = Describe("GitOpsDeploymentManagedEnvironment Controller Test", func() {
 
 		})
 
+		It("reconciles on a deleted managed-env, without requeuing it", func() {
+			result, err := reconciler.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: namespace.Name,
+					Name:      "deleted-managed-env",
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(len(mockProcessor.requestsReceived)).Should(Equal(1))
+			Expect(result.RequeueAfter).To(BeZero())
+		})
+
 		It("reconciles on a managed-env", func() {
 			// secret with the right type, and 2 managed envs referring to it
 			// expect: 2
 			secret := createSecret("my-secret", true)
 			managedEnv := createManagedEnvTargetingSecret("managed-env1", secret)
-			_, err := reconciler.Reconcile(context.Background(), ctrl.Request{
+			result, err := reconciler.Reconcile(context.Background(), ctrl.Request{
 				NamespacedName: types.NamespacedName{
 					Namespace: managedEnv.Namespace,
 					Name:      managedEnv.Name,
@@ -177,6 +190,8 @@ var _ = Describe("GitOpsDeploymentManagedEnvironment Controller Test", func() {
 			})
 			Expect(err).To(BeNil())
 			Expect(len(mockProcessor.requestsReceived)).Should(Equal(1))
+			Expect(result.RequeueAfter).To(Equal(shared_resource_loop.ServiceAccountTokenRotationInterval),
+				"the managed env should be periodically requeued, so that its service account token is rotated")
 
 		})
 
@@ -26,6 +26,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 )
 
 type deploymentModifiedResult string
@@ -229,7 +230,14 @@ func (a applicationEventLoopRunner_Action) handleNewGitOpsDeplEvent(ctx context.
 		sourceRepoURL:        gitopsDeployment.Spec.Source.RepoURL,
 		sourcePath:           gitopsDeployment.Spec.Source.Path,
 		sourceTargetRevision: gitopsDeployment.Spec.Source.TargetRevision,
+		sourceChart:          gitopsDeployment.Spec.Source.Chart,
+		sourceHelm:           gitopsDeployment.Spec.Source.Helm,
+		sourceKustomize:      gitopsDeployment.Spec.Source.Kustomize,
+		sources:              gitopsDeployment.Spec.Sources,
 		automated:            strings.EqualFold(gitopsDeployment.Spec.Type, managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated),
+		suspended:            gitopsDeployment.Spec.Suspend,
+		syncPolicy:           gitopsDeployment.Spec.SyncPolicy,
+		ignoreDifferences:    gitopsDeployment.Spec.IgnoreDifferences,
 	}
 
 	specFieldText, err := createSpecField(specFieldInput)
@@ -463,7 +471,14 @@ func (a applicationEventLoopRunner_Action) handleUpdatedGitOpsDeplEvent(ctx cont
 		sourceRepoURL:        gitopsDeployment.Spec.Source.RepoURL,
 		sourcePath:           gitopsDeployment.Spec.Source.Path,
 		sourceTargetRevision: gitopsDeployment.Spec.Source.TargetRevision,
+		sourceChart:          gitopsDeployment.Spec.Source.Chart,
+		sourceHelm:           gitopsDeployment.Spec.Source.Helm,
+		sourceKustomize:      gitopsDeployment.Spec.Source.Kustomize,
+		sources:              gitopsDeployment.Spec.Sources,
 		automated:            strings.EqualFold(gitopsDeployment.Spec.Type, managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated),
+		suspended:            gitopsDeployment.Spec.Suspend,
+		syncPolicy:           gitopsDeployment.Spec.SyncPolicy,
+		ignoreDifferences:    gitopsDeployment.Spec.IgnoreDifferences,
 	}
 
 	shouldUpdateApplication := false
@@ -725,6 +740,7 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleUpdateD
 	gitopsDeployment.Status.Health.Message = applicationState.Message
 	gitopsDeployment.Status.Sync.Status = managedgitopsv1alpha1.SyncStatusCode(applicationState.Sync_Status)
 	gitopsDeployment.Status.Sync.Revision = applicationState.Revision
+	gitopsDeployment.Status.Suspended = gitopsDeployment.Spec.Suspend
 
 	// Fetch the list of resources created by deployment from table and update local gitopsDeployment instance.
 	var err error
@@ -734,6 +750,25 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleUpdateD
 		return err
 	}
 
+	// Fetch the state of the most recent sync operation, and the sync history, from the table
+	gitopsDeployment.Status.OperationState, err = decompressOperationState(applicationState.Operation_state)
+	if err != nil {
+		a.log.Error(err, "unable to decompress operation state received from table.")
+		return err
+	}
+
+	gitopsDeployment.Status.History, err = decompressHistory(applicationState.History)
+	if err != nil {
+		a.log.Error(err, "unable to decompress history received from table.")
+		return err
+	}
+
+	gitopsDeployment.Status.ReconciledAt = nil
+	if !applicationState.Reconciled_at.IsZero() {
+		reconciledAt := metav1.NewTime(applicationState.Reconciled_at)
+		gitopsDeployment.Status.ReconciledAt = &reconciledAt
+	}
+
 	// Update the actual object in Kubernetes
 	if err := a.workspaceClient.Status().Update(ctx, gitopsDeployment, &client.UpdateOptions{}); err != nil {
 		return err
@@ -742,6 +777,11 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleUpdateD
 
 	// NOTE: make sure to preserve the existing conditions fields that are in the status field of the CR, when updating the status!
 
+	// 5) update the status of the GitOpsDeploymentSyncRuns that target the GitOpsDeployment
+	if err := a.updateSyncRunStatuses(ctx, *gitopsDeployment, dbQueries); err != nil {
+		return err
+	}
+
 	return nil
 
 }
@@ -823,8 +863,30 @@ type argoCDSpecInput struct {
 	sourceRepoURL        string
 	sourcePath           string
 	sourceTargetRevision string
+	sourceChart          string
 	// MAKE SURE YOU SANITIZE ANY NEW FIELDS THAT ARE ADDED!!!!
 	automated bool
+	// If suspended, the Application is never automatically synced (even if automated is true)
+	suspended bool
+
+	// The Helm values (and parameter values) are not sanitized (values legitimately contain quotes, newlines, etc),
+	// but they are only ever marshalled as YAML string values. The value files and parameter names are sanitized.
+	sourceHelm *managedgitopsv1alpha1.ApplicationSourceHelm
+
+	// The patch content of kustomize patches, and the values of common annotations, are not sanitized (they
+	// legitimately contain quotes, newlines, etc), but they are only ever marshalled as YAML string values. All
+	// other kustomize fields are sanitized.
+	sourceKustomize *managedgitopsv1alpha1.ApplicationSourceKustomize
+
+	// The fields of each of the sources are sanitized in the same way as the fields of the single source, above.
+	sources []managedgitopsv1alpha1.ApplicationSource
+
+	// The sync options and retry backoff durations of the sync policy are sanitized.
+	syncPolicy *managedgitopsv1alpha1.SyncPolicy
+
+	// The jq path expressions of ignoreDifferences are not sanitized (they legitimately contain quotes, pipes, etc),
+	// but they are only ever marshalled as YAML string values. All other ignoreDifferences fields are sanitized.
+	ignoreDifferences []managedgitopsv1alpha1.ResourceIgnoreDifferences
 
 	// Hopefully you are getting the message, here :)
 }
@@ -854,7 +916,9 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 		sourceRepoURL:        sanitize(fieldsParam.sourceRepoURL),
 		sourcePath:           sanitize(fieldsParam.sourcePath),
 		sourceTargetRevision: sanitize(fieldsParam.sourceTargetRevision),
+		sourceChart:          sanitize(fieldsParam.sourceChart),
 		automated:            fieldsParam.automated,
+		suspended:            fieldsParam.suspended,
 		// MAKE SURE YOU SANITIZE ANY NEW FIELDS THAT ARE ADDED!!!!
 
 		// Hopefully you are getting the message, here :)
@@ -874,6 +938,7 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 				RepoURL:        fields.sourceRepoURL,
 				Path:           fields.sourcePath,
 				TargetRevision: fields.sourceTargetRevision,
+				Chart:          fields.sourceChart,
 			},
 			Destination: fauxargocd.ApplicationDestination{
 				Name:      fields.destinationName,
@@ -883,7 +948,28 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 		},
 	}
 
-	if fields.automated {
+	application.Spec.Source.Helm = createFauxHelmSource(fieldsParam.sourceHelm, sanitize)
+	application.Spec.Source.Kustomize = createFauxKustomizeSource(fieldsParam.sourceKustomize, sanitize)
+
+	if len(fieldsParam.sources) > 0 {
+		// Argo CD ignores 'source' when 'sources' is specified, so we only include the latter
+		application.Spec.Source = fauxargocd.ApplicationSource{}
+
+		for _, source := range fieldsParam.sources {
+			application.Spec.Sources = append(application.Spec.Sources, fauxargocd.ApplicationSource{
+				RepoURL:        sanitize(source.RepoURL),
+				Path:           sanitize(source.Path),
+				TargetRevision: sanitize(source.TargetRevision),
+				Chart:          sanitize(source.Chart),
+				Ref:            sanitize(source.Ref),
+				Helm:           createFauxHelmSource(source.Helm, sanitize),
+				Kustomize:      createFauxKustomizeSource(source.Kustomize, sanitize),
+			})
+		}
+	}
+
+	// A suspended GitOpsDeployment is rendered without automated sync, so that Argo CD neither syncs nor self-heals it.
+	if fields.automated && !fields.suspended {
 		application.Spec.SyncPolicy = &fauxargocd.SyncPolicy{
 			Automated: &fauxargocd.SyncPolicyAutomated{
 				Prune:      true,
@@ -891,6 +977,57 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 				AllowEmpty: true,
 			},
 		}
+
+		if fieldsParam.syncPolicy != nil {
+			if fieldsParam.syncPolicy.Prune != nil {
+				application.Spec.SyncPolicy.Automated.Prune = *fieldsParam.syncPolicy.Prune
+			}
+			if fieldsParam.syncPolicy.SelfHeal != nil {
+				application.Spec.SyncPolicy.Automated.SelfHeal = *fieldsParam.syncPolicy.SelfHeal
+			}
+		}
+	}
+
+	if fieldsParam.syncPolicy != nil && (len(fieldsParam.syncPolicy.SyncOptions) > 0 || fieldsParam.syncPolicy.Retry != nil) {
+
+		if application.Spec.SyncPolicy == nil {
+			application.Spec.SyncPolicy = &fauxargocd.SyncPolicy{}
+		}
+
+		for _, syncOption := range fieldsParam.syncPolicy.SyncOptions {
+			application.Spec.SyncPolicy.SyncOptions = append(application.Spec.SyncPolicy.SyncOptions, sanitize(syncOption))
+		}
+
+		if retry := fieldsParam.syncPolicy.Retry; retry != nil {
+			application.Spec.SyncPolicy.Retry = &fauxargocd.RetryStrategy{
+				Limit: retry.Limit,
+			}
+
+			if retry.Backoff != nil {
+				application.Spec.SyncPolicy.Retry.Backoff = &fauxargocd.Backoff{
+					Duration:    sanitize(retry.Backoff.Duration),
+					Factor:      retry.Backoff.Factor,
+					MaxDuration: sanitize(retry.Backoff.MaxDuration),
+				}
+			}
+		}
+	}
+
+	for _, ignoreDifference := range fieldsParam.ignoreDifferences {
+
+		fauxIgnoreDifference := fauxargocd.ResourceIgnoreDifferences{
+			Group:             sanitize(ignoreDifference.Group),
+			Kind:              sanitize(ignoreDifference.Kind),
+			Name:              sanitize(ignoreDifference.Name),
+			Namespace:         sanitize(ignoreDifference.Namespace),
+			JQPathExpressions: ignoreDifference.JQPathExpressions,
+		}
+
+		for _, jsonPointer := range ignoreDifference.JSONPointers {
+			fauxIgnoreDifference.JSONPointers = append(fauxIgnoreDifference.JSONPointers, sanitize(jsonPointer))
+		}
+
+		application.Spec.IgnoreDifferences = append(application.Spec.IgnoreDifferences, fauxIgnoreDifference)
 	}
 
 	resBytes, err := goyaml.Marshal(application)
@@ -902,16 +1039,159 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 	return string(resBytes), nil
 }
 
+// createFauxHelmSource converts the Helm options of a GitOpsDeployment source into the Helm options of an Argo CD
+// Application source, sanitizing fields as described in argoCDSpecInput.
+func createFauxHelmSource(helm *managedgitopsv1alpha1.ApplicationSourceHelm, sanitize func(string) string) *fauxargocd.ApplicationSourceHelm {
+
+	if helm == nil {
+		return nil
+	}
+
+	res := &fauxargocd.ApplicationSourceHelm{
+		Values: helm.Values,
+	}
+
+	for _, valueFile := range helm.ValueFiles {
+		res.ValueFiles = append(res.ValueFiles, sanitize(valueFile))
+	}
+
+	for _, param := range helm.Parameters {
+		res.Parameters = append(res.Parameters, fauxargocd.HelmParameter{
+			Name:        sanitize(param.Name),
+			Value:       param.Value,
+			ForceString: param.ForceString,
+		})
+	}
+
+	return res
+}
+
+// createFauxKustomizeSource converts the kustomize options of a GitOpsDeployment source into the kustomize options of
+// an Argo CD Application source, sanitizing fields as described in argoCDSpecInput.
+func createFauxKustomizeSource(kustomize *managedgitopsv1alpha1.ApplicationSourceKustomize, sanitize func(string) string) *fauxargocd.ApplicationSourceKustomize {
+
+	if kustomize == nil {
+		return nil
+	}
+
+	res := &fauxargocd.ApplicationSourceKustomize{
+		NamePrefix: sanitize(kustomize.NamePrefix),
+		NameSuffix: sanitize(kustomize.NameSuffix),
+		Version:    sanitize(kustomize.Version),
+	}
+
+	for _, image := range kustomize.Images {
+		res.Images = append(res.Images, fauxargocd.KustomizeImage(sanitize(image)))
+	}
+
+	if len(kustomize.CommonLabels) > 0 {
+		res.CommonLabels = map[string]string{}
+		for key, value := range kustomize.CommonLabels {
+			res.CommonLabels[sanitize(key)] = sanitize(value)
+		}
+	}
+
+	if len(kustomize.CommonAnnotations) > 0 {
+		res.CommonAnnotations = map[string]string{}
+		for key, value := range kustomize.CommonAnnotations {
+			res.CommonAnnotations[sanitize(key)] = value
+		}
+	}
+
+	for _, patch := range kustomize.Patches {
+
+		fauxPatch := fauxargocd.KustomizePatch{Patch: patch.Patch}
+
+		if patch.Target != nil {
+			fauxPatch.Target = &fauxargocd.KustomizeSelector{
+				KustomizeResId: fauxargocd.KustomizeResId{
+					KustomizeGvk: fauxargocd.KustomizeGvk{
+						Group:   sanitize(patch.Target.Group),
+						Version: sanitize(patch.Target.Version),
+						Kind:    sanitize(patch.Target.Kind),
+					},
+					Name:      sanitize(patch.Target.Name),
+					Namespace: sanitize(patch.Target.Namespace),
+				},
+				AnnotationSelector: sanitize(patch.Target.AnnotationSelector),
+				LabelSelector:      sanitize(patch.Target.LabelSelector),
+			}
+		}
+
+		res.Patches = append(res.Patches, fauxPatch)
+	}
+
+	return res
+}
+
 // Decompress byte array received from table to get String and then convert it into ResourceStatus Array.
 func decompressResourceData(resourceData []byte) ([]managedgitopsv1alpha1.ResourceStatus, error) {
 	var resourceList []managedgitopsv1alpha1.ResourceStatus
 
+	resourceStr, err := decompressData(resourceData)
+	if err != nil {
+		return resourceList, err
+	}
+
+	// Convert resource string into ResourceStatus Array
+	err = goyaml.Unmarshal(resourceStr, &resourceList)
+	if err != nil {
+		return resourceList, fmt.Errorf("unable to Unmarshal resource data: %v", err)
+	}
+
+	return resourceList, nil
+}
+
+// decompressOperationState decompresses the operation state of an ApplicationState, as written by the cluster-agent.
+// Returns nil if no operation state has been recorded (for example, if no sync has occurred yet).
+func decompressOperationState(operationStateData []byte) (*managedgitopsv1alpha1.OperationState, error) {
+	if len(operationStateData) == 0 {
+		return nil, nil
+	}
+
+	operationStateStr, err := decompressData(operationStateData)
+	if err != nil {
+		return nil, err
+	}
+
+	// The operation state is marshaled by the cluster-agent with sigs.k8s.io/yaml, which respects the JSON field names
+	// of the API types, and thus it must be unmarshaled the same way.
+	operationState := &managedgitopsv1alpha1.OperationState{}
+	if err := yaml.Unmarshal(operationStateStr, operationState); err != nil {
+		return nil, fmt.Errorf("unable to Unmarshal operation state: %v", err)
+	}
+
+	return operationState, nil
+}
+
+// decompressHistory decompresses the sync history of an ApplicationState, as written by the cluster-agent.
+func decompressHistory(historyData []byte) ([]managedgitopsv1alpha1.RevisionHistory, error) {
+	if len(historyData) == 0 {
+		return nil, nil
+	}
+
+	historyStr, err := decompressData(historyData)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []managedgitopsv1alpha1.RevisionHistory
+	if err := yaml.Unmarshal(historyStr, &history); err != nil {
+		return nil, fmt.Errorf("unable to Unmarshal history: %v", err)
+	}
+
+	return history, nil
+}
+
+// decompressData decompresses data that was compressed by the cluster-agent, using gzip
+func decompressData(data []byte) ([]byte, error) {
+
 	// Decompress data to get actual resource string
-	bufferIn := bytes.NewBuffer(resourceData)
+	bufferIn := bytes.NewBuffer(data)
 	gzipReader, err := gzip.NewReader(bufferIn)
 
 	if err != nil {
-		return resourceList, fmt.Errorf("unable to create gzipReader: %v", err)
+		return nil, fmt.Errorf("unable to create gzipReader: %v", err)
 	}
 
 	var bufferOut bytes.Buffer
@@ -924,19 +1204,13 @@ func decompressResourceData(resourceData []byte) ([]managedgitopsv1alpha1.Resour
 			if err == io.EOF {
 				break
 			}
-			return resourceList, fmt.Errorf("unable to convert resource data to string: %v", err)
+			return nil, fmt.Errorf("unable to convert resource data to string: %v", err)
 		}
 	}
 
 	if err := gzipReader.Close(); err != nil {
-		return resourceList, fmt.Errorf("unable to close gzip reader connection: %v", err)
+		return nil, fmt.Errorf("unable to close gzip reader connection: %v", err)
 	}
 
-	// Convert resource string into ResourceStatus Array
-	err = goyaml.Unmarshal(bufferOut.Bytes(), &resourceList)
-	if err != nil {
-		return resourceList, fmt.Errorf("unable to Unmarshal resource data: %v", err)
-	}
-
-	return resourceList, nil
+	return bufferOut.Bytes(), nil
 }
@@ -3,6 +3,7 @@ package application_event_loop
 import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
 	"gopkg.in/yaml.v2"
 )
@@ -89,5 +90,318 @@ var _ = Describe("Application Event Runner Deployments", func() {
 			Expect(err).To(BeNil())
 			Expect(application).To(Equal(getValidApplication(true)))
 		})
+
+		It("Input spec with kustomize patches should include the patches in the kustomize source", func() {
+			input := getfakeArgoCDSpecInput(false, false)
+			input.sourceKustomize = &managedgitopsv1alpha1.ApplicationSourceKustomize{
+				Patches: []managedgitopsv1alpha1.KustomizePatch{
+					{
+						Patch: "spec:\n  replicas: 3\n",
+						Target: &managedgitopsv1alpha1.KustomizeSelector{
+							Group: "apps",
+							Kind:  "Deployment",
+							Name:  "component-a\";",
+						},
+					},
+				},
+			}
+
+			applicationText, err := createSpecField(input)
+			Expect(err).To(BeNil())
+
+			application := fauxargocd.FauxApplication{}
+			err = yaml.Unmarshal([]byte(applicationText), &application)
+			Expect(err).To(BeNil())
+
+			Expect(application.Spec.Source.Kustomize).ToNot(BeNil())
+			Expect(application.Spec.Source.Kustomize.Patches).To(HaveLen(1))
+
+			patch := application.Spec.Source.Kustomize.Patches[0]
+			Expect(patch.Patch).To(Equal(input.sourceKustomize.Patches[0].Patch))
+			Expect(patch.Target).ToNot(BeNil())
+			Expect(patch.Target.Group).To(Equal("apps"))
+			Expect(patch.Target.Kind).To(Equal("Deployment"))
+			Expect(patch.Target.Name).To(Equal("component-a"), "target fields should be sanitized")
+		})
+
+		It("Input spec with kustomize options should include the options in the kustomize source", func() {
+			input := getfakeArgoCDSpecInput(false, false)
+			input.sourceKustomize = &managedgitopsv1alpha1.ApplicationSourceKustomize{
+				NamePrefix:        "prod-",
+				NameSuffix:        "-v2;",
+				CommonLabels:      map[string]string{"app.kubernetes.io/part-of": "my-app'"},
+				CommonAnnotations: map[string]string{"description": "My \"production\" app"},
+				Images:            []string{"my-image=quay.io/my-org/my-image:v2", "`other-image:v3`"},
+				Version:           "v4.5.7",
+			}
+
+			applicationText, err := createSpecField(input)
+			Expect(err).To(BeNil())
+
+			application := fauxargocd.FauxApplication{}
+			err = yaml.Unmarshal([]byte(applicationText), &application)
+			Expect(err).To(BeNil())
+
+			kustomize := application.Spec.Source.Kustomize
+			Expect(kustomize).ToNot(BeNil())
+			Expect(kustomize.NamePrefix).To(Equal("prod-"))
+			Expect(kustomize.NameSuffix).To(Equal("-v2"), "name suffix should be sanitized")
+			Expect(kustomize.CommonLabels).To(Equal(map[string]string{"app.kubernetes.io/part-of": "my-app"}),
+				"common labels should be sanitized")
+			Expect(kustomize.CommonAnnotations).To(Equal(input.sourceKustomize.CommonAnnotations),
+				"common annotation values should not be sanitized")
+			Expect(kustomize.Images).To(Equal(fauxargocd.KustomizeImages{"my-image=quay.io/my-org/my-image:v2", "other-image:v3"}),
+				"images should be sanitized")
+			Expect(kustomize.Version).To(Equal("v4.5.7"))
+			Expect(kustomize.Patches).To(BeEmpty())
+
+			By("verifying the generated spec field is deterministic, as it is compared with the spec field in the database")
+			for i := 0; i < 5; i++ {
+				Expect(createSpecField(input)).To(Equal(applicationText))
+			}
+		})
+
+		It("Input spec without kustomize patches should not include a kustomize source", func() {
+			input := getfakeArgoCDSpecInput(false, false)
+			application, err := createSpecField(input)
+			Expect(err).To(BeNil())
+			Expect(application).ToNot(ContainSubstring("kustomize"))
+		})
+
+		It("Input spec with an automated sync policy should respect the prune and selfHeal settings", func() {
+			input := getfakeArgoCDSpecInput(true, false)
+			prune, selfHeal := false, true
+			input.syncPolicy = &managedgitopsv1alpha1.SyncPolicy{
+				Prune:    &prune,
+				SelfHeal: &selfHeal,
+			}
+
+			applicationText, err := createSpecField(input)
+			Expect(err).To(BeNil())
+
+			application := fauxargocd.FauxApplication{}
+			err = yaml.Unmarshal([]byte(applicationText), &application)
+			Expect(err).To(BeNil())
+
+			Expect(application.Spec.SyncPolicy).ToNot(BeNil())
+			Expect(application.Spec.SyncPolicy.Automated).To(Equal(&fauxargocd.SyncPolicyAutomated{
+				Prune:      false,
+				SelfHeal:   true,
+				AllowEmpty: true,
+			}))
+			Expect(application.Spec.SyncPolicy.SyncOptions).To(BeEmpty())
+			Expect(application.Spec.SyncPolicy.Retry).To(BeNil())
+		})
+
+		It("Input spec of a suspended GitOpsDeployment should not be automated, but should retain the other sync policy fields", func() {
+			input := getfakeArgoCDSpecInput(true, false)
+			input.suspended = true
+			input.syncPolicy = &managedgitopsv1alpha1.SyncPolicy{
+				SyncOptions: []string{managedgitopsv1alpha1.SyncOption_CreateNamespace},
+			}
+
+			applicationText, err := createSpecField(input)
+			Expect(err).To(BeNil())
+
+			application := fauxargocd.FauxApplication{}
+			err = yaml.Unmarshal([]byte(applicationText), &application)
+			Expect(err).To(BeNil())
+
+			Expect(application.Spec.SyncPolicy).ToNot(BeNil())
+			Expect(application.Spec.SyncPolicy.Automated).To(BeNil(), "a suspended GitOpsDeployment should not be automated")
+			Expect(application.Spec.SyncPolicy.SyncOptions).To(Equal(fauxargocd.SyncOptions{"CreateNamespace=true"}))
+
+			By("verifying that a suspended GitOpsDeployment without a sync policy generates the same Application as a manual one")
+			input.syncPolicy = nil
+			applicationText, err = createSpecField(input)
+			Expect(err).To(BeNil())
+			Expect(applicationText).To(Equal(getValidApplication(false)))
+		})
+
+		It("Input spec with an empty sync policy should generate the same Application as no sync policy", func() {
+			input := getfakeArgoCDSpecInput(true, false)
+			input.syncPolicy = &managedgitopsv1alpha1.SyncPolicy{}
+
+			application, err := createSpecField(input)
+			Expect(err).To(BeNil())
+			Expect(application).To(Equal(getValidApplication(true)))
+		})
+
+		It("Input spec with sync options and retry should include them in the sync policy", func() {
+			factor := int64(2)
+			input := getfakeArgoCDSpecInput(false, false)
+			input.syncPolicy = &managedgitopsv1alpha1.SyncPolicy{
+				SyncOptions: []string{
+					managedgitopsv1alpha1.SyncOption_CreateNamespace,
+					managedgitopsv1alpha1.SyncOption_ServerSideApply,
+					managedgitopsv1alpha1.SyncOption_PruneLast + "\";",
+				},
+				Retry: &managedgitopsv1alpha1.RetryStrategy{
+					Limit: 5,
+					Backoff: &managedgitopsv1alpha1.Backoff{
+						Duration:    "5s",
+						Factor:      &factor,
+						MaxDuration: "3m'",
+					},
+				},
+			}
+
+			applicationText, err := createSpecField(input)
+			Expect(err).To(BeNil())
+
+			application := fauxargocd.FauxApplication{}
+			err = yaml.Unmarshal([]byte(applicationText), &application)
+			Expect(err).To(BeNil())
+
+			Expect(application.Spec.SyncPolicy).ToNot(BeNil())
+			Expect(application.Spec.SyncPolicy.Automated).To(BeNil(), "a manual GitOpsDeployment should not be automated")
+			Expect(application.Spec.SyncPolicy.SyncOptions).To(Equal(fauxargocd.SyncOptions{
+				"CreateNamespace=true", "ServerSideApply=true", "PruneLast=true"}), "sync options should be sanitized")
+			Expect(application.Spec.SyncPolicy.Retry).To(Equal(&fauxargocd.RetryStrategy{
+				Limit: 5,
+				Backoff: &fauxargocd.Backoff{
+					Duration:    "5s",
+					Factor:      &factor,
+					MaxDuration: "3m",
+				},
+			}))
+		})
+
+		It("Input spec with ignoreDifferences should include them in the Application", func() {
+			input := getfakeArgoCDSpecInput(true, false)
+			input.ignoreDifferences = []managedgitopsv1alpha1.ResourceIgnoreDifferences{
+				{
+					Group:        "apps",
+					Kind:         "Deployment",
+					Name:         "component-a;",
+					JSONPointers: []string{"/spec/replicas", "/metadata/annotations/`example`"},
+				},
+				{
+					Kind: "Pod",
+					JQPathExpressions: []string{
+						".spec.containers[] | select(.name == \"istio-proxy\")",
+					},
+				},
+			}
+
+			applicationText, err := createSpecField(input)
+			Expect(err).To(BeNil())
+
+			application := fauxargocd.FauxApplication{}
+			err = yaml.Unmarshal([]byte(applicationText), &application)
+			Expect(err).To(BeNil())
+
+			Expect(application.Spec.IgnoreDifferences).To(Equal([]fauxargocd.ResourceIgnoreDifferences{
+				{
+					Group:        "apps",
+					Kind:         "Deployment",
+					Name:         "component-a",
+					JSONPointers: []string{"/spec/replicas", "/metadata/annotations/example"},
+				},
+				{
+					Kind:              "Pod",
+					JQPathExpressions: input.ignoreDifferences[1].JQPathExpressions,
+				},
+			}), "all fields other than the jq path expressions should be sanitized")
+		})
+
+		It("Input spec with a Helm chart source should include the chart and Helm options", func() {
+			input := getfakeArgoCDSpecInput(false, false)
+			input.sourceRepoURL = "https://charts.example.com"
+			input.sourcePath = ""
+			input.sourceTargetRevision = "1.2.3"
+			input.sourceChart = "my-chart\";"
+			input.sourceHelm = &managedgitopsv1alpha1.ApplicationSourceHelm{
+				ValueFiles: []string{"values-prod.yaml", "values-'extra'.yaml"},
+				Parameters: []managedgitopsv1alpha1.HelmParameter{
+					{Name: "image.tag;", Value: "\"v1\"", ForceString: true},
+				},
+				Values: "replicaCount: 2\nservice:\n  type: \"ClusterIP\"\n",
+			}
+
+			applicationText, err := createSpecField(input)
+			Expect(err).To(BeNil())
+
+			application := fauxargocd.FauxApplication{}
+			err = yaml.Unmarshal([]byte(applicationText), &application)
+			Expect(err).To(BeNil())
+
+			Expect(application.Spec.Source.Chart).To(Equal("my-chart"), "chart should be sanitized")
+			Expect(application.Spec.Source.TargetRevision).To(Equal("1.2.3"))
+
+			Expect(application.Spec.Source.Helm).ToNot(BeNil())
+			Expect(application.Spec.Source.Helm.ValueFiles).To(Equal([]string{"values-prod.yaml", "values-extra.yaml"}),
+				"value files should be sanitized")
+			Expect(application.Spec.Source.Helm.Values).To(Equal(input.sourceHelm.Values), "values should not be sanitized")
+			Expect(application.Spec.Source.Helm.Parameters).To(Equal([]fauxargocd.HelmParameter{
+				{Name: "image.tag", Value: "\"v1\"", ForceString: true},
+			}))
+		})
+
+		It("Input spec with multiple sources should include only the sources, and not the single source", func() {
+			input := getfakeArgoCDSpecInput(false, false)
+			input.sources = []managedgitopsv1alpha1.ApplicationSource{
+				{
+					RepoURL:        "https://charts.example.com",
+					Chart:          "my-chart",
+					TargetRevision: "1.2.3",
+					Helm: &managedgitopsv1alpha1.ApplicationSourceHelm{
+						ValueFiles: []string{"$values/environments/prod/values.yaml"},
+					},
+				},
+				{
+					RepoURL:        "https://github.com/test/values`",
+					TargetRevision: "main",
+					Ref:            "values;",
+				},
+				{
+					RepoURL: "https://github.com/test/test",
+					Path:    "components/my-component",
+					Kustomize: &managedgitopsv1alpha1.ApplicationSourceKustomize{
+						NamePrefix: "prod-",
+					},
+				},
+			}
+
+			applicationText, err := createSpecField(input)
+			Expect(err).To(BeNil())
+			Expect(applicationText).ToNot(ContainSubstring("source:"))
+
+			application := fauxargocd.FauxApplication{}
+			err = yaml.Unmarshal([]byte(applicationText), &application)
+			Expect(err).To(BeNil())
+
+			Expect(application.Spec.Source).To(Equal(fauxargocd.ApplicationSource{}))
+			Expect(application.Spec.Sources).To(Equal(fauxargocd.ApplicationSources{
+				{
+					RepoURL:        "https://charts.example.com",
+					Chart:          "my-chart",
+					TargetRevision: "1.2.3",
+					Helm: &fauxargocd.ApplicationSourceHelm{
+						ValueFiles: []string{"$values/environments/prod/values.yaml"},
+					},
+				},
+				{
+					RepoURL:        "https://github.com/test/values",
+					TargetRevision: "main",
+					Ref:            "values",
+				},
+				{
+					RepoURL: "https://github.com/test/test",
+					Path:    "components/my-component",
+					Kustomize: &fauxargocd.ApplicationSourceKustomize{
+						NamePrefix: "prod-",
+					},
+				},
+			}), "the fields of each source should be sanitized")
+		})
+
+		It("Input spec without Helm options should not include a Helm source", func() {
+			input := getfakeArgoCDSpecInput(false, false)
+			application, err := createSpecField(input)
+			Expect(err).To(BeNil())
+			Expect(application).ToNot(ContainSubstring("helm"))
+			Expect(application).ToNot(ContainSubstring("chart"))
+		})
 	})
 })
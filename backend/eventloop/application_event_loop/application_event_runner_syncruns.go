@@ -2,7 +2,10 @@ package application_event_loop
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 
 	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	db "github.com/redhat-appstudio/managed-gitops/backend-shared/config/db"
@@ -20,6 +23,12 @@ import (
 
 // This file is responsible for processing events related to GitOpsDeploymentSyncRun CR.
 
+const (
+	// syncRunOperationGCExpirationTime is the amount of time (in seconds) after a SyncRun Operation has completed, after
+	// which it will be garbage collected by the cluster-agent.
+	syncRunOperationGCExpirationTime = 60 * 60
+)
+
 func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleSyncRunModified(ctx context.Context, dbQueries db.ApplicationScopedQueries) (bool, error) {
 
 	log := a.log
@@ -181,12 +190,40 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleSyncRun
 		// in reverse order.
 		var createdResources []db.AppScopedDisposableResource
 
+		revision := syncRunCR.Spec.RevisionID
+
+		// If the SyncRun is a rollback, sync to the revision of the targeted history entry
+		if syncRunCR.Spec.RollbackToHistoryID != nil {
+			if revision, err = getRollbackRevision(ctx, *syncRunCR, *gitopsDeployment, *application, dbQueries); err != nil {
+				log.Error(err, "unable to roll back GitOpsDeployment")
+				return false, err
+			}
+		}
+
+		syncResources, err := syncRunResourcesToJSON(syncRunCR.Spec.Resources)
+		if err != nil {
+			log.Error(err, "unable to convert the resources of the GitOpsDeploymentSyncRun")
+			return false, err
+		}
+
+		// If the SyncRun was terminated before we first saw it, the sync should never start: the cluster-agent still
+		// processes the Operation, so that it can report that the sync operation was terminated.
+		desiredState := db.SyncOperation_DesiredState_Running
+		if syncRunCR.Spec.Terminate {
+			desiredState = db.SyncOperation_DesiredState_Terminated
+		}
+
 		// Create sync operation
 		syncOperation := &db.SyncOperation{
 			Application_id:      application.Application_id,
 			DeploymentNameField: syncRunCR.Spec.GitopsDeploymentName,
-			Revision:            syncRunCR.Spec.RevisionID,
-			DesiredState:        db.SyncOperation_DesiredState_Running,
+			Revision:            revision,
+			DesiredState:        desiredState,
+			Rollback_history_id: syncRunCR.Spec.RollbackToHistoryID,
+			Prune:               syncRunCR.Spec.Prune,
+			Dry_run:             syncRunCR.Spec.DryRun,
+			Force:               syncRunCR.Spec.Force,
+			Resources:           syncResources,
 		}
 		if err := dbQueries.CreateSyncOperation(ctx, syncOperation); err != nil {
 			log.Error(err, "unable to create sync operation in database")
@@ -228,13 +265,16 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleSyncRun
 			return false, err
 		}
 
+		// The sync may take a long time to complete, so we don't wait for it: instead, the cluster-agent garbage
+		// collects the Operation once it has completed.
 		dbOperationInput := db.Operation{
-			Instance_id:   gitopsEngineInstance.Gitopsengineinstance_id,
-			Resource_id:   syncOperation.SyncOperation_id,
-			Resource_type: db.OperationResourceType_SyncOperation,
+			Instance_id:        gitopsEngineInstance.Gitopsengineinstance_id,
+			Resource_id:        syncOperation.SyncOperation_id,
+			Resource_type:      db.OperationResourceType_SyncOperation,
+			GC_expiration_time: syncRunOperationGCExpirationTime,
 		}
 
-		k8sOperation, dbOperation, err := operations.CreateOperation(ctx, false && !a.testOnlySkipCreateOperation, dbOperationInput, clusterUser.Clusteruser_id,
+		_, _, err = operations.CreateOperation(ctx, false, dbOperationInput, clusterUser.Clusteruser_id,
 			dbutil.GetGitOpsEngineSingleInstanceNamespace(), dbQueries, operationClient, log)
 		if err != nil {
 			log.Error(err, "could not create operation", "namespace", dbutil.GetGitOpsEngineSingleInstanceNamespace())
@@ -245,13 +285,6 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleSyncRun
 			return false, err
 		}
 
-		// TODO: GITOPSRVCE-82 - STUB - Remove the 'false' in createOperation above, once cluster agent handling of operation is implemented.
-		log.Info("STUB: Not waiting for create Sync Run operation to complete, in handleNewSyncRunModified")
-
-		if err := operations.CleanupOperation(ctx, *dbOperation, *k8sOperation, dbutil.GetGitOpsEngineSingleInstanceNamespace(), dbQueries, operationClient, log); err != nil {
-			return false, err
-		}
-
 		return false, nil
 	}
 
@@ -283,39 +316,23 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleSyncRun
 			return false, err
 		}
 
-		// 2) Update the state of the SyncOperation DB table to say that we want to terminate it, if it is runing
-		syncOperation.DesiredState = db.SyncOperation_DesiredState_Terminated
-
-		dbOperationInput := db.Operation{
-			Instance_id:   gitopsEngineInstance.Gitopsengineinstance_id,
-			Resource_id:   syncOperation.SyncOperation_id,
-			Resource_type: db.OperationResourceType_SyncOperation,
-		}
-
-		// 3) Create the operation, in order to inform the cluster agent it needs to cancel the sync operation
-		operationClient, err := a.getK8sClientForGitOpsEngineInstance(gitopsEngineInstance)
-		if err != nil {
-			log.Error(err, "unable to retrieve gitopsengine instance from handleSyncRunModified, when resource was deleted")
-			return false, err
-		}
-
+		// 2) Update the state of the SyncOperation DB table to say that we want to terminate it, if it is running, and
+		// create the operation, in order to inform the cluster agent it needs to cancel the sync operation.
 		waitForOperation := !a.testOnlySkipCreateOperation // if it's for a unit test, we don't wait for the operation
-		k8sOperation, dbOperation, err := operations.CreateOperation(ctx, waitForOperation, dbOperationInput, clusterUser.Clusteruser_id,
-			dbutil.GetGitOpsEngineSingleInstanceNamespace(), dbQueries, operationClient, log)
+		k8sOperation, dbOperation, operationClient, err := a.terminateSyncOperation(ctx, &syncOperation, waitForOperation,
+			*clusterUser, namespace, dbQueries)
 		if err != nil {
-			log.Error(err, "could not create operation, when resource was deleted", "namespace", dbutil.GetGitOpsEngineSingleInstanceNamespace())
-
+			log.Error(err, "unable to terminate sync operation, when resource was deleted")
 			return false, err
 		}
 
-		// 4) Clean up the operation and database table entries
-		if err := operations.CleanupOperation(ctx, *dbOperation, *k8sOperation, dbutil.GetGitOpsEngineSingleInstanceNamespace(), dbQueries, operationClient, log); err != nil {
-			return false, err
+		// 3) Clean up the operation and database table entries
+		if k8sOperation != nil && dbOperation != nil {
+			if err := operations.CleanupOperation(ctx, *dbOperation, *k8sOperation, dbutil.GetGitOpsEngineSingleInstanceNamespace(), dbQueries, operationClient, log); err != nil {
+				return false, err
+			}
 		}
 
-		// TODO: GITOPSRVCE-82 - STUB - need to implement support for sync operation in cluster agent
-		log.Info("STUB: need to implement sync on cluster side")
-
 		if _, err := dbQueries.DeleteSyncOperationById(ctx, syncOperation.SyncOperation_id); err != nil {
 			log.Error(err, "could not delete sync operation, when resource was deleted", "namespace", dbutil.GetGitOpsEngineSingleInstanceNamespace())
 			return false, err
@@ -386,12 +403,51 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleSyncRun
 			return false, err
 		}
 
-		if syncOperation.Revision != syncRunCR.Spec.RevisionID {
+		if syncOperation.Rollback_history_id != nil || syncRunCR.Spec.RollbackToHistoryID != nil {
+			// For a rollback, the revision of the SyncOperation is that of the history entry, so compare the history ID instead.
+			if syncOperation.Rollback_history_id == nil || syncRunCR.Spec.RollbackToHistoryID == nil ||
+				*syncOperation.Rollback_history_id != *syncRunCR.Spec.RollbackToHistoryID {
+
+				err := fmt.Errorf("rollback history id change is not supported: changing it from its initial value is not supported")
+				log.Error(err, "rollback history id field change is not supported")
+				return false, err
+			}
+
+		} else if syncOperation.Revision != syncRunCR.Spec.RevisionID {
 			err := fmt.Errorf("revision change is not supported: changing it from its initial value is not supported")
 			log.Error(err, "revision field change is not supported")
 			return false, err
 		}
 
+		syncResources, err := syncRunResourcesToJSON(syncRunCR.Spec.Resources)
+		if err != nil {
+			log.Error(err, "unable to convert the resources of the GitOpsDeploymentSyncRun")
+			return false, err
+		}
+
+		if syncOperation.Prune != syncRunCR.Spec.Prune || syncOperation.Dry_run != syncRunCR.Spec.DryRun ||
+			syncOperation.Force != syncRunCR.Spec.Force || syncOperation.Resources != syncResources {
+			err := fmt.Errorf("sync options change is not supported: changing them from their initial value is not supported")
+			log.Error(err, "sync options field change is not supported")
+			return false, err
+		}
+
+		// Terminate the sync operation, if requested
+		if syncOperation.DesiredState == db.SyncOperation_DesiredState_Terminated && !syncRunCR.Spec.Terminate {
+			err := fmt.Errorf("terminate field change is not supported: a terminated sync operation cannot be resumed")
+			log.Error(err, "terminate field change is not supported")
+			return false, err
+
+		} else if syncOperation.DesiredState != db.SyncOperation_DesiredState_Terminated && syncRunCR.Spec.Terminate {
+
+			// The sync may take a long time to terminate, so we don't wait for it: instead, the cluster-agent garbage
+			// collects the Operation once it has completed.
+			if _, _, _, err := a.terminateSyncOperation(ctx, &syncOperation, false, *clusterUser, namespace, dbQueries); err != nil {
+				log.Error(err, "unable to terminate sync operation")
+				return false, err
+			}
+		}
+
 		// TODO: GITOPSRVCE-67 - DEBT - Include test case to check that the various goroutines are terminated when the CR is deleted.
 
 		return false, nil
@@ -401,6 +457,232 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleSyncRun
 
 }
 
+// getRollbackRevision validates a GitOpsDeploymentSyncRun that requests a rollback, and returns the revision of the entry
+// of the GitOpsDeployment's sync history that it targets.
+func getRollbackRevision(ctx context.Context, syncRunCR managedgitopsv1alpha1.GitOpsDeploymentSyncRun,
+	gitopsDeployment managedgitopsv1alpha1.GitOpsDeployment, application db.Application, dbQueries db.ApplicationScopedQueries) (string, error) {
+
+	historyID := *syncRunCR.Spec.RollbackToHistoryID
+
+	if syncRunCR.Spec.RevisionID != "" {
+		return "", fmt.Errorf("revisionID must not be specified when rollbackToHistoryID is specified")
+	}
+
+	// An automated GitOpsDeployment would immediately be synced back to the latest revision, by Argo CD.
+	if !strings.EqualFold(gitopsDeployment.Spec.Type, managedgitopsv1alpha1.GitOpsDeploymentSpecType_Manual) {
+		return "", fmt.Errorf("rollback is only supported for GitOpsDeployments of type '%s'", managedgitopsv1alpha1.GitOpsDeploymentSpecType_Manual)
+	}
+
+	applicationState := &db.ApplicationState{Applicationstate_application_id: application.Application_id}
+	if err := dbQueries.GetApplicationStateById(ctx, applicationState); err != nil {
+		if db.IsResultNotFoundError(err) {
+			return "", fmt.Errorf("unable to roll back to history id '%d': the GitOpsDeployment has no sync history", historyID)
+		}
+		return "", fmt.Errorf("unable to retrieve application state, on sync run modified: %v", err)
+	}
+
+	history, err := decompressHistory(applicationState.History)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range history {
+		if entry.ID != historyID {
+			continue
+		}
+
+		if entry.Revision == "" {
+			return "", fmt.Errorf("unable to roll back to history id '%d': the history entry has no revision", historyID)
+		}
+
+		return entry.Revision, nil
+	}
+
+	return "", fmt.Errorf("unable to roll back to history id '%d': no such entry in the sync history of the GitOpsDeployment", historyID)
+}
+
+// terminateSyncOperation sets the desired state of a SyncOperation to terminated, and, if the sync operation has not
+// yet completed, creates an Operation to inform the cluster-agent that it should cancel the sync.
+// The Operation (and the client of the cluster that it was created on) is returned, or nil if it was not created.
+func (a *applicationEventLoopRunner_Action) terminateSyncOperation(ctx context.Context, syncOperation *db.SyncOperation,
+	waitForOperation bool, clusterUser db.ClusterUser, namespace corev1.Namespace,
+	dbQueries db.ApplicationScopedQueries) (*managedgitopsv1alpha1.Operation, *db.Operation, client.Client, error) {
+
+	log := a.log.WithValues("syncOperationID", syncOperation.SyncOperation_id)
+
+	if syncOperation.DesiredState != db.SyncOperation_DesiredState_Terminated {
+		syncOperation.DesiredState = db.SyncOperation_DesiredState_Terminated
+
+		if err := dbQueries.UpdateSyncOperation(ctx, syncOperation); err != nil {
+			log.Error(err, "unable to update desired state of sync operation")
+			return nil, nil, nil, err
+		}
+		log.Info("Updated desired state of SyncOperation to terminated")
+	}
+
+	// If the sync operation has already completed, there is nothing to cancel.
+	if operationState, err := decompressOperationState(syncOperation.Operation_state); err != nil {
+		log.Error(err, "unable to decompress operation state of sync operation")
+		return nil, nil, nil, err
+	} else if operationState != nil && operationState.FinishedAt != nil {
+		return nil, nil, nil, nil
+	}
+
+	if syncOperation.Application_id == "" {
+		log.Info("SyncOperation does not reference an Application, so there is no sync to terminate")
+		return nil, nil, nil, nil
+	}
+
+	// Locate the Argo CD instance that the sync operation is running on, via the Application
+	application := &db.Application{Application_id: syncOperation.Application_id}
+	if err := dbQueries.GetApplicationById(ctx, application); err != nil {
+		if db.IsResultNotFoundError(err) {
+			log.Info("Application of SyncOperation no longer exists, so there is no sync to terminate")
+			return nil, nil, nil, nil
+		}
+		log.Error(err, "unable to retrieve application of sync operation")
+		return nil, nil, nil, err
+	}
+
+	gitopsEngineInstance, err := a.sharedResourceEventLoop.GetGitopsEngineInstanceById(ctx, application.Engine_instance_inst_id,
+		a.workspaceClient, namespace)
+	if err != nil {
+		log.Error(err, "unable to retrieve gitopsengineinstance of sync operation", "instanceId", application.Engine_instance_inst_id)
+		return nil, nil, nil, err
+	}
+
+	operationClient, err := a.getK8sClientForGitOpsEngineInstance(gitopsEngineInstance)
+	if err != nil {
+		log.Error(err, "unable to retrieve gitopsengine instance client, on terminate sync operation")
+		return nil, nil, nil, err
+	}
+
+	dbOperationInput := db.Operation{
+		Instance_id:   gitopsEngineInstance.Gitopsengineinstance_id,
+		Resource_id:   syncOperation.SyncOperation_id,
+		Resource_type: db.OperationResourceType_SyncOperation,
+	}
+	if !waitForOperation {
+		dbOperationInput.GC_expiration_time = syncRunOperationGCExpirationTime
+	}
+
+	k8sOperation, dbOperation, err := operations.CreateOperation(ctx, waitForOperation, dbOperationInput, clusterUser.Clusteruser_id,
+		dbutil.GetGitOpsEngineSingleInstanceNamespace(), dbQueries, operationClient, log)
+	if err != nil {
+		log.Error(err, "could not create operation, on terminate sync operation", "namespace", dbutil.GetGitOpsEngineSingleInstanceNamespace())
+		return nil, nil, nil, err
+	}
+
+	return k8sOperation, dbOperation, operationClient, nil
+}
+
+// syncRunResourcesToJSON converts the resources of a GitOpsDeploymentSyncRun into the JSON format stored in the
+// 'resources' field of the SyncOperation table. An empty string is returned if no resources are specified.
+func syncRunResourcesToJSON(resources []managedgitopsv1alpha1.SyncRunResource) (string, error) {
+	if len(resources) == 0 {
+		return "", nil
+	}
+
+	resourcesJSON, err := json.Marshal(resources)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal resources of sync run: %v", err)
+	}
+
+	return string(resourcesJSON), nil
+}
+
+// updateSyncRunStatuses updates the status of the GitOpsDeploymentSyncRuns that target the given GitOpsDeployment,
+// from the operation state reported by the cluster-agent in the corresponding SyncOperation rows.
+func (a *applicationEventLoopRunner_Action) updateSyncRunStatuses(ctx context.Context,
+	gitopsDeployment managedgitopsv1alpha1.GitOpsDeployment, dbQueries db.ApplicationScopedQueries) error {
+
+	var syncRunList managedgitopsv1alpha1.GitOpsDeploymentSyncRunList
+	if err := a.workspaceClient.List(ctx, &syncRunList, &client.ListOptions{Namespace: gitopsDeployment.Namespace}); err != nil {
+		a.log.Error(err, "unable to list GitOpsDeploymentSyncRuns, on deploymentStatusTick")
+		return err
+	}
+
+	for idx := range syncRunList.Items {
+		syncRun := syncRunList.Items[idx]
+
+		// Once a sync operation has completed, its status will no longer change.
+		if syncRun.Spec.GitopsDeploymentName != gitopsDeployment.Name || syncRun.Status.FinishedAt != nil {
+			continue
+		}
+
+		log := a.log.WithValues("syncRun", syncRun.Name)
+
+		apiCRToDBMapping := db.APICRToDatabaseMapping{
+			APIResourceType: db.APICRToDatabaseMapping_ResourceType_GitOpsDeploymentSyncRun,
+			APIResourceUID:  string(syncRun.UID),
+			DBRelationType:  db.APICRToDatabaseMapping_DBRelationType_SyncOperation,
+		}
+		if err := dbQueries.GetDatabaseMappingForAPICR(ctx, &apiCRToDBMapping); err != nil {
+			if !db.IsResultNotFoundError(err) {
+				log.Error(err, "unable to retrieve APICRToDatabaseMapping of GitOpsDeploymentSyncRun")
+			}
+			continue
+		}
+
+		syncOperation := db.SyncOperation{SyncOperation_id: apiCRToDBMapping.DBRelationKey}
+		if err := dbQueries.GetSyncOperationById(ctx, &syncOperation); err != nil {
+			if !db.IsResultNotFoundError(err) {
+				log.Error(err, "unable to retrieve SyncOperation of GitOpsDeploymentSyncRun")
+			}
+			continue
+		}
+
+		operationState, err := decompressOperationState(syncOperation.Operation_state)
+		if err != nil {
+			log.Error(err, "unable to decompress operation state of SyncOperation")
+			continue
+		}
+
+		newStatus := syncRun.Status.DeepCopy()
+		setSyncRunStatusFromOperationState(newStatus, operationState)
+
+		if reflect.DeepEqual(syncRun.Status, *newStatus) {
+			continue
+		}
+
+		syncRun.Status = *newStatus
+		if err := a.workspaceClient.Status().Update(ctx, &syncRun); err != nil {
+			log.Error(err, "unable to update status of GitOpsDeploymentSyncRun")
+			continue
+		}
+		sharedutil.LogAPIResourceChangeEvent(syncRun.Namespace, syncRun.Name, syncRun, sharedutil.ResourceModified, log)
+	}
+
+	return nil
+}
+
+// setSyncRunStatusFromOperationState sets the sync operation fields of a GitOpsDeploymentSyncRun status, from the
+// state of the sync operation. The status is unchanged if the sync operation has not yet started.
+func setSyncRunStatusFromOperationState(status *managedgitopsv1alpha1.GitOpsDeploymentSyncRunStatus,
+	operationState *managedgitopsv1alpha1.OperationState) {
+
+	if operationState == nil {
+		return
+	}
+
+	status.Phase = operationState.Phase
+	status.Message = operationState.Message
+
+	status.StartedAt = nil
+	if !operationState.StartedAt.IsZero() {
+		startedAt := operationState.StartedAt
+		status.StartedAt = &startedAt
+	}
+	status.FinishedAt = operationState.FinishedAt.DeepCopy()
+
+	status.Revision = ""
+	status.Resources = nil
+	if operationState.SyncResult != nil {
+		status.Revision = operationState.SyncResult.Revision
+		status.Resources = operationState.SyncResult.Resources
+	}
+}
+
 func (a *applicationEventLoopRunner_Action) cleanupOldSyncDBEntry(ctx context.Context, apiCRToDB *db.APICRToDatabaseMapping,
 	clusterUser db.ClusterUser, dbQueries db.ApplicationScopedQueries) error {
 
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1/mocks"
@@ -31,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -38,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	sigsyaml "sigs.k8s.io/yaml"
 )
 
 var _ = Describe("ApplicationEventLoop Test", func() {
@@ -601,6 +604,192 @@ var _ = Describe("ApplicationEventLoop Test", func() {
 			Expect(err).To(BeNil())
 		})
 
+		It("Ensure the sync run handler terminates the sync operation when terminate is set, and rejects unsetting it", func() {
+			ctx := context.Background()
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+
+			gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-gitops-depl",
+					Namespace: workspace.Name,
+					UID:       uuid.NewUUID(),
+				},
+			}
+
+			gitopsDeplSyncRun := &managedgitopsv1alpha1.GitOpsDeploymentSyncRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-gitops-depl-sync",
+					Namespace: workspace.Name,
+					UID:       uuid.NewUUID(),
+				},
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSyncRunSpec{
+					GitopsDeploymentName: gitopsDepl.Name,
+					RevisionID:           "HEAD",
+				},
+			}
+
+			informer := sharedutil.ListEventReceiver{}
+
+			k8sClientOuter := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gitopsDepl, gitopsDeplSyncRun, workspace, argocdNamespace, kubesystemNamespace).Build()
+			k8sClient := &sharedutil.ProxyClient{
+				InnerClient: k8sClientOuter,
+				Informer:    &informer,
+			}
+
+			dbQueries, err := db.NewUnsafePostgresDBQueries(true, false)
+			Expect(err).To(BeNil())
+
+			sharedResourceLoop := shared_resource_loop.NewSharedResourceLoop()
+
+			a := applicationEventLoopRunner_Action{
+				getK8sClientForGitOpsEngineInstance: func(gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error) {
+					return k8sClient, nil
+				},
+				eventResourceName:           gitopsDepl.Name,
+				eventResourceNamespace:      gitopsDepl.Namespace,
+				workspaceClient:             k8sClient,
+				log:                         log.FromContext(context.Background()),
+				sharedResourceEventLoop:     sharedResourceLoop,
+				workspaceID:                 string(workspace.UID),
+				testOnlySkipCreateOperation: true,
+			}
+			_, _, _, _, err = a.applicationEventRunner_handleDeploymentModified(ctx, dbQueries)
+			Expect(err).To(BeNil())
+
+			a.eventResourceName = gitopsDeplSyncRun.Name
+			a.eventResourceNamespace = gitopsDeplSyncRun.Namespace
+			_, err = a.applicationEventRunner_handleSyncRunModified(ctx, dbQueries)
+			Expect(err).To(BeNil())
+
+			apiCRToDBMapping := db.APICRToDatabaseMapping{
+				APIResourceType: db.APICRToDatabaseMapping_ResourceType_GitOpsDeploymentSyncRun,
+				APIResourceUID:  string(gitopsDeplSyncRun.UID),
+				DBRelationType:  db.APICRToDatabaseMapping_DBRelationType_SyncOperation,
+			}
+			err = dbQueries.GetDatabaseMappingForAPICR(ctx, &apiCRToDBMapping)
+			Expect(err).To(BeNil())
+
+			syncOperation := db.SyncOperation{SyncOperation_id: apiCRToDBMapping.DBRelationKey}
+			err = dbQueries.GetSyncOperationById(ctx, &syncOperation)
+			Expect(err).To(BeNil())
+			Expect(syncOperation.DesiredState).To(Equal(db.SyncOperation_DesiredState_Running))
+
+			By("setting terminate on the GitOpsDeploymentSyncRun")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(gitopsDeplSyncRun), gitopsDeplSyncRun)
+			Expect(err).To(BeNil())
+			gitopsDeplSyncRun.Spec.Terminate = true
+			err = k8sClient.Update(ctx, gitopsDeplSyncRun)
+			Expect(err).To(BeNil())
+
+			_, err = a.applicationEventRunner_handleSyncRunModified(ctx, dbQueries)
+			Expect(err).To(BeNil())
+
+			err = dbQueries.GetSyncOperationById(ctx, &syncOperation)
+			Expect(err).To(BeNil())
+			Expect(syncOperation.DesiredState).To(Equal(db.SyncOperation_DesiredState_Terminated))
+
+			clusterUser, _, err := sharedResourceLoop.GetOrCreateClusterUserByNamespaceUID(ctx, k8sClient, *workspace)
+			Expect(err).To(BeNil())
+
+			// The cluster-agent has not yet processed the Operation of the sync, so it is reused for the terminate: the
+			// cluster-agent will see the terminated desired state of the SyncOperation when it processes it.
+			var dbOperations []db.Operation
+			err = dbQueries.ListOperationsByResourceIdAndTypeAndOwnerId(ctx, syncOperation.SyncOperation_id,
+				db.OperationResourceType_SyncOperation, &dbOperations, clusterUser.Clusteruser_id)
+			Expect(err).To(BeNil())
+			Expect(dbOperations).To(HaveLen(1))
+
+			By("unsetting terminate on the GitOpsDeploymentSyncRun, which should be rejected")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(gitopsDeplSyncRun), gitopsDeplSyncRun)
+			Expect(err).To(BeNil())
+			gitopsDeplSyncRun.Spec.Terminate = false
+			err = k8sClient.Update(ctx, gitopsDeplSyncRun)
+			Expect(err).To(BeNil())
+
+			_, err = a.applicationEventRunner_handleSyncRunModified(ctx, dbQueries)
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("Ensure the sync run handler creates a terminated sync operation, if terminate is set when the sync run is created", func() {
+			ctx := context.Background()
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+
+			gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-gitops-depl",
+					Namespace: workspace.Name,
+					UID:       uuid.NewUUID(),
+				},
+			}
+
+			gitopsDeplSyncRun := &managedgitopsv1alpha1.GitOpsDeploymentSyncRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-gitops-depl-sync",
+					Namespace: workspace.Name,
+					UID:       uuid.NewUUID(),
+				},
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSyncRunSpec{
+					GitopsDeploymentName: gitopsDepl.Name,
+					RevisionID:           "HEAD",
+					Terminate:            true,
+				},
+			}
+
+			informer := sharedutil.ListEventReceiver{}
+
+			k8sClientOuter := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gitopsDepl, gitopsDeplSyncRun, workspace, argocdNamespace, kubesystemNamespace).Build()
+			k8sClient := &sharedutil.ProxyClient{
+				InnerClient: k8sClientOuter,
+				Informer:    &informer,
+			}
+
+			dbQueries, err := db.NewUnsafePostgresDBQueries(true, false)
+			Expect(err).To(BeNil())
+
+			sharedResourceLoop := shared_resource_loop.NewSharedResourceLoop()
+
+			a := applicationEventLoopRunner_Action{
+				getK8sClientForGitOpsEngineInstance: func(gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error) {
+					return k8sClient, nil
+				},
+				eventResourceName:           gitopsDepl.Name,
+				eventResourceNamespace:      gitopsDepl.Namespace,
+				workspaceClient:             k8sClient,
+				log:                         log.FromContext(context.Background()),
+				sharedResourceEventLoop:     sharedResourceLoop,
+				workspaceID:                 string(workspace.UID),
+				testOnlySkipCreateOperation: true,
+			}
+			_, _, _, _, err = a.applicationEventRunner_handleDeploymentModified(ctx, dbQueries)
+			Expect(err).To(BeNil())
+
+			a.eventResourceName = gitopsDeplSyncRun.Name
+			a.eventResourceNamespace = gitopsDeplSyncRun.Namespace
+			_, err = a.applicationEventRunner_handleSyncRunModified(ctx, dbQueries)
+			Expect(err).To(BeNil())
+
+			apiCRToDBMapping := db.APICRToDatabaseMapping{
+				APIResourceType: db.APICRToDatabaseMapping_ResourceType_GitOpsDeploymentSyncRun,
+				APIResourceUID:  string(gitopsDeplSyncRun.UID),
+				DBRelationType:  db.APICRToDatabaseMapping_DBRelationType_SyncOperation,
+			}
+			err = dbQueries.GetDatabaseMappingForAPICR(ctx, &apiCRToDBMapping)
+			Expect(err).To(BeNil())
+
+			syncOperation := db.SyncOperation{SyncOperation_id: apiCRToDBMapping.DBRelationKey}
+			err = dbQueries.GetSyncOperationById(ctx, &syncOperation)
+			Expect(err).To(BeNil())
+			Expect(syncOperation.DesiredState).To(Equal(db.SyncOperation_DesiredState_Terminated))
+
+			By("processing the unchanged GitOpsDeploymentSyncRun again, and verifying no error is returned")
+			_, err = a.applicationEventRunner_handleSyncRunModified(ctx, dbQueries)
+			Expect(err).To(BeNil())
+		})
+
 		It("Ensure the sync run handler fails when an invalid new sync run resource is passed.", func() {
 			ctx := context.Background()
 
@@ -676,6 +865,146 @@ var _ = Describe("ApplicationEventLoop Test", func() {
 			Expect(err).NotTo(BeNil())
 
 		})
+
+		It("Ensure the sync run handler rolls back to the revision of the given history entry", func() {
+			ctx := context.Background()
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+
+			gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-gitops-depl",
+					Namespace: workspace.Name,
+					UID:       uuid.NewUUID(),
+				},
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSpec{
+					Type: managedgitopsv1alpha1.GitOpsDeploymentSpecType_Manual,
+				},
+			}
+
+			rollbackToHistoryID := int64(1)
+			gitopsDeplSyncRun := &managedgitopsv1alpha1.GitOpsDeploymentSyncRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-gitops-depl-rollback",
+					Namespace: workspace.Name,
+					UID:       uuid.NewUUID(),
+				},
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSyncRunSpec{
+					GitopsDeploymentName: gitopsDepl.Name,
+					RollbackToHistoryID:  &rollbackToHistoryID,
+				},
+			}
+
+			k8sClientOuter := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gitopsDepl, gitopsDeplSyncRun, workspace, argocdNamespace, kubesystemNamespace).Build()
+			k8sClient := &sharedutil.ProxyClient{
+				InnerClient: k8sClientOuter,
+			}
+
+			dbQueries, err := db.NewUnsafePostgresDBQueries(true, false)
+			Expect(err).To(BeNil())
+
+			sharedResourceLoop := shared_resource_loop.NewSharedResourceLoop()
+
+			By("sending a deployment modified event, to ensure the deployment is added to the database")
+			a := applicationEventLoopRunner_Action{
+				getK8sClientForGitOpsEngineInstance: func(gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error) {
+					return k8sClient, nil
+				},
+				eventResourceName:           gitopsDepl.Name,
+				eventResourceNamespace:      gitopsDepl.Namespace,
+				workspaceClient:             k8sClient,
+				log:                         log.FromContext(context.Background()),
+				sharedResourceEventLoop:     sharedResourceLoop,
+				workspaceID:                 string(workspace.UID),
+				testOnlySkipCreateOperation: true,
+			}
+			_, _, _, _, err = a.applicationEventRunner_handleDeploymentModified(ctx, dbQueries)
+			Expect(err).To(BeNil())
+
+			By("recording a sync history for the Application, as the cluster-agent would")
+			deplToAppMapping := &db.DeploymentToApplicationMapping{Deploymenttoapplicationmapping_uid_id: string(gitopsDepl.UID)}
+			err = dbQueries.GetDeploymentToApplicationMappingByDeplId(ctx, deplToAppMapping)
+			Expect(err).To(BeNil())
+
+			history, err := sigsyaml.Marshal([]managedgitopsv1alpha1.RevisionHistory{
+				{ID: 0, Revision: "abc123"},
+				{ID: 1, Revision: "def456"},
+			})
+			Expect(err).To(BeNil())
+			var buffer bytes.Buffer
+			gzipWriter := gzip.NewWriter(&buffer)
+			_, err = gzipWriter.Write(history)
+			Expect(err).To(BeNil())
+			Expect(gzipWriter.Close()).To(Succeed())
+
+			err = dbQueries.CreateApplicationState(ctx, &db.ApplicationState{
+				Applicationstate_application_id: deplToAppMapping.Application_id,
+				Health:                          "Healthy",
+				Sync_Status:                     "Synced",
+				History:                         buffer.Bytes(),
+			})
+			Expect(err).To(BeNil())
+
+			By("sending a sync run modified event for the rollback")
+			a = applicationEventLoopRunner_Action{
+				getK8sClientForGitOpsEngineInstance: func(gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error) {
+					return k8sClient, nil
+				},
+				eventResourceName:       gitopsDeplSyncRun.Name,
+				eventResourceNamespace:  gitopsDeplSyncRun.Namespace,
+				workspaceClient:         k8sClient,
+				log:                     log.FromContext(context.Background()),
+				sharedResourceEventLoop: sharedResourceLoop,
+				workspaceID:             a.workspaceID,
+			}
+			_, err = a.applicationEventRunner_handleSyncRunModified(ctx, dbQueries)
+			Expect(err).To(BeNil())
+
+			By("verifying that the SyncOperation targets the revision of the history entry")
+			mapping := db.APICRToDatabaseMapping{
+				APIResourceType: db.APICRToDatabaseMapping_ResourceType_GitOpsDeploymentSyncRun,
+				APIResourceUID:  string(gitopsDeplSyncRun.UID),
+				DBRelationType:  db.APICRToDatabaseMapping_DBRelationType_SyncOperation,
+			}
+			err = dbQueries.GetDatabaseMappingForAPICR(ctx, &mapping)
+			Expect(err).To(BeNil())
+
+			syncOperation := db.SyncOperation{SyncOperation_id: mapping.DBRelationKey}
+			err = dbQueries.GetSyncOperationById(ctx, &syncOperation)
+			Expect(err).To(BeNil())
+			Expect(syncOperation.Revision).To(Equal("def456"))
+			Expect(syncOperation.Rollback_history_id).To(Equal(&rollbackToHistoryID))
+		})
+
+		It("Ensure that a rollback is rejected if the GitOpsDeployment is automated, or a revision is also specified", func() {
+			rollbackToHistoryID := int64(1)
+
+			syncRun := managedgitopsv1alpha1.GitOpsDeploymentSyncRun{
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSyncRunSpec{
+					GitopsDeploymentName: "my-gitops-depl",
+					RollbackToHistoryID:  &rollbackToHistoryID,
+				},
+			}
+
+			gitopsDepl := managedgitopsv1alpha1.GitOpsDeployment{
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSpec{
+					Type: managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated,
+				},
+			}
+
+			// The database is not queried, as the SyncRun is rejected before then
+			_, err := getRollbackRevision(context.Background(), syncRun, gitopsDepl, db.Application{}, nil)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("rollback is only supported"))
+
+			gitopsDepl.Spec.Type = managedgitopsv1alpha1.GitOpsDeploymentSpecType_Manual
+			syncRun.Spec.RevisionID = "HEAD"
+
+			_, err = getRollbackRevision(context.Background(), syncRun, gitopsDepl, db.Application{}, nil)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("revisionID must not be specified"))
+		})
 	})
 
 	Context("Handle deployment Health/Sync status.", func() {
@@ -1176,6 +1505,93 @@ var _ = Describe("ApplicationEventLoop Test", func() {
 			Expect(resourcesOut).To(BeEmpty())
 		})
 	})
+
+	Context("Check decompressOperationState and decompressHistory functions.", func() {
+
+		compress := func(obj interface{}) []byte {
+			objStr, err := sigsyaml.Marshal(obj)
+			Expect(err).To(BeNil())
+
+			var buffer bytes.Buffer
+			gzipWriter, err := gzip.NewWriterLevel(&buffer, gzip.BestSpeed)
+			Expect(err).To(BeNil())
+
+			_, err = gzipWriter.Write(objStr)
+			Expect(err).To(BeNil())
+
+			err = gzipWriter.Close()
+			Expect(err).To(BeNil())
+
+			return buffer.Bytes()
+		}
+
+		startedAt := metav1.NewTime(time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC))
+		finishedAt := metav1.NewTime(time.Date(2022, 6, 1, 10, 1, 0, 0, time.UTC))
+
+		It("Should return nil if no operation state or history has been recorded.", func() {
+			operationState, err := decompressOperationState(nil)
+			Expect(err).To(BeNil())
+			Expect(operationState).To(BeNil())
+
+			history, err := decompressHistory(nil)
+			Expect(err).To(BeNil())
+			Expect(history).To(BeNil())
+		})
+
+		It("Should decompress operation state data and return the OperationState.", func() {
+			operationStateIn := managedgitopsv1alpha1.OperationState{
+				Phase:   managedgitopsv1alpha1.OperationPhaseFailed,
+				Message: "one or more synchronization tasks completed unsuccessfully",
+				SyncResult: &managedgitopsv1alpha1.SyncOperationResult{
+					Revision: "abc123",
+					Resources: []managedgitopsv1alpha1.ResourceResult{
+						{
+							Group: "batch", Version: "v1", Kind: "Job", Namespace: "jane", Name: "db-migration",
+							Message: "Job has reached the specified backoff limit", HookType: "PreSync",
+							HookPhase: managedgitopsv1alpha1.OperationPhaseFailed, SyncPhase: "PreSync",
+						},
+					},
+				},
+				StartedAt:  startedAt,
+				FinishedAt: &finishedAt,
+			}
+
+			operationStateOut, err := decompressOperationState(compress(operationStateIn))
+			Expect(err).To(BeNil())
+			Expect(operationStateOut).NotTo(BeNil())
+
+			Expect(operationStateOut.Phase).To(Equal(operationStateIn.Phase))
+			Expect(operationStateOut.Message).To(Equal(operationStateIn.Message))
+			Expect(operationStateOut.SyncResult).To(Equal(operationStateIn.SyncResult))
+			Expect(operationStateOut.StartedAt.Equal(&startedAt)).To(BeTrue())
+			Expect(operationStateOut.FinishedAt.Equal(&finishedAt)).To(BeTrue())
+		})
+
+		It("Should decompress history data and return the Array of RevisionHistory objects.", func() {
+			historyIn := []managedgitopsv1alpha1.RevisionHistory{
+				{
+					ID:       1,
+					Revision: "abc123",
+					Source: managedgitopsv1alpha1.ApplicationSource{
+						RepoURL: "https://github.com/redhat-appstudio/managed-gitops",
+						Path:    "resources/test-data/sample-gitops-repository/environments/overlays/dev",
+					},
+					DeployStartedAt: &startedAt,
+					DeployedAt:      finishedAt,
+				},
+			}
+
+			historyOut, err := decompressHistory(compress(historyIn))
+			Expect(err).To(BeNil())
+
+			Expect(historyOut).To(HaveLen(1))
+			Expect(historyOut[0].ID).To(Equal(historyIn[0].ID))
+			Expect(historyOut[0].Revision).To(Equal(historyIn[0].Revision))
+			Expect(historyOut[0].Source).To(Equal(historyIn[0].Source))
+			Expect(historyOut[0].DeployStartedAt.Equal(&startedAt)).To(BeTrue())
+			Expect(historyOut[0].DeployedAt.Equal(&finishedAt)).To(BeTrue())
+		})
+	})
 })
 
 var _ = Describe("GitOpsDeployment Conditions", func() {
@@ -1765,6 +2181,10 @@ func (f MockSRLK8sClientFactory) BuildK8sClient(restConfig *rest.Config) (client
 	return f.fakeClient, nil
 }
 
+func (f MockSRLK8sClientFactory) BuildK8sClientset(restConfig *rest.Config) (kubernetes.Interface, error) {
+	return nil, fmt.Errorf("a clientset is not supported by this mock")
+}
+
 func (f MockSRLK8sClientFactory) GetK8sClientForGitOpsEngineInstance(gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error) {
 	return f.fakeClient, nil
 }
@@ -2004,3 +2424,85 @@ users:
       token: sha256~abcDef1gHIjkLmNOp-q19QRtUV1_w9x2yzabcdEFgh4
 `
 }
+
+var _ = Describe("GitOpsDeploymentSyncRun status and sync options tests", func() {
+
+	Context("Test setSyncRunStatusFromOperationState", func() {
+
+		It("should leave the status unchanged if the sync operation has not started", func() {
+			status := managedgitopsv1alpha1.GitOpsDeploymentSyncRunStatus{}
+			setSyncRunStatusFromOperationState(&status, nil)
+			Expect(status).To(Equal(managedgitopsv1alpha1.GitOpsDeploymentSyncRunStatus{}))
+		})
+
+		It("should report a running sync operation", func() {
+			startedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+
+			status := managedgitopsv1alpha1.GitOpsDeploymentSyncRunStatus{}
+			setSyncRunStatusFromOperationState(&status, &managedgitopsv1alpha1.OperationState{
+				Phase:     managedgitopsv1alpha1.OperationPhaseRunning,
+				StartedAt: startedAt,
+			})
+
+			Expect(status.Phase).To(Equal(managedgitopsv1alpha1.OperationPhaseRunning))
+			Expect(status.StartedAt).ToNot(BeNil())
+			Expect(status.StartedAt.Equal(&startedAt)).To(BeTrue())
+			Expect(status.FinishedAt).To(BeNil())
+			Expect(status.Revision).To(BeEmpty())
+			Expect(status.Resources).To(BeEmpty())
+		})
+
+		It("should report the result of a completed sync operation", func() {
+			startedAt := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+			finishedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+
+			resources := []managedgitopsv1alpha1.ResourceResult{{
+				Group:     "apps",
+				Version:   "v1",
+				Kind:      "Deployment",
+				Namespace: "my-namespace",
+				Name:      "my-deployment",
+				Status:    "Synced",
+				Message:   "deployment.apps/my-deployment created",
+			}}
+
+			status := managedgitopsv1alpha1.GitOpsDeploymentSyncRunStatus{}
+			setSyncRunStatusFromOperationState(&status, &managedgitopsv1alpha1.OperationState{
+				Phase:      managedgitopsv1alpha1.OperationPhaseSucceeded,
+				Message:    "successfully synced (all tasks run)",
+				StartedAt:  startedAt,
+				FinishedAt: &finishedAt,
+				SyncResult: &managedgitopsv1alpha1.SyncOperationResult{
+					Revision:  "abc123",
+					Resources: resources,
+				},
+			})
+
+			Expect(status.Phase).To(Equal(managedgitopsv1alpha1.OperationPhaseSucceeded))
+			Expect(status.Message).To(Equal("successfully synced (all tasks run)"))
+			Expect(status.StartedAt.Equal(&startedAt)).To(BeTrue())
+			Expect(status.FinishedAt.Equal(&finishedAt)).To(BeTrue())
+			Expect(status.Revision).To(Equal("abc123"))
+			Expect(status.Resources).To(Equal(resources))
+		})
+	})
+
+	Context("Test syncRunResourcesToJSON", func() {
+
+		It("should return an empty string if no resources are specified", func() {
+			resourcesJSON, err := syncRunResourcesToJSON(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resourcesJSON).To(BeEmpty())
+		})
+
+		It("should use the same field names as Argo CD, for the specified resources", func() {
+			resourcesJSON, err := syncRunResourcesToJSON([]managedgitopsv1alpha1.SyncRunResource{
+				{Group: "apps", Kind: "Deployment", Name: "my-deployment", Namespace: "my-namespace"},
+				{Kind: "ConfigMap", Name: "my-configmap"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resourcesJSON).To(Equal(`[{"group":"apps","kind":"Deployment","name":"my-deployment","namespace":"my-namespace"},` +
+				`{"kind":"ConfigMap","name":"my-configmap"}]`))
+		})
+	})
+})
@@ -253,11 +253,14 @@ func internalSharedResourceEventLoop(inputChan chan sharedResourceLoopMessage) {
 		return
 	}
 
+	// Tasks that would otherwise block the event loop (for example, waiting for Operations to complete) are run here
+	taskRetryLoop := sharedutil.NewTaskRetryLoop("shared-resource-loop-retry-loop")
+
 	for {
 		msg := <-inputChan
 
 		_, err = sharedutil.CatchPanic(func() error {
-			processSharedResourceMessage(ctx, msg, dbQueries, log)
+			processSharedResourceMessage(ctx, msg, dbQueries, taskRetryLoop, log)
 			return nil
 		})
 		if err != nil {
@@ -266,7 +269,8 @@ func internalSharedResourceEventLoop(inputChan chan sharedResourceLoopMessage) {
 	}
 }
 
-func processSharedResourceMessage(ctx context.Context, msg sharedResourceLoopMessage, dbQueries db.DatabaseQueries, log logr.Logger) {
+func processSharedResourceMessage(ctx context.Context, msg sharedResourceLoopMessage, dbQueries db.DatabaseQueries,
+	taskRetryLoop *sharedutil.TaskRetryLoop, log logr.Logger) {
 
 	log.V(sharedutil.LogLevel_Debug).Info("sharedResourceEventLoop received message: "+string(msg.messageType),
 		"workspace", msg.workspaceNamespace.UID)
@@ -289,7 +293,19 @@ func processSharedResourceMessage(ctx context.Context, msg sharedResourceLoopMes
 
 		res, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, msg.workspaceClient, payload.managedEnvironmentCRName,
 			payload.managedEnvironmentCRNamespace, payload.isWorkspaceTarget, msg.workspaceNamespace,
-			payload.k8sClientFactory, dbQueries, log)
+			payload.k8sClientFactory, taskRetryLoop, dbQueries, log)
+
+		// Report the result of connecting to the managed environment on the GitOpsDeploymentManagedEnvironment
+		if !payload.isWorkspaceTarget {
+			updateManagedEnvironmentConnectionStatus(ctx, msg.workspaceClient, payload.managedEnvironmentCRName,
+				payload.managedEnvironmentCRNamespace, err, log)
+
+			// Ensure that we are able to clean up the ServiceAccount we installed, when the managed environment is deleted
+			if err == nil && res.ManagedEnv != nil {
+				addManagedEnvironmentFinalizer(ctx, msg.workspaceClient, payload.managedEnvironmentCRName,
+					payload.managedEnvironmentCRNamespace, *res.ManagedEnv, dbQueries, log)
+			}
+		}
 
 		response := sharedResourceLoopMessage_getOrCreateSharedResourcesResponse{
 			err:               err,
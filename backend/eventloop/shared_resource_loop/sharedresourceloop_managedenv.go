@@ -2,8 +2,11 @@ package shared_resource_loop
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 
@@ -14,13 +17,17 @@ import (
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/operations"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/eventlooptypes"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 func internalProcessMessage_ReconcileSharedManagedEnv(ctx context.Context, workspaceClient client.Client,
@@ -28,6 +35,7 @@ func internalProcessMessage_ReconcileSharedManagedEnv(ctx context.Context, works
 	managedEnvironmentCRNamespace string, isWorkspaceTarget bool,
 	workspaceNamespace corev1.Namespace,
 	k8sClientFactory SRLK8sClientFactory,
+	taskRetryLoop *sharedutil.TaskRetryLoop,
 	dbQueries db.DatabaseQueries,
 	log logr.Logger) (SharedResourceManagedEnvContainer, error) {
 
@@ -39,8 +47,8 @@ func internalProcessMessage_ReconcileSharedManagedEnv(ctx context.Context, works
 
 	clusterUser, isNewUser, err := internalGetOrCreateClusterUserByNamespaceUID(ctx, string(workspaceNamespace.UID), dbQueries, log)
 	if err != nil || clusterUser == nil {
-		return newSharedResourceManagedEnvContainer(),
-			fmt.Errorf("unable to retrieve cluster user in processMessage, '%s': %v", string(workspaceNamespace.UID), err)
+		return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to retrieve cluster user in processMessage, '%s': %v", string(workspaceNamespace.UID), err))
 	}
 
 	// Attempt to retrieve the CRs; if they don't exist, then delete the corresponding Managed Environment DB entry
@@ -60,8 +68,9 @@ func internalProcessMessage_ReconcileSharedManagedEnv(ctx context.Context, works
 	// of managedEnvironmentNew
 	if err := deleteManagedEnvironmentByAPINameAndNamespace(ctx, workspaceClient, managedEnvironmentCRName, managedEnvironmentCRNamespace,
 		string(managedEnvironmentCR.UID), workspaceNamespace, k8sClientFactory, dbQueries, *clusterUser, log); err != nil {
-		return SharedResourceManagedEnvContainer{}, fmt.Errorf("unable to delete old managed environments by API name and namespace '%s' in '%s': %v",
-			managedEnvironmentCRName, managedEnvironmentCRNamespace, err)
+		return SharedResourceManagedEnvContainer{}, newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to delete old managed environments by API name and namespace '%s' in '%s': %v",
+				managedEnvironmentCRName, managedEnvironmentCRNamespace, err))
 	}
 
 	apiCRToDBMapping := db.APICRToDatabaseMapping{
@@ -73,8 +82,8 @@ func internalProcessMessage_ReconcileSharedManagedEnv(ctx context.Context, works
 	if err := dbQueries.GetDatabaseMappingForAPICR(ctx, &apiCRToDBMapping); err != nil {
 
 		if !db.IsResultNotFoundError(err) {
-			return newSharedResourceManagedEnvContainer(),
-				fmt.Errorf("unable to retrieve managed environment APICRToDatabaseMapping for %s: %v", apiCRToDBMapping.APIResourceUID, err)
+			return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+				fmt.Errorf("unable to retrieve managed environment APICRToDatabaseMapping for %s: %v", apiCRToDBMapping.APIResourceUID, err))
 		}
 
 		// A) If there exists no APICRToDatabaseMapping for this Managed Environment resource, then just create a new managed environment
@@ -88,16 +97,16 @@ func internalProcessMessage_ReconcileSharedManagedEnv(ctx context.Context, works
 	if err := dbQueries.GetManagedEnvironmentById(ctx, managedEnv); err != nil {
 
 		if !db.IsResultNotFoundError(err) {
-			return newSharedResourceManagedEnvContainer(),
-				fmt.Errorf("unable to retrieve managed environment '%s", managedEnv.Managedenvironment_id)
+			return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+				fmt.Errorf("unable to retrieve managed environment '%s", managedEnv.Managedenvironment_id))
 		}
 
 		// B) The APICRToDBMapping exists, but the managed env doesn't, so delete the mapping, then create the
 		//    managed environment/mapping from scratch.
 		rowsDeleted, err := dbQueries.DeleteAPICRToDatabaseMapping(ctx, &apiCRToDBMapping)
 		if err != nil {
-			return newSharedResourceManagedEnvContainer(),
-				fmt.Errorf("unable to delete APICRToDatabaseMapping for '%s'", apiCRToDBMapping.APIResourceUID)
+			return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+				fmt.Errorf("unable to delete APICRToDatabaseMapping for '%s'", apiCRToDBMapping.APIResourceUID))
 		}
 		if rowsDeleted != 1 {
 			// Warn, but continue.
@@ -113,23 +122,30 @@ func internalProcessMessage_ReconcileSharedManagedEnv(ctx context.Context, works
 	if err := dbQueries.GetClusterCredentialsById(ctx, clusterCreds); err != nil {
 
 		if !db.IsResultNotFoundError(err) {
-			return newSharedResourceManagedEnvContainer(),
-				fmt.Errorf("unable to retrieve cluster credentials for '%s': %v", clusterCreds.Clustercredentials_cred_id, err)
+			return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+				fmt.Errorf("unable to retrieve cluster credentials for '%s': %v", clusterCreds.Clustercredentials_cred_id, err))
 		}
 
 		// Sanity test:
 		// Cluster credentials referenced by managed environment doesn't exist.
 		// However, this really shouldn't be possible, since there is a foreign key from managed environment to cluster credentials.
-		return newSharedResourceManagedEnvContainer(),
-			fmt.Errorf("SEVERE: managed environment referenced cluster credentials value which doens't exist: %v", err)
+		return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("SEVERE: managed environment referenced cluster credentials value which doens't exist: %v", err))
 
 	}
 
-	// We found the managed env, now verify that the API url of the k8s resources matches what is in the cluster credential
-	if clusterCreds.Host != managedEnvironmentCR.Spec.APIURL {
-		// C) If the API URL defined in the managed env CR has changed, then replace the cluster credentials of the managed environment
+	secretCredentials, err := getManagedEnvironmentSecretCredentials(managedEnvironmentCR, secretCR)
+	if err != nil {
+		return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonInvalidSecret, err)
+	}
+
+	// We found the managed env, now verify that the API url, TLS settings, namespaces and credentials of the k8s
+	// resources match what is in the cluster credential
+	if !clusterCredentialsMatchManagedEnvironment(*clusterCreds, managedEnvironmentCR, secretCredentials) {
+		// C) If the API URL, TLS settings, namespaces or credentials defined in the managed env CR (or its Secret) have
+		// changed, then replace the cluster credentials of the managed environment
 		return replaceExistingManagedEnv(ctx, workspaceClient, *clusterUser, isNewUser, managedEnvironmentCR, secretCR, *managedEnv,
-			workspaceNamespace, k8sClientFactory, dbQueries, log)
+			*clusterCreds, workspaceNamespace, k8sClientFactory, dbQueries, log)
 	}
 
 	// Verify that we are able to connect to the cluster using the service account token we stored
@@ -139,19 +155,29 @@ func internalProcessMessage_ReconcileSharedManagedEnv(ctx context.Context, works
 		// D) If the cluster credentials appear to no longer be valid (we're no longer able to connect), then reacquire using the
 		// Secret.
 		return replaceExistingManagedEnv(ctx, workspaceClient, *clusterUser, isNewUser, managedEnvironmentCR, secretCR, *managedEnv,
-			workspaceNamespace, k8sClientFactory, dbQueries, log)
+			*clusterCreds, workspaceNamespace, k8sClientFactory, dbQueries, log)
+	}
+
+	// The API url hasn't changed, the existing service account still works, so no more work needed, except to
+	// rotate the token of the service account, if it is due to be rotated.
+	if isServiceAccountTokenRotationRequired(*clusterCreds) {
+		if err := rotateServiceAccountToken(ctx, managedEnvironmentCR, secretCredentials, managedEnv, *clusterCreds,
+			k8sClientFactory, taskRetryLoop, dbQueries, log); err != nil {
+			// Whichever token the managed environment now points to is still valid, so the error is only logged: if the
+			// token was not replaced, the rotation will be attempted again the next time the managed environment is
+			// reconciled, otherwise the revocation of the old tokens will be attempted again on the next rotation.
+			log.Error(err, "unable to rotate service account token of managed environment", "managedEnv", managedEnv.Managedenvironment_id)
+		}
 	}
 
-	// The API url hasn't changed, the existing service account still works, so no more work needed.
-
 	// E) We already have an existing managed env from the database, so get or create the remaining items for it
 
 	engineInstance, isNewEngineInstance, clusterAccess, isNewClusterAccess, engineCluster, err := wrapManagedEnv(ctx,
 		*managedEnv, workspaceNamespace, *clusterUser, workspaceClient, dbQueries, log)
 
 	if err != nil {
-		return newSharedResourceManagedEnvContainer(),
-			fmt.Errorf("unable to wrap managed environment, on existing managed env, for %s: %v", apiCRToDBMapping.APIResourceUID, err)
+		return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to wrap managed environment, on existing managed env, for %s: %v", apiCRToDBMapping.APIResourceUID, err))
 	}
 
 	res := SharedResourceManagedEnvContainer{
@@ -169,6 +195,84 @@ func internalProcessMessage_ReconcileSharedManagedEnv(ctx context.Context, works
 	return res, nil
 }
 
+// updateManagedEnvironmentConnectionStatus updates the ConnectionInitializationSucceeded condition of the
+// GitOpsDeploymentManagedEnvironment, based on the result of reconciling it ('reconcileErr').
+// If the GitOpsDeploymentManagedEnvironment no longer exists, or is being deleted, no action is taken.
+func updateManagedEnvironmentConnectionStatus(ctx context.Context, workspaceClient client.Client, managedEnvironmentCRName string,
+	managedEnvironmentCRNamespace string, reconcileErr error, log logr.Logger) {
+
+	managedEnvironmentCR := managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      managedEnvironmentCRName,
+			Namespace: managedEnvironmentCRNamespace,
+		},
+	}
+	if err := workspaceClient.Get(ctx, client.ObjectKeyFromObject(&managedEnvironmentCR), &managedEnvironmentCR); err != nil {
+		if !apierr.IsNotFound(err) {
+			log.Error(err, "unable to retrieve managed environment, in order to update its status", "managedEnv", managedEnvironmentCRName)
+		}
+		return
+	}
+
+	if managedEnvironmentCR.DeletionTimestamp != nil {
+		// The managed environment is being deleted: any failure to clean it up is instead reported by the
+		// ServiceAccountCleanupSucceeded condition.
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:    managedgitopsv1alpha1.ManagedEnvironmentConditionConnectionInitializationSucceeded,
+		Status:  metav1.ConditionTrue,
+		Reason:  managedgitopsv1alpha1.ManagedEnvironmentReasonSucceeded,
+		Message: "Successfully connected to the managed environment using the provided credentials",
+	}
+	if reconcileErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = managedgitopsv1alpha1.ManagedEnvironmentReasonUnableToInitializeConnection
+		condition.Message = reconcileErr.Error()
+
+		var connectionErr managedEnvironmentConnectionError
+		if errors.As(reconcileErr, &connectionErr) {
+			condition.Reason = connectionErr.reason
+		}
+	}
+
+	existingCondition := meta.FindStatusCondition(managedEnvironmentCR.Status.Conditions, condition.Type)
+	if existingCondition != nil && existingCondition.Status == condition.Status &&
+		existingCondition.Reason == condition.Reason && existingCondition.Message == condition.Message {
+		// The condition is unchanged, so no update is required
+		return
+	}
+
+	meta.SetStatusCondition(&managedEnvironmentCR.Status.Conditions, condition)
+
+	if err := workspaceClient.Status().Update(ctx, &managedEnvironmentCR); err != nil {
+		log.Error(err, "unable to update status of managed environment", "managedEnv", managedEnvironmentCRName)
+		return
+	}
+	sharedutil.LogAPIResourceChangeEvent(managedEnvironmentCR.Namespace, managedEnvironmentCR.Name, managedEnvironmentCR, sharedutil.ResourceModified, log)
+}
+
+// managedEnvironmentConnectionError is an error that occurred while reconciling a managed environment, along with the
+// reason that is reported by the ConnectionInitializationSucceeded condition of the GitOpsDeploymentManagedEnvironment.
+// Errors that are not a managedEnvironmentConnectionError are reported as UnableToInitializeConnection.
+type managedEnvironmentConnectionError struct {
+	reason string
+	err    error
+}
+
+func newManagedEnvironmentConnectionError(reason string, err error) error {
+	return managedEnvironmentConnectionError{reason: reason, err: err}
+}
+
+func (e managedEnvironmentConnectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e managedEnvironmentConnectionError) Unwrap() error {
+	return e.err
+}
+
 // getManagedEnvironmentCRs retrieves the Managed Environment and Secret CRs.
 // returns:
 // - managed environment and secret CRs, if they exist
@@ -209,10 +313,28 @@ func getManagedEnvironmentCRs(ctx context.Context,
 			fmt.Errorf("managed environment '%s' in '%s', could not be retrieved: %v", managedEnvironmentCR.Name, managedEnvironmentCR.Namespace, err)
 	}
 
+	// If the managed environment CR is being deleted, clean up the service account that we installed on the managed
+	// environment, then remove our finalizer, and then delete the corresponding Managed Environment DB entry.
+	if managedEnvironmentCR.DeletionTimestamp != nil &&
+		controllerutil.ContainsFinalizer(&managedEnvironmentCR, managedgitopsv1alpha1.ManagedEnvironmentServiceAccountCleanupFinalizer) {
+
+		log.Info("Managed environment is being deleted, so cleaning up its service account before cleaning the database entry.")
+
+		if err := cleanUpManagedEnvironmentServiceAccount(ctx, managedEnvironmentCR, workspaceClient, k8sClientFactory, dbQueries, log); err != nil {
+			return managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{}, corev1.Secret{}, resourceExists, err
+		}
+
+		err := deleteManagedEnvironmentByAPINameAndNamespace(ctx, workspaceClient, managedEnvironmentCRName,
+			managedEnvironmentCRNamespace, "", workspaceNamespace, k8sClientFactory, dbQueries, clusterUser, log)
+
+		return managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{}, corev1.Secret{}, resourceDoesNotExist, err
+	}
+
 	if managedEnvironmentCR.Spec.ClusterCredentialsSecret == "" {
 		return managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{}, corev1.Secret{}, resourceExists,
-			fmt.Errorf("secret '%s' referenced by managed environment '%s' in '%s', is invalid",
-				managedEnvironmentCR.Spec.ClusterCredentialsSecret, managedEnvironmentCR.Name, managedEnvironmentCR.Namespace)
+			newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonUnableToRetrieveSecret,
+				fmt.Errorf("secret '%s' referenced by managed environment '%s' in '%s', is invalid",
+					managedEnvironmentCR.Spec.ClusterCredentialsSecret, managedEnvironmentCR.Name, managedEnvironmentCR.Namespace))
 	}
 
 	// Retrieve the Secret CR from the workspace
@@ -224,8 +346,9 @@ func getManagedEnvironmentCRs(ctx context.Context,
 	}
 	if err := workspaceClient.Get(ctx, client.ObjectKeyFromObject(&secretCR), &secretCR); err != nil {
 		return managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{}, corev1.Secret{}, resourceExists,
-			fmt.Errorf("secret '%s' referenced by managed environment '%s' in '%s', could not be retrieved: %v",
-				managedEnvironmentCR.Spec.ClusterCredentialsSecret, managedEnvironmentCR.Name, managedEnvironmentCR.Namespace, err)
+			newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonUnableToRetrieveSecret,
+				fmt.Errorf("secret '%s' referenced by managed environment '%s' in '%s', could not be retrieved: %v",
+					managedEnvironmentCR.Spec.ClusterCredentialsSecret, managedEnvironmentCR.Name, managedEnvironmentCR.Namespace, err))
 	}
 
 	return managedEnvironmentCR, secretCR, resourceExists, nil
@@ -296,13 +419,15 @@ func deleteManagedEnvironmentByAPINameAndNamespace(ctx context.Context, workspac
 }
 
 // replaceExistingManagedEnv updates an existing managed environment by creating new credentials, updating the
-// managed environment to point to them, then deleting the old credentials.
+// managed environment to point to them, then deleting the old credentials (the current credentials of the managed
+// environment).
 func replaceExistingManagedEnv(ctx context.Context,
 	workspaceClient client.Client,
 	clusterUser db.ClusterUser, isNewUser bool,
 	managedEnvironmentCR managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment,
 	secret corev1.Secret,
 	managedEnvironmentDB db.ManagedEnvironment,
+	oldClusterCredentials db.ClusterCredentials,
 	workspaceNamespace corev1.Namespace,
 	k8sClientFactory SRLK8sClientFactory,
 	dbQueries db.DatabaseQueries,
@@ -313,20 +438,37 @@ func replaceExistingManagedEnv(ctx context.Context,
 	// 1) Create new cluster creds, based on secret
 	clusterCredentials, err := createNewClusterCredentials(ctx, managedEnvironmentCR, secret, k8sClientFactory, dbQueries, log)
 	if err != nil {
-		return SharedResourceManagedEnvContainer{}, fmt.Errorf("unable to create new cluster credentials for managed env, while replacing existing managed env: %v", err)
+		return SharedResourceManagedEnvContainer{}, fmt.Errorf("unable to create new cluster credentials for managed env, while replacing existing managed env: %w", err)
+	}
+
+	// 1b) Revoke the access of the service account to the namespaces that are no longer part of the managed environment.
+	// This is done before the managed environment points to the new credentials, so that it is retried on failure.
+	if err := deleteServiceAccountRolesOfRemovedNamespaces(ctx, managedEnvironmentCR, secret, oldClusterCredentials, clusterCredentials,
+		k8sClientFactory, log); err != nil {
+
+		// The managed environment still points to the old credentials, so delete the new credentials (they will be
+		// recreated on retry), rather than leaving them orphaned.
+		if _, deleteErr := dbQueries.DeleteClusterCredentialsById(ctx, clusterCredentials.Clustercredentials_cred_id); deleteErr != nil {
+			log.Error(deleteErr, "unable to delete new cluster credentials, after failing to revoke access to removed namespaces",
+				"clusterCredentialsID", clusterCredentials.Clustercredentials_cred_id)
+		}
+
+		return SharedResourceManagedEnvContainer{}, err
 	}
 
 	// 2) Update the existing managed environment to point to the new credentials
 	managedEnvironmentDB.Clustercredentials_id = clusterCredentials.Clustercredentials_cred_id
 
 	if err := dbQueries.UpdateManagedEnvironment(ctx, &managedEnvironmentDB); err != nil {
-		return SharedResourceManagedEnvContainer{}, fmt.Errorf("unable to update managed environment with new credentials: %v", err)
+		return SharedResourceManagedEnvContainer{}, newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to update managed environment with new credentials: %v", err))
 	}
 
 	// 3) Delete the old credentials
 	rowsDeleted, err := dbQueries.DeleteClusterCredentialsById(ctx, oldClusterCredentialsPrimaryKey)
 	if err != nil {
-		return SharedResourceManagedEnvContainer{}, fmt.Errorf("unable to delete old cluster credentials '%s': %v", oldClusterCredentialsPrimaryKey, err)
+		return SharedResourceManagedEnvContainer{}, newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to delete old cluster credentials '%s': %v", oldClusterCredentialsPrimaryKey, err))
 	}
 	if rowsDeleted != 1 {
 		log.V(sharedutil.LogLevel_Warn).Info("unexpected number of rows deleted when deleting cluster credentials", "clusterCredentialsID", oldClusterCredentialsPrimaryKey)
@@ -339,8 +481,8 @@ func replaceExistingManagedEnv(ctx context.Context,
 		managedEnvironmentDB, workspaceNamespace, clusterUser, workspaceClient, dbQueries, log)
 
 	if err != nil {
-		return newSharedResourceManagedEnvContainer(),
-			fmt.Errorf("unable to wrap managed environment for %s: %v", managedEnvironmentCR.UID, err)
+		return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to wrap managed environment for %s: %v", managedEnvironmentCR.UID, err))
 	}
 
 	res := SharedResourceManagedEnvContainer{
@@ -358,6 +500,63 @@ func replaceExistingManagedEnv(ctx context.Context,
 	return res, nil
 }
 
+// deleteServiceAccountRolesOfRemovedNamespaces deletes the Role/RoleBinding of the service account of the managed
+// environment, in each namespace of the old cluster credentials that is not a namespace of the new cluster credentials.
+//
+// This is only needed when a service account was installed for both the old and new credentials: InstallServiceAccount
+// only creates/updates the Role/RoleBinding of the namespaces it is given.
+func deleteServiceAccountRolesOfRemovedNamespaces(ctx context.Context,
+	managedEnvironmentCR managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment, secret corev1.Secret,
+	oldClusterCredentials db.ClusterCredentials, newClusterCredentials db.ClusterCredentials,
+	k8sClientFactory SRLK8sClientFactory, log logr.Logger) error {
+
+	if oldClusterCredentials.Serviceaccount_ns == "" || newClusterCredentials.Serviceaccount_ns == "" || oldClusterCredentials.Namespaces == "" {
+		return nil
+	}
+
+	newNamespaces := map[string]bool{}
+	if newClusterCredentials.Namespaces != "" {
+		for _, namespace := range strings.Split(newClusterCredentials.Namespaces, ",") {
+			newNamespaces[namespace] = true
+		}
+	}
+
+	removedNamespaces := []string{}
+	for _, namespace := range strings.Split(oldClusterCredentials.Namespaces, ",") {
+		if !newNamespaces[namespace] {
+			removedNamespaces = append(removedNamespaces, namespace)
+		}
+	}
+
+	if len(removedNamespaces) == 0 {
+		return nil
+	}
+
+	secretCredentials, err := getManagedEnvironmentSecretCredentials(managedEnvironmentCR, secret)
+	if err != nil {
+		return newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonInvalidSecret, err)
+	}
+
+	k8sClient, err := k8sClientFactory.BuildK8sClient(secretCredentials.restConfig)
+	if err != nil {
+		return fmt.Errorf("unable to create k8s client from RESTConfig: %v", err)
+	}
+
+	for _, namespace := range removedNamespaces {
+		if err := sharedutil.DeleteRoleAndRoleBinding(ctx, string(managedEnvironmentCR.UID), k8sClient, namespace, log); err != nil {
+			if apierr.IsForbidden(err) {
+				// The credentials of the Secret no longer have access to the namespace, so we are unable to clean it up.
+				log.Info("unable to delete the role and role binding of a removed namespace, due to insufficient permissions",
+					"namespace", namespace, "error", err.Error())
+				continue
+			}
+			return fmt.Errorf("unable to revoke access to removed namespace '%s': %w", namespace, err)
+		}
+	}
+
+	return nil
+}
+
 // constructNewManagedEnv creates a new ManagedEnvironment using the provided parameters, then creates ClusterAccess/GitOpsEngieInstance,
 // and returns those all in SharedResourceContainer
 func constructNewManagedEnv(ctx context.Context,
@@ -373,7 +572,7 @@ func constructNewManagedEnv(ctx context.Context,
 	managedEnvDB, err := createNewManagedEnv(ctx, managedEnvironment, secret, clusterUser, workspaceNamespace, k8sClientFactory, dbQueries, log)
 	if err != nil {
 		return newSharedResourceManagedEnvContainer(),
-			fmt.Errorf("unable to create managed environment for %s: %v", managedEnvironment.UID, err)
+			fmt.Errorf("unable to create managed environment for %s: %w", managedEnvironment.UID, err)
 	}
 
 	engineInstance, isNewEngineInstance, clusterAccess,
@@ -381,8 +580,8 @@ func constructNewManagedEnv(ctx context.Context,
 		*managedEnvDB, workspaceNamespace, clusterUser, workspaceClient, dbQueries, log)
 
 	if err != nil {
-		return newSharedResourceManagedEnvContainer(),
-			fmt.Errorf("unable to wrap managed environment for %s: %v", managedEnvironment.UID, err)
+		return newSharedResourceManagedEnvContainer(), newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to wrap managed environment for %s: %v", managedEnvironment.UID, err))
 	}
 
 	res := SharedResourceManagedEnvContainer{
@@ -441,7 +640,7 @@ func createNewManagedEnv(ctx context.Context, managedEnvironment managedgitopsv1
 
 	clusterCredentials, err := createNewClusterCredentials(ctx, managedEnvironment, secret, k8sClientFactory, dbQueries, log)
 	if err != nil {
-		return nil, fmt.Errorf("unable to create new cluster credentials for managed env, while creating new managed env: %v", err)
+		return nil, fmt.Errorf("unable to create new cluster credentials for managed env, while creating new managed env: %w", err)
 	}
 
 	managedEnv := &db.ManagedEnvironment{
@@ -450,7 +649,8 @@ func createNewManagedEnv(ctx context.Context, managedEnvironment managedgitopsv1
 	}
 
 	if err := dbQueries.CreateManagedEnvironment(ctx, managedEnv); err != nil {
-		return nil, fmt.Errorf("unable to create managed environment for env obj '%s': %v", managedEnvironment.UID, err)
+		return nil, newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to create managed environment for env obj '%s': %v", managedEnvironment.UID, err))
 	}
 
 	apiCRToDBMapping := &db.APICRToDatabaseMapping{
@@ -464,7 +664,8 @@ func createNewManagedEnv(ctx context.Context, managedEnvironment managedgitopsv1
 	}
 
 	if err := dbQueries.CreateAPICRToDatabaseMapping(ctx, apiCRToDBMapping); err != nil {
-		return nil, fmt.Errorf("unable to create APICRToDatabaseMapping for managed environment: %v", err)
+		return nil, newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to create APICRToDatabaseMapping for managed environment: %v", err))
 	}
 
 	return managedEnv, nil
@@ -606,6 +807,9 @@ type SRLK8sClientFactory interface {
 	// Create a client.Client using the given restconfig
 	BuildK8sClient(restConfig *rest.Config) (client.Client, error)
 
+	// Create a kubernetes.Interface (clientset) using the given restconfig
+	BuildK8sClientset(restConfig *rest.Config) (kubernetes.Interface, error)
+
 	// Create a client.Client which can access the cluster that Argo CD is on
 	GetK8sClientForGitOpsEngineInstance(gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error)
 }
@@ -630,60 +834,287 @@ func (DefaultK8sClientFactory) BuildK8sClient(restConfig *rest.Config) (client.C
 
 }
 
+func (DefaultK8sClientFactory) BuildK8sClientset(restConfig *rest.Config) (kubernetes.Interface, error) {
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create k8s clientset from RESTConfig: %v", err)
+	}
+
+	return clientset, nil
+}
+
 func createNewClusterCredentials(ctx context.Context, managedEnvironment managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment,
 	secret corev1.Secret, k8sClientFactory SRLK8sClientFactory, dbQueries db.DatabaseQueries, log logr.Logger) (db.ClusterCredentials, error) {
 
+	secretCredentials, err := getManagedEnvironmentSecretCredentials(managedEnvironment, secret)
+	if err != nil {
+		return db.ClusterCredentials{}, newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonInvalidSecret, err)
+	}
+
+	namespaces := getManagedEnvironmentNamespaces(managedEnvironment)
+
+	clusterCredentials := db.ClusterCredentials{
+		Host:                           managedEnvironment.Spec.APIURL,
+		Kube_config:                    "",
+		Kube_config_context:            "",
+		Ca_bundle:                      secretCredentials.caBundle,
+		Allow_insecure_skip_tls_verify: secretCredentials.allowInsecureSkipTLSVerify,
+		Namespaces:                     strings.Join(namespaces, ","),
+	}
+
+	if secretCredentials.createServiceAccount {
+
+		k8sClient, err := k8sClientFactory.BuildK8sClient(secretCredentials.restConfig)
+		if err != nil {
+			return db.ClusterCredentials{}, fmt.Errorf("unable to create k8s client from RESTConfig: %v", err)
+		}
+
+		bearerToken, _, err := sharedutil.InstallServiceAccount(ctx, k8sClient, string(managedEnvironment.UID), serviceAccountNamespaceKubeSystem,
+			namespaces, log)
+		if err != nil {
+			return db.ClusterCredentials{}, fmt.Errorf("unable to install service account from secret '%s': %v", secret.Name, err)
+		}
+
+		clusterCredentials.Serviceaccount_bearer_token = bearerToken
+		clusterCredentials.Serviceaccount_ns = serviceAccountNamespaceKubeSystem
+
+	} else {
+		// Use the credentials of the Secret directly
+		clusterCredentials.Serviceaccount_bearer_token = secretCredentials.restConfig.BearerToken
+		clusterCredentials.Client_cert_data = string(secretCredentials.restConfig.CertData)
+		clusterCredentials.Client_key_data = string(secretCredentials.restConfig.KeyData)
+	}
+
+	if err := dbQueries.CreateClusterCredentials(ctx, &clusterCredentials); err != nil {
+		return db.ClusterCredentials{}, newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError,
+			fmt.Errorf("unable to create cluster credentials for host '%s': %v", clusterCredentials.Host, err))
+	}
+
+	return clusterCredentials, nil
+
+}
+
+// managedEnvironmentSecretCredentials are the credentials that are used to connect to a managed environment, as
+// defined by the GitOpsDeploymentManagedEnvironment and its Secret.
+type managedEnvironmentSecretCredentials struct {
+	// restConfig contains the API URL, TLS settings and credentials (from the Secret) of the managed environment
+	restConfig *rest.Config
+
+	// caBundle is the PEM-encoded CA bundle used to verify the TLS certificate of the API server: if empty, the
+	// system roots are used.
+	caBundle string
+
+	// allowInsecureSkipTLSVerify is true if the TLS certificate of the API server should not be verified: this is the
+	// case if it is enabled by the managed environment, or by the cluster of the kubeconfig of the Secret.
+	allowInsecureSkipTLSVerify bool
+
+	// createServiceAccount is true if the credentials of the Secret should be used to install a ServiceAccount on the
+	// managed environment (whose token is then used), and false if the credentials of the Secret should be used directly.
+	createServiceAccount bool
+}
+
+// getManagedEnvironmentSecretCredentials returns the credentials of a managed environment: these are either the
+// credentials of the kubeconfig of the Secret, or the bearer token and/or client certificate/key of the Secret.
+func getManagedEnvironmentSecretCredentials(managedEnvironment managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment,
+	secret corev1.Secret) (managedEnvironmentSecretCredentials, error) {
+
+	if secret.Type != sharedutil.ManagedEnvironmentSecretType {
+		return managedEnvironmentSecretCredentials{}, fmt.Errorf("invalid secret type: %s", secret.Type)
+	}
+
+	res := managedEnvironmentSecretCredentials{}
+
+	if _, exists := secret.Data[sharedutil.ManagedEnvironmentSecretKubeConfigKey]; exists {
+
+		config, matchingContextName, err := loadKubeConfigFromManagedEnvironmentSecret(managedEnvironment, secret)
+		if err != nil {
+			return managedEnvironmentSecretCredentials{}, err
+		}
+
+		clientConfig := clientcmd.NewNonInteractiveClientConfig(*config, matchingContextName, &clientcmd.ConfigOverrides{}, nil)
+
+		restConfig, err := clientConfig.ClientConfig()
+		if err != nil {
+			return managedEnvironmentSecretCredentials{}, fmt.Errorf("unable to retrive restConfig from managed env secret: %v", err)
+		}
+
+		res.restConfig = restConfig
+		res.caBundle = getManagedEnvironmentCABundle(managedEnvironment, config, matchingContextName)
+
+		// A kubeconfig cluster with 'insecure-skip-tls-verify' explicitly opts out of TLS verification, in the same way
+		// as the managed environment. This also ensures that existing managed environments, which were connected to
+		// insecurely before TLS verification was supported, continue to work.
+		res.allowInsecureSkipTLSVerify = restConfig.TLSClientConfig.Insecure
+
+		// For backwards compatibility, a ServiceAccount is installed by default when the Secret contains a kubeconfig.
+		res.createServiceAccount = true
+
+	} else {
+
+		token := secret.Data[sharedutil.ManagedEnvironmentSecretTokenKey]
+		clientCert := secret.Data[sharedutil.ManagedEnvironmentSecretClientCertKey]
+		clientKey := secret.Data[sharedutil.ManagedEnvironmentSecretClientKeyKey]
+
+		if len(token) == 0 && len(clientCert) == 0 {
+			return managedEnvironmentSecretCredentials{}, fmt.Errorf("the Secret must contain either a '%s' field, or a '%s' and/or '%s' field",
+				sharedutil.ManagedEnvironmentSecretKubeConfigKey, sharedutil.ManagedEnvironmentSecretTokenKey, sharedutil.ManagedEnvironmentSecretClientCertKey)
+		}
+
+		if (len(clientCert) == 0) != (len(clientKey) == 0) {
+			return managedEnvironmentSecretCredentials{}, fmt.Errorf("the Secret must contain both a '%s' and a '%s' field, or neither",
+				sharedutil.ManagedEnvironmentSecretClientCertKey, sharedutil.ManagedEnvironmentSecretClientKeyKey)
+		}
+
+		res.restConfig = &rest.Config{
+			Host:        managedEnvironment.Spec.APIURL,
+			BearerToken: string(token),
+		}
+		res.restConfig.CertData = clientCert
+		res.restConfig.KeyData = clientKey
+
+		res.caBundle = managedEnvironment.Spec.CABundle
+		if res.caBundle == "" {
+			res.caBundle = string(secret.Data[sharedutil.ManagedEnvironmentSecretCACertKey])
+		}
+	}
+
+	if managedEnvironment.Spec.CreateNewServiceAccount != nil {
+		res.createServiceAccount = *managedEnvironment.Spec.CreateNewServiceAccount
+	}
+
+	if managedEnvironment.Spec.AllowInsecureSkipTLSVerify {
+		res.allowInsecureSkipTLSVerify = true
+	}
+
+	// Replace the TLS settings of the kubeconfig (if any) with those we have determined above
+	configureTLSClientConfig(res.restConfig, res.caBundle, res.allowInsecureSkipTLSVerify)
+
+	// If the credentials are used directly, they must be of a type that can be stored in the database (and passed to Argo CD)
+	if !res.createServiceAccount && res.restConfig.BearerToken == "" && len(res.restConfig.CertData) == 0 {
+		return managedEnvironmentSecretCredentials{}, fmt.Errorf("the credentials of the Secret must contain a bearer token or client " +
+			"certificate data, if a new service account is not created")
+	}
+
+	return res, nil
+}
+
+// clusterCredentialsMatchManagedEnvironment returns true if the cluster credentials are consistent with the
+// GitOpsDeploymentManagedEnvironment and the credentials of its Secret, and false if they should be replaced.
+func clusterCredentialsMatchManagedEnvironment(clusterCreds db.ClusterCredentials,
+	managedEnvironment managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment, secretCredentials managedEnvironmentSecretCredentials) bool {
+
+	if clusterCreds.Host != managedEnvironment.Spec.APIURL ||
+		clusterCreds.Ca_bundle != secretCredentials.caBundle ||
+		clusterCreds.Allow_insecure_skip_tls_verify != secretCredentials.allowInsecureSkipTLSVerify ||
+		clusterCreds.Namespaces != strings.Join(getManagedEnvironmentNamespaces(managedEnvironment), ",") {
+		return false
+	}
+
+	// Credentials of a ServiceAccount installed by the GitOps Service include the namespace of the ServiceAccount.
+	isServiceAccount := clusterCreds.Serviceaccount_ns != ""
+	if isServiceAccount != secretCredentials.createServiceAccount {
+		return false
+	}
+
+	if isServiceAccount {
+		// The token of the ServiceAccount remains valid, even if the credentials of the Secret change.
+		return true
+	}
+
+	return clusterCreds.Serviceaccount_bearer_token == secretCredentials.restConfig.BearerToken &&
+		clusterCreds.Client_cert_data == string(secretCredentials.restConfig.CertData) &&
+		clusterCreds.Client_key_data == string(secretCredentials.restConfig.KeyData)
+}
+
+// loadKubeConfigFromManagedEnvironmentSecret parses the kubeconfig of a managed environment Secret, and returns it,
+// along with the name of the context that matches the API URL of the managed environment.
+func loadKubeConfigFromManagedEnvironmentSecret(managedEnvironment managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment,
+	secret corev1.Secret) (*clientcmdapi.Config, string, error) {
+
 	if secret.Type != sharedutil.ManagedEnvironmentSecretType {
-		return db.ClusterCredentials{}, fmt.Errorf("invalid secret type: %s", secret.Type)
+		return nil, "", fmt.Errorf("invalid secret type: %s", secret.Type)
 	}
 
-	kubeconfig, exists := secret.Data["kubeconfig"]
+	kubeconfig, exists := secret.Data[sharedutil.ManagedEnvironmentSecretKubeConfigKey]
 	if !exists {
-		return db.ClusterCredentials{}, fmt.Errorf("missing kubeConfig field in Secret")
+		return nil, "", fmt.Errorf("missing kubeConfig field in Secret")
 	}
 
 	// Load the kubeconfig from the field
 	config, err := clientcmd.Load(kubeconfig)
 	if err != nil {
-		return db.ClusterCredentials{}, fmt.Errorf("unable to parse kubeconfig data: %v", err)
+		return nil, "", fmt.Errorf("unable to parse kubeconfig data: %v", err)
 	}
 
 	matchingContextName, err := locateContextThatMatchesAPIURL(config, managedEnvironment.Spec.APIURL)
 	if err != nil {
-		return db.ClusterCredentials{}, err
+		return nil, "", err
 	}
 
-	clientConfig := clientcmd.NewNonInteractiveClientConfig(*config, matchingContextName, &clientcmd.ConfigOverrides{}, nil)
+	return config, matchingContextName, nil
+}
 
-	restConfig, err := clientConfig.ClientConfig()
-	if err != nil {
-		return db.ClusterCredentials{}, fmt.Errorf("unable to retrive restConfig from managed env secret: %v", err)
+// getManagedEnvironmentCABundle returns the PEM-encoded CA bundle that should be used to verify the TLS certificate of
+// the API server of the managed environment: this is the CA bundle of the GitOpsDeploymentManagedEnvironment, if
+// specified, otherwise it is the certificate authority data of the kubeconfig cluster referenced by the given context.
+// An empty string is returned if neither is available, in which case the system roots are used.
+func getManagedEnvironmentCABundle(managedEnvironment managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment,
+	config *clientcmdapi.Config, contextName string) string {
+
+	if managedEnvironment.Spec.CABundle != "" {
+		return managedEnvironment.Spec.CABundle
 	}
 
-	k8sClient, err := k8sClientFactory.BuildK8sClient(restConfig)
-	if err != nil {
-		return db.ClusterCredentials{}, fmt.Errorf("unable to create k8s client from RESTConfig: %v", err)
+	kubeContext, exists := config.Contexts[contextName]
+	if !exists || kubeContext == nil {
+		return ""
 	}
 
-	bearerToken, _, err := sharedutil.InstallServiceAccount(ctx, k8sClient, string(managedEnvironment.UID), serviceAccountNamespaceKubeSystem, log)
-	if err != nil {
-		return db.ClusterCredentials{}, fmt.Errorf("unable to install service account from secret '%s': %v", secret.Name, err)
+	cluster, exists := config.Clusters[kubeContext.Cluster]
+	if !exists || cluster == nil {
+		return ""
 	}
 
-	clusterCredentials := db.ClusterCredentials{
-		Host:                        managedEnvironment.Spec.APIURL,
-		Kube_config:                 "",
-		Kube_config_context:         "",
-		Serviceaccount_bearer_token: bearerToken,
-		Serviceaccount_ns:           serviceAccountNamespaceKubeSystem,
+	return string(cluster.CertificateAuthorityData)
+}
+
+// getManagedEnvironmentNamespaces returns the sorted (and de-duplicated) allow-list of namespaces of the managed
+// environment. An empty list is returned if the managed environment is not namespace-scoped.
+func getManagedEnvironmentNamespaces(managedEnvironment managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment) []string {
+
+	namespaces := []string{}
+	for _, namespace := range managedEnvironment.Spec.Namespaces {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" {
+			continue
+		}
+		namespaces = append(namespaces, namespace)
 	}
+	sort.Strings(namespaces)
 
-	if err := dbQueries.CreateClusterCredentials(ctx, &clusterCredentials); err != nil {
-		return db.ClusterCredentials{}, fmt.Errorf("unable to create cluster credentials for host '%s': %v", clusterCredentials.Host, err)
+	res := []string{}
+	for idx, namespace := range namespaces {
+		if idx > 0 && namespaces[idx-1] == namespace {
+			continue
+		}
+		res = append(res, namespace)
 	}
 
-	return clusterCredentials, nil
+	return res
+}
+
+// configureTLSClientConfig replaces the TLS server verification settings of 'restConfig': the TLS certificate of the API
+// server is verified using 'caBundle' (or the system roots, if empty), unless 'allowInsecureSkipTLSVerify' is true.
+func configureTLSClientConfig(restConfig *rest.Config, caBundle string, allowInsecureSkipTLSVerify bool) {
+
+	restConfig.TLSClientConfig.Insecure = allowInsecureSkipTLSVerify
 
+	// A root CA may not be specified alongside the insecure flag, so these are only set when TLS verification is enabled.
+	restConfig.TLSClientConfig.CAFile = ""
+	restConfig.TLSClientConfig.CAData = nil
+	if !allowInsecureSkipTLSVerify && caBundle != "" {
+		restConfig.TLSClientConfig.CAData = []byte(caBundle)
+	}
 }
 
 // locateContextThatMatchesAPIURL examines a kubeconfig (Config struct), and looks for the context that
@@ -730,16 +1161,20 @@ func verifyClusterCredentials(ctx context.Context, clusterCreds db.ClusterCreden
 	if clusterCreds.Host == "" {
 		return false, fmt.Errorf("cluster credentials is missing host")
 	}
-	if clusterCreds.Serviceaccount_bearer_token == "" {
-		return false, fmt.Errorf("cluster credentials is missing service account bearer token")
+	if clusterCreds.Serviceaccount_bearer_token == "" && clusterCreds.Client_cert_data == "" {
+		return false, fmt.Errorf("cluster credentials is missing bearer token or client certificate")
 	}
 
 	configParam := &rest.Config{
 		Host:        clusterCreds.Host,
 		BearerToken: clusterCreds.Serviceaccount_bearer_token,
 	}
+	if clusterCreds.Client_cert_data != "" {
+		configParam.CertData = []byte(clusterCreds.Client_cert_data)
+		configParam.KeyData = []byte(clusterCreds.Client_key_data)
+	}
 
-	configParam.Insecure = true // TODO: GITOPSRVCE-178: Once we have TLS validation enabled, the TLS validation value should be used here.
+	configureTLSClientConfig(configParam, clusterCreds.Ca_bundle, clusterCreds.Allow_insecure_skip_tls_verify)
 	configParam.ServerName = ""
 
 	clientObj, err := k8sClientFactory.BuildK8sClient(configParam)
@@ -747,6 +1182,41 @@ func verifyClusterCredentials(ctx context.Context, clusterCreds db.ClusterCreden
 		return false, fmt.Errorf("unable to create new K8s client to '%v'", configParam.Host)
 	}
 
+	if clusterCreds.Serviceaccount_ns == "" {
+		// The credentials were provided directly by the user, rather than being those of a ServiceAccount installed by
+		// the GitOps Service. So, verify that the client works by listing ServiceAccounts, either within the namespaces
+		// of the managed environment (if it is namespace-scoped), or cluster-wide.
+		listOpts := []client.ListOption{client.Limit(1)}
+		if clusterCreds.Namespaces != "" {
+			listOpts = append(listOpts, client.InNamespace(strings.Split(clusterCreds.Namespaces, ",")[0]))
+		}
+		if err := clientObj.List(ctx, &corev1.ServiceAccountList{}, listOpts...); err != nil {
+			return false, fmt.Errorf("unable to list service accounts when verifying cluster credential '%s': %v",
+				clusterCreds.Clustercredentials_cred_id, err)
+		}
+
+		// Success!
+		return true, nil
+	}
+
+	if clusterCreds.Namespaces != "" {
+		// The service account of a namespace-scoped managed environment is not able to retrieve itself, so instead
+		// verify that the client works by attempting to retrieve the Role that grants it access to a namespace.
+		role := &rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      sharedutil.ArgoCDManagerRoleNamePrefix + string(managedEnvCR.UID),
+				Namespace: strings.Split(clusterCreds.Namespaces, ",")[0],
+			},
+		}
+		if err := clientObj.Get(ctx, client.ObjectKeyFromObject(role), role); err != nil {
+			return false, fmt.Errorf("unable to retrieve role when verifying cluster credential '%s': %v",
+				clusterCreds.Clustercredentials_cred_id, err)
+		}
+
+		// Success!
+		return true, nil
+	}
+
 	// To verify that the client works, attempt to retrieve the service account
 	serviceAccount := &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
@@ -762,3 +1232,432 @@ func verifyClusterCredentials(ctx context.Context, clusterCreds db.ClusterCreden
 	// Success!
 	return true, nil
 }
+
+const (
+	// ServiceAccountTokenExpiration is the lifetime of the ServiceAccount bearer tokens that are requested (via the
+	// TokenRequest API) when the token of a managed environment is rotated.
+	ServiceAccountTokenExpiration = 24 * time.Hour
+
+	// ServiceAccountTokenRotationInterval is how often managed environments are reconciled, in order to re-verify their
+	// credentials and to rotate their ServiceAccount bearer tokens (see 'isServiceAccountTokenRotationRequired').
+	ServiceAccountTokenRotationInterval = 1 * time.Hour
+
+	// ServiceAccountTokenRotationOperationTimeout is how long we will wait for the Argo CD cluster secrets of a managed
+	// environment to be updated with a rotated token, before giving up on revoking the old token (until the next rotation).
+	ServiceAccountTokenRotationOperationTimeout = 5 * time.Minute
+
+	// serviceAccountTokenRotationOperationGCExpirationTime is the amount of time (in seconds) after a token rotation
+	// Operation has completed, after which it will be garbage collected by the cluster-agent. This must exceed
+	// 'ServiceAccountTokenRotationOperationTimeout', so that the Operation is not deleted while we are waiting for it.
+	serviceAccountTokenRotationOperationGCExpirationTime = 60 * 60
+)
+
+// isServiceAccountTokenRotationRequired returns true if the cluster credentials contain the bearer token of a ServiceAccount
+// installed by the GitOps Service, and that token is due to be rotated: this is the case if the token does not expire
+// (for example, the long-lived token of a ServiceAccount token Secret), or if less than half of its lifetime remains.
+func isServiceAccountTokenRotationRequired(clusterCreds db.ClusterCredentials) bool {
+
+	if clusterCreds.Serviceaccount_ns == "" {
+		// The credentials were provided directly by the user, and so they are not ours to rotate.
+		return false
+	}
+
+	if clusterCreds.Serviceaccount_bearer_token_expiry.IsZero() {
+		return true
+	}
+
+	return time.Until(clusterCreds.Serviceaccount_bearer_token_expiry) < ServiceAccountTokenExpiration/2
+}
+
+// rotateServiceAccountToken replaces the ServiceAccount bearer token of the cluster credentials of a managed environment:
+// 1) A new token, which expires after 'ServiceAccountTokenExpiration', is requested for the ServiceAccount via the
+// TokenRequest API, using the credentials of the Secret of the managed environment.
+// 2) New cluster credentials are created containing the new token, the managed environment is updated to point to
+// them, and the old cluster credentials are deleted.
+// 3) An Operation is created for each Argo CD instance that targets the managed environment, so that the Argo CD
+// cluster secret of the managed environment is updated with the new token.
+// 4) A task is added to 'taskRetryLoop' which waits (for up to 'ServiceAccountTokenRotationOperationTimeout') for these
+// Operations to complete, and then revokes any long-lived tokens of the ServiceAccount, by deleting its token Secrets.
+// This is not done here, so that the shared resource event loop is not blocked while waiting. If the Operations do not
+// complete in time, the long-lived tokens are left in place (as Argo CD may still be using them): revocation is
+// attempted again on the next rotation.
+func rotateServiceAccountToken(ctx context.Context, managedEnvironmentCR managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment,
+	secretCredentials managedEnvironmentSecretCredentials, managedEnvironmentDB *db.ManagedEnvironment, oldClusterCreds db.ClusterCredentials,
+	k8sClientFactory SRLK8sClientFactory, taskRetryLoop *sharedutil.TaskRetryLoop, dbQueries db.DatabaseQueries, log logr.Logger) error {
+
+	log = log.WithValues("managedEnv", managedEnvironmentDB.Managedenvironment_id)
+
+	// 1) Request a new token for the ServiceAccount
+	clientset, err := k8sClientFactory.BuildK8sClientset(secretCredentials.restConfig)
+	if err != nil {
+		return err
+	}
+
+	bearerToken, expiry, err := sharedutil.RequestServiceAccountToken(ctx, clientset, string(managedEnvironmentCR.UID),
+		oldClusterCreds.Serviceaccount_ns, ServiceAccountTokenExpiration)
+	if err != nil {
+		return err
+	}
+
+	// 2) Replace the cluster credentials of the managed environment with new cluster credentials containing the new token
+	newClusterCreds := oldClusterCreds
+	newClusterCreds.Clustercredentials_cred_id = ""
+	newClusterCreds.SeqID = 0
+	newClusterCreds.Serviceaccount_bearer_token = bearerToken
+	newClusterCreds.Serviceaccount_bearer_token_expiry = expiry
+
+	if err := dbQueries.CreateClusterCredentials(ctx, &newClusterCreds); err != nil {
+		return fmt.Errorf("unable to create cluster credentials for rotated token: %v", err)
+	}
+
+	managedEnvironmentDB.Clustercredentials_id = newClusterCreds.Clustercredentials_cred_id
+	if err := dbQueries.UpdateManagedEnvironment(ctx, managedEnvironmentDB); err != nil {
+		managedEnvironmentDB.Clustercredentials_id = oldClusterCreds.Clustercredentials_cred_id
+		return fmt.Errorf("unable to update managed environment with rotated token: %v", err)
+	}
+
+	rowsDeleted, err := dbQueries.DeleteClusterCredentialsById(ctx, oldClusterCreds.Clustercredentials_cred_id)
+	if err != nil {
+		return fmt.Errorf("unable to delete old cluster credentials '%s': %v", oldClusterCreds.Clustercredentials_cred_id, err)
+	}
+	if rowsDeleted != 1 {
+		log.V(sharedutil.LogLevel_Warn).Info("unexpected number of rows deleted when deleting cluster credentials",
+			"clusterCredentialsID", oldClusterCreds.Clustercredentials_cred_id)
+	}
+
+	log.Info("Rotated service account token of managed environment", "clusterCredentialsID", newClusterCreds.Clustercredentials_cred_id)
+
+	// 3) Inform each Argo CD instance that targets the managed environment that the managed environment has changed,
+	// so that the Argo CD cluster secrets are updated with the new token
+	clusterAccesses := []db.ClusterAccess{}
+	if err := dbQueries.ListClusterAccessesByManagedEnvironmentID(ctx, managedEnvironmentDB.Managedenvironment_id, &clusterAccesses); err != nil {
+		return fmt.Errorf("unable to list cluster accesses of managed environment: %v", err)
+	}
+
+	// key: gitops engine instance id, value: the user that has access to the managed environment via that instance
+	gitopsEngineInstanceUsers := map[string]string{}
+	for _, clusterAccess := range clusterAccesses {
+		if _, exists := gitopsEngineInstanceUsers[clusterAccess.Clusteraccess_gitops_engine_instance_id]; !exists {
+			gitopsEngineInstanceUsers[clusterAccess.Clusteraccess_gitops_engine_instance_id] = clusterAccess.Clusteraccess_user_id
+		}
+	}
+
+	operationIDs := []string{}
+	for gitopsEngineInstanceID, userID := range gitopsEngineInstanceUsers {
+
+		gitopsEngineInstance := &db.GitopsEngineInstance{
+			Gitopsengineinstance_id: gitopsEngineInstanceID,
+		}
+		if err := dbQueries.GetGitopsEngineInstanceById(ctx, gitopsEngineInstance); err != nil {
+			return fmt.Errorf("unable to retrieve gitopsengineinstance '%s' while rotating token: %v", gitopsEngineInstanceID, err)
+		}
+
+		client, err := k8sClientFactory.GetK8sClientForGitOpsEngineInstance(gitopsEngineInstance)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve k8s client for engine instance '%s': %v", gitopsEngineInstanceID, err)
+		}
+
+		// We don't wait for the Operation here: instead, the cluster-agent garbage collects it once it has completed.
+		operation := db.Operation{
+			Instance_id:             gitopsEngineInstanceID,
+			Operation_owner_user_id: userID,
+			Resource_type:           db.OperationResourceType_ManagedEnvironment,
+			Resource_id:             managedEnvironmentDB.Managedenvironment_id,
+			GC_expiration_time:      serviceAccountTokenRotationOperationGCExpirationTime,
+		}
+
+		log.Info("Creating operation to update managed environment with rotated token", "gitopsEngineInstance", gitopsEngineInstanceID)
+
+		_, dbOperation, err := operations.CreateOperation(ctx, false, operation, userID,
+			dbutil.GetGitOpsEngineSingleInstanceNamespace(), dbQueries, client, log)
+		if err != nil {
+			return fmt.Errorf("unable to create operation for managed environment with rotated token: %v", err)
+		}
+
+		operationIDs = append(operationIDs, dbOperation.Operation_id)
+	}
+
+	// 4) Once the Argo CD cluster secrets have been updated, revoke the long-lived tokens of the ServiceAccount, which
+	// would otherwise never expire. This is done on every rotation, so that a previously failed revocation is retried.
+	taskRetryLoop.AddTaskIfNotPresent("revoke-service-account-tokens-"+managedEnvironmentDB.Managedenvironment_id,
+		&revokeServiceAccountTokensTask{
+			operationIDs:            operationIDs,
+			deadline:                time.Now().Add(ServiceAccountTokenRotationOperationTimeout),
+			restConfig:              secretCredentials.restConfig,
+			managedEnvironmentUID:   string(managedEnvironmentCR.UID),
+			serviceAccountNamespace: oldClusterCreds.Serviceaccount_ns,
+			k8sClientFactory:        k8sClientFactory,
+			dbQueries:               dbQueries,
+			log:                     log,
+		},
+		sharedutil.ExponentialBackoff{Factor: 2, Min: time.Millisecond * 200, Max: time.Second * 10, Jitter: true})
+
+	return nil
+}
+
+// revokeServiceAccountTokensTask waits for the Operations that update the Argo CD cluster secrets of a managed
+// environment with a rotated token to complete, and then revokes the long-lived tokens of the ServiceAccount of the
+// managed environment (see 'rotateServiceAccountToken').
+type revokeServiceAccountTokensTask struct {
+	operationIDs []string
+
+	// deadline is the time after which we stop waiting for the Operations, and leave the long-lived tokens in place
+	deadline time.Time
+
+	// restConfig contains the credentials of the Secret of the managed environment
+	restConfig              *rest.Config
+	managedEnvironmentUID   string
+	serviceAccountNamespace string
+
+	k8sClientFactory SRLK8sClientFactory
+	dbQueries        db.DatabaseQueries
+	log              logr.Logger
+}
+
+func (task *revokeServiceAccountTokensTask) PerformTask(taskContext context.Context) (bool, error) {
+
+	for _, operationID := range task.operationIDs {
+
+		dbOperation := db.Operation{Operation_id: operationID}
+		if err := task.dbQueries.GetOperationById(taskContext, &dbOperation); err != nil {
+			return task.retryUntilDeadline(fmt.Errorf("unable to retrieve operation '%s' for managed environment with rotated token: %v",
+				operationID, err))
+		}
+
+		if dbOperation.State == db.OperationState_Failed {
+			return false, fmt.Errorf("operation '%s' for managed environment with rotated token did not complete successfully, "+
+				"so the old service account tokens were not revoked", operationID)
+		}
+
+		if dbOperation.State != db.OperationState_Completed {
+			// The Operation has not yet been processed by the cluster-agent, so keep waiting
+			return task.retryUntilDeadline(nil)
+		}
+	}
+
+	k8sClient, err := task.k8sClientFactory.BuildK8sClient(task.restConfig)
+	if err != nil {
+		return task.retryUntilDeadline(err)
+	}
+
+	if err := sharedutil.DeleteServiceAccountTokenSecrets(taskContext, k8sClient, task.managedEnvironmentUID,
+		task.serviceAccountNamespace, task.log); err != nil {
+		return task.retryUntilDeadline(fmt.Errorf("unable to revoke old service account tokens: %v", err))
+	}
+
+	return false, nil
+}
+
+// retryUntilDeadline returns true (the task should be retried), until the deadline of the task has passed: after that,
+// revocation is abandoned until the next rotation.
+func (task *revokeServiceAccountTokensTask) retryUntilDeadline(err error) (bool, error) {
+
+	if time.Now().Before(task.deadline) {
+		return true, err
+	}
+
+	if err == nil {
+		err = fmt.Errorf("operations for managed environment with rotated token did not complete in time")
+	}
+
+	return false, fmt.Errorf("old service account tokens were not revoked, and will be revoked on the next rotation: %w", err)
+}
+
+const (
+	// ManagedEnvironmentServiceAccountCleanupTimeout is how long we will attempt to clean up the service account of a
+	// managed environment that is being deleted (measured from its deletion timestamp), before giving up and allowing
+	// the managed environment to be deleted without the cleanup.
+	ManagedEnvironmentServiceAccountCleanupTimeout = 5 * time.Minute
+)
+
+// addManagedEnvironmentFinalizer adds the service account cleanup finalizer to the GitOpsDeploymentManagedEnvironment,
+// if the cluster credentials of the managed environment are those of a ServiceAccount that we installed. This ensures
+// that we are able to clean up the ServiceAccount when the GitOpsDeploymentManagedEnvironment is deleted.
+// If the GitOpsDeploymentManagedEnvironment no longer exists, or is being deleted, no action is taken.
+func addManagedEnvironmentFinalizer(ctx context.Context, workspaceClient client.Client, managedEnvironmentCRName string,
+	managedEnvironmentCRNamespace string, managedEnv db.ManagedEnvironment, dbQueries db.DatabaseQueries, log logr.Logger) {
+
+	clusterCreds := &db.ClusterCredentials{
+		Clustercredentials_cred_id: managedEnv.Clustercredentials_id,
+	}
+	if err := dbQueries.GetClusterCredentialsById(ctx, clusterCreds); err != nil {
+		log.Error(err, "unable to retrieve cluster credentials, in order to add finalizer to managed environment", "managedEnv", managedEnvironmentCRName)
+		return
+	}
+
+	if clusterCreds.Serviceaccount_ns == "" {
+		// We didn't install a ServiceAccount on the managed environment, so there is nothing to clean up.
+		return
+	}
+
+	managedEnvironmentCR := managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      managedEnvironmentCRName,
+			Namespace: managedEnvironmentCRNamespace,
+		},
+	}
+	if err := workspaceClient.Get(ctx, client.ObjectKeyFromObject(&managedEnvironmentCR), &managedEnvironmentCR); err != nil {
+		if !apierr.IsNotFound(err) {
+			log.Error(err, "unable to retrieve managed environment, in order to add finalizer", "managedEnv", managedEnvironmentCRName)
+		}
+		return
+	}
+
+	if managedEnvironmentCR.DeletionTimestamp != nil ||
+		controllerutil.ContainsFinalizer(&managedEnvironmentCR, managedgitopsv1alpha1.ManagedEnvironmentServiceAccountCleanupFinalizer) {
+		return
+	}
+
+	controllerutil.AddFinalizer(&managedEnvironmentCR, managedgitopsv1alpha1.ManagedEnvironmentServiceAccountCleanupFinalizer)
+
+	if err := workspaceClient.Update(ctx, &managedEnvironmentCR); err != nil {
+		log.Error(err, "unable to add finalizer to managed environment", "managedEnv", managedEnvironmentCRName)
+		return
+	}
+	sharedutil.LogAPIResourceChangeEvent(managedEnvironmentCR.Namespace, managedEnvironmentCR.Name, managedEnvironmentCR, sharedutil.ResourceModified, log)
+}
+
+// cleanUpManagedEnvironmentServiceAccount is called when a GitOpsDeploymentManagedEnvironment with the service account
+// cleanup finalizer is being deleted. It deletes the ServiceAccount (and the related Secret and roles) that we installed
+// on the managed environment, then removes the finalizer.
+//
+// Cleanup is best-effort: if it fails, the failure is reported via the ServiceAccountCleanupSucceeded condition, and
+// an error is returned so that the cleanup is retried (by the task retry loop of the caller). Once
+// 'ManagedEnvironmentServiceAccountCleanupTimeout' has elapsed since the deletion timestamp, we stop retrying and
+// remove the finalizer regardless.
+func cleanUpManagedEnvironmentServiceAccount(ctx context.Context, managedEnvironmentCR managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment,
+	workspaceClient client.Client, k8sClientFactory SRLK8sClientFactory, dbQueries db.DatabaseQueries, log logr.Logger) error {
+
+	if cleanupErr := uninstallManagedEnvironmentServiceAccount(ctx, managedEnvironmentCR, workspaceClient, k8sClientFactory, dbQueries, log); cleanupErr != nil {
+
+		if time.Now().Before(managedEnvironmentCR.DeletionTimestamp.Add(ManagedEnvironmentServiceAccountCleanupTimeout)) {
+			updateManagedEnvironmentCleanupStatus(ctx, workspaceClient, managedEnvironmentCR, cleanupErr, log)
+			return fmt.Errorf("unable to clean up service account of managed environment '%s': %v", managedEnvironmentCR.Name, cleanupErr)
+		}
+
+		log.Error(cleanupErr, "unable to clean up service account of managed environment before the timeout expired: "+
+			"the remaining resources must be deleted from the managed environment manually", "managedEnv", managedEnvironmentCR.Name)
+	}
+
+	controllerutil.RemoveFinalizer(&managedEnvironmentCR, managedgitopsv1alpha1.ManagedEnvironmentServiceAccountCleanupFinalizer)
+
+	if err := workspaceClient.Update(ctx, &managedEnvironmentCR); err != nil {
+		if apierr.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to remove finalizer from managed environment '%s': %v", managedEnvironmentCR.Name, err)
+	}
+	sharedutil.LogAPIResourceChangeEvent(managedEnvironmentCR.Namespace, managedEnvironmentCR.Name, managedEnvironmentCR, sharedutil.ResourceModified, log)
+
+	return nil
+}
+
+// uninstallManagedEnvironmentServiceAccount deletes the ServiceAccount (and the related Secret and roles) that we installed
+// on the managed environment, using the credentials of the Secret of the managed environment.
+//
+// If the managed environment no longer has cluster credentials containing a ServiceAccount that we installed, or if
+// the Secret no longer exists (and thus we no longer have credentials that can access the managed environment),
+// there is nothing we can clean up, and no error is returned.
+func uninstallManagedEnvironmentServiceAccount(ctx context.Context, managedEnvironmentCR managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment,
+	workspaceClient client.Client, k8sClientFactory SRLK8sClientFactory, dbQueries db.DatabaseQueries, log logr.Logger) error {
+
+	// 1) Locate the cluster credentials of the managed environment
+	apiCRToDBMapping := db.APICRToDatabaseMapping{
+		APIResourceType: db.APICRToDatabaseMapping_ResourceType_GitOpsDeploymentManagedEnvironment,
+		APIResourceUID:  string(managedEnvironmentCR.UID),
+		DBRelationType:  db.APICRToDatabaseMapping_DBRelationType_ManagedEnvironment,
+	}
+	if err := dbQueries.GetDatabaseMappingForAPICR(ctx, &apiCRToDBMapping); err != nil {
+		if db.IsResultNotFoundError(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to retrieve managed environment APICRToDatabaseMapping for %s: %v", apiCRToDBMapping.APIResourceUID, err)
+	}
+
+	managedEnv := &db.ManagedEnvironment{
+		Managedenvironment_id: apiCRToDBMapping.DBRelationKey,
+	}
+	if err := dbQueries.GetManagedEnvironmentById(ctx, managedEnv); err != nil {
+		if db.IsResultNotFoundError(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to retrieve managed environment '%s': %v", managedEnv.Managedenvironment_id, err)
+	}
+
+	clusterCreds := &db.ClusterCredentials{
+		Clustercredentials_cred_id: managedEnv.Clustercredentials_id,
+	}
+	if err := dbQueries.GetClusterCredentialsById(ctx, clusterCreds); err != nil {
+		if db.IsResultNotFoundError(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to retrieve cluster credentials for '%s': %v", clusterCreds.Clustercredentials_cred_id, err)
+	}
+
+	if clusterCreds.Serviceaccount_ns == "" {
+		// We didn't install a ServiceAccount on the managed environment, so there is nothing to clean up.
+		return nil
+	}
+
+	// 2) Retrieve the credentials of the Secret: these are the credentials that were used to install the ServiceAccount
+	secretCR := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      managedEnvironmentCR.Spec.ClusterCredentialsSecret,
+			Namespace: managedEnvironmentCR.Namespace,
+		},
+	}
+	if err := workspaceClient.Get(ctx, client.ObjectKeyFromObject(&secretCR), &secretCR); err != nil {
+		if apierr.IsNotFound(err) {
+			log.Info("Secret of managed environment no longer exists, so its service account cannot be cleaned up.",
+				"secret", secretCR.Name, "serviceAccount", sharedutil.GenerateServiceAccountName(string(managedEnvironmentCR.UID)))
+			return nil
+		}
+		return fmt.Errorf("unable to retrieve secret '%s' of managed environment: %v", secretCR.Name, err)
+	}
+
+	secretCredentials, err := getManagedEnvironmentSecretCredentials(managedEnvironmentCR, secretCR)
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := k8sClientFactory.BuildK8sClient(secretCredentials.restConfig)
+	if err != nil {
+		return fmt.Errorf("unable to create k8s client from RESTConfig: %v", err)
+	}
+
+	// 3) Delete the ServiceAccount, and the related Secret and roles, from the managed environment
+	namespaces := []string{}
+	if clusterCreds.Namespaces != "" {
+		namespaces = strings.Split(clusterCreds.Namespaces, ",")
+	}
+
+	return sharedutil.UninstallServiceAccount(ctx, k8sClient, string(managedEnvironmentCR.UID), clusterCreds.Serviceaccount_ns, namespaces, log)
+}
+
+// updateManagedEnvironmentCleanupStatus reports a failure to clean up the service account of a GitOpsDeploymentManagedEnvironment
+// that is being deleted, via its ServiceAccountCleanupSucceeded condition.
+func updateManagedEnvironmentCleanupStatus(ctx context.Context, workspaceClient client.Client,
+	managedEnvironmentCR managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment, cleanupErr error, log logr.Logger) {
+
+	condition := metav1.Condition{
+		Type:    managedgitopsv1alpha1.ManagedEnvironmentConditionServiceAccountCleanupSucceeded,
+		Status:  metav1.ConditionFalse,
+		Reason:  managedgitopsv1alpha1.ManagedEnvironmentReasonUnableToCleanUpServiceAccount,
+		Message: cleanupErr.Error(),
+	}
+
+	existingCondition := meta.FindStatusCondition(managedEnvironmentCR.Status.Conditions, condition.Type)
+	if existingCondition != nil && existingCondition.Status == condition.Status &&
+		existingCondition.Reason == condition.Reason && existingCondition.Message == condition.Message {
+		// The condition is unchanged, so no update is required
+		return
+	}
+
+	meta.SetStatusCondition(&managedEnvironmentCR.Status.Conditions, condition)
+
+	if err := workspaceClient.Status().Update(ctx, &managedEnvironmentCR); err != nil {
+		log.Error(err, "unable to update status of managed environment", "managedEnv", managedEnvironmentCR.Name)
+		return
+	}
+	sharedutil.LogAPIResourceChangeEvent(managedEnvironmentCR.Namespace, managedEnvironmentCR.Name, managedEnvironmentCR, sharedutil.ResourceModified, log)
+}
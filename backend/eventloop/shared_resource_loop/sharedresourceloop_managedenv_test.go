@@ -3,6 +3,8 @@ package shared_resource_loop
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
@@ -17,10 +19,19 @@ import (
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/eventloop_test_util"
 
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -33,6 +44,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 	Context("Shared Resource Event Loop test", func() {
 
 		var mockFactory MockSRLK8sClientFactory
+		var taskRetryLoop *sharedutil.TaskRetryLoop
 
 		var k8sClient client.WithWatch
 		var dbQueries db.AllDatabaseQueries
@@ -67,6 +79,8 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 				fakeClient: k8sClient,
 			}
 
+			taskRetryLoop = sharedutil.NewTaskRetryLoop("test-shared-resource-loop-retry-loop")
+
 			dbQueries, err = db.NewUnsafePostgresDBQueries(true, true)
 			Expect(err).To(BeNil())
 
@@ -125,7 +139,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			By("calling managed environment for the first time, and verifying the database rows are created")
 
 			src, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 			Expect(src.ManagedEnv).To(Not(BeNil()))
 
@@ -138,7 +152,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			Expect(err).To(BeNil())
 
 			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 			Expect(src.ManagedEnv).To(Not(BeNil()))
 			verifyResult(managedEnv, src)
@@ -158,7 +172,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			Expect(err).To(BeNil())
 
 			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 
 			By("updating the managed environment, and verifying that the database rows are also updated")
@@ -173,7 +187,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			err = k8sClient.Update(ctx, &managedEnv)
 			Expect(err).To(BeNil())
 			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 
 			By("verifying the old cluster credentials have been deleted, after update")
@@ -200,7 +214,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			oldManagedEnv := src.ManagedEnv
 
 			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 
 			err = dbQueries.GetManagedEnvironmentById(ctx, oldManagedEnv)
@@ -220,6 +234,357 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 		})
 
+		It("should replace the cluster credentials of the managed environment, if its TLS settings change", func() {
+
+			managedEnv, secret := buildManagedEnvironmentForSRL()
+			managedEnv.UID = "test-" + uuid.NewUUID()
+			secret.UID = "test-" + uuid.NewUUID()
+			eventloop_test_util.StartServiceAccountListenerOnFakeClient(ctx, string(managedEnv.UID), k8sClient)
+
+			// Remove the 'insecure-skip-tls-verify' flag from the kubeconfig, so that TLS verification is only disabled by the spec
+			secret.Data["kubeconfig"] = []byte(strings.ReplaceAll(string(secret.Data["kubeconfig"]), "insecure-skip-tls-verify: true", "insecure-skip-tls-verify: false"))
+
+			err := k8sClient.Create(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Create(ctx, &secret)
+			Expect(err).To(BeNil())
+
+			By("calling managed environment for the first time, and verifying TLS verification is enabled by default")
+
+			src, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			verifyResult(managedEnv, src)
+
+			oldClusterCreds := &db.ClusterCredentials{
+				Clustercredentials_cred_id: src.ManagedEnv.Clustercredentials_id,
+			}
+			err = dbQueries.GetClusterCredentialsById(ctx, oldClusterCreds)
+			Expect(err).To(BeNil())
+			Expect(oldClusterCreds.Allow_insecure_skip_tls_verify).To(BeFalse())
+			Expect(oldClusterCreds.Ca_bundle).To(BeEmpty())
+
+			By("specifying a CA bundle and disabling TLS verification, and verifying new cluster credentials contain the update")
+
+			managedEnv.Spec.CABundle = "my-ca-bundle"
+			managedEnv.Spec.AllowInsecureSkipTLSVerify = true
+			err = k8sClient.Update(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			verifyResult(managedEnv, src)
+
+			err = dbQueries.GetClusterCredentialsById(ctx, oldClusterCreds)
+			Expect(db.IsResultNotFoundError(err)).To(BeTrue())
+
+			newClusterCreds := &db.ClusterCredentials{
+				Clustercredentials_cred_id: src.ManagedEnv.Clustercredentials_id,
+			}
+			err = dbQueries.GetClusterCredentialsById(ctx, newClusterCreds)
+			Expect(err).To(BeNil())
+			Expect(newClusterCreds.Allow_insecure_skip_tls_verify).To(BeTrue())
+			Expect(newClusterCreds.Ca_bundle).To(Equal(managedEnv.Spec.CABundle))
+
+			By("calling reconcile on an unchanged resource, and verifying the cluster credentials are not replaced")
+
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv.Clustercredentials_id).To(Equal(newClusterCreds.Clustercredentials_cred_id))
+		})
+
+		It("should only grant access to the namespaces of a namespace-scoped managed environment", func() {
+
+			managedEnv, secret := buildManagedEnvironmentForSRL()
+			managedEnv.UID = "test-" + uuid.NewUUID()
+			managedEnv.Spec.Namespaces = []string{"namespace-b", "namespace-a"}
+			secret.UID = "test-" + uuid.NewUUID()
+			eventloop_test_util.StartServiceAccountListenerOnFakeClient(ctx, string(managedEnv.UID), k8sClient)
+
+			err := k8sClient.Create(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Create(ctx, &secret)
+			Expect(err).To(BeNil())
+
+			src, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			verifyResult(managedEnv, src)
+
+			By("verifying the cluster credentials are limited to the namespaces")
+			clusterCreds := &db.ClusterCredentials{
+				Clustercredentials_cred_id: src.ManagedEnv.Clustercredentials_id,
+			}
+			err = dbQueries.GetClusterCredentialsById(ctx, clusterCreds)
+			Expect(err).To(BeNil())
+			Expect(clusterCreds.Namespaces).To(Equal("namespace-a,namespace-b"))
+
+			By("verifying a RoleBinding was created in each namespace, and no ClusterRoleBinding was created")
+			for _, ns := range managedEnv.Spec.Namespaces {
+				roleBinding := &rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      sharedutil.ArgoCDManagerRoleBindingNamePrefix + string(managedEnv.UID),
+						Namespace: ns,
+					},
+				}
+				err = k8sClient.Get(ctx, client.ObjectKeyFromObject(roleBinding), roleBinding)
+				Expect(err).To(BeNil())
+			}
+
+			clusterRoleBindingList := rbacv1.ClusterRoleBindingList{}
+			err = k8sClient.List(ctx, &clusterRoleBindingList)
+			Expect(err).To(BeNil())
+			Expect(clusterRoleBindingList.Items).To(BeEmpty())
+
+			By("calling reconcile on an unchanged resource, and verifying the cluster credentials are still valid")
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv.Clustercredentials_id).To(Equal(clusterCreds.Clustercredentials_cred_id))
+
+			By("removing a namespace, but failing to revoke the access to that namespace, and verifying the new cluster credentials are deleted")
+			managedEnv.Spec.Namespaces = []string{"namespace-a"}
+			err = k8sClient.Update(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			var clusterCredsBefore []db.ClusterCredentials
+			err = dbQueries.UnsafeListAllClusterCredentials(ctx, &clusterCredsBefore)
+			Expect(err).To(BeNil())
+
+			failingRoleDeletionFactory := &MockSRLK8sClientFactory{fakeClient: failingRoleDeletionClient{Client: k8sClient}}
+			_, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, failingRoleDeletionFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).ToNot(BeNil())
+
+			var clusterCredsAfter []db.ClusterCredentials
+			err = dbQueries.UnsafeListAllClusterCredentials(ctx, &clusterCredsAfter)
+			Expect(err).To(BeNil())
+			Expect(clusterCredsAfter).To(HaveLen(len(clusterCredsBefore)))
+
+			managedEnvDB := &db.ManagedEnvironment{Managedenvironment_id: src.ManagedEnv.Managedenvironment_id}
+			err = dbQueries.GetManagedEnvironmentById(ctx, managedEnvDB)
+			Expect(err).To(BeNil())
+			Expect(managedEnvDB.Clustercredentials_id).To(Equal(clusterCreds.Clustercredentials_cred_id))
+
+			By("retrying the removal of the namespace, and verifying the access to that namespace is revoked")
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv.Clustercredentials_id).ToNot(Equal(clusterCreds.Clustercredentials_cred_id))
+
+			clusterCreds = &db.ClusterCredentials{
+				Clustercredentials_cred_id: src.ManagedEnv.Clustercredentials_id,
+			}
+			err = dbQueries.GetClusterCredentialsById(ctx, clusterCreds)
+			Expect(err).To(BeNil())
+			Expect(clusterCreds.Namespaces).To(Equal("namespace-a"))
+
+			for ns, shouldExist := range map[string]bool{"namespace-a": true, "namespace-b": false} {
+				roleBinding := &rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      sharedutil.ArgoCDManagerRoleBindingNamePrefix + string(managedEnv.UID),
+						Namespace: ns,
+					},
+				}
+				err = k8sClient.Get(ctx, client.ObjectKeyFromObject(roleBinding), roleBinding)
+				if shouldExist {
+					Expect(err).To(BeNil())
+				} else {
+					Expect(apierr.IsNotFound(err)).To(BeTrue(), "RoleBinding of removed namespace '%s' should be deleted", ns)
+				}
+
+				role := &rbacv1.Role{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      sharedutil.ArgoCDManagerRoleNamePrefix + string(managedEnv.UID),
+						Namespace: ns,
+					},
+				}
+				err = k8sClient.Get(ctx, client.ObjectKeyFromObject(role), role)
+				Expect(apierr.IsNotFound(err)).To(Equal(!shouldExist))
+			}
+
+			By("removing the namespaces, and verifying the cluster credentials are replaced with cluster-scoped credentials")
+			managedEnv.Spec.Namespaces = nil
+			err = k8sClient.Update(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv.Clustercredentials_id).ToNot(Equal(clusterCreds.Clustercredentials_cred_id))
+
+			newClusterCreds := &db.ClusterCredentials{
+				Clustercredentials_cred_id: src.ManagedEnv.Clustercredentials_id,
+			}
+			err = dbQueries.GetClusterCredentialsById(ctx, newClusterCreds)
+			Expect(err).To(BeNil())
+			Expect(newClusterCreds.Namespaces).To(BeEmpty())
+		})
+
+		It("should use the bearer token of the Secret directly, without installing a service account, and replace it when the Secret changes", func() {
+
+			managedEnv, secret := buildManagedEnvironmentForSRL()
+			managedEnv.UID = "test-" + uuid.NewUUID()
+			secret.UID = "test-" + uuid.NewUUID()
+			secret.Data = map[string][]byte{
+				sharedutil.ManagedEnvironmentSecretTokenKey: ([]byte)("my-token"),
+			}
+
+			err := k8sClient.Create(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Create(ctx, &secret)
+			Expect(err).To(BeNil())
+
+			src, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv).ToNot(BeNil())
+
+			clusterCreds := &db.ClusterCredentials{
+				Clustercredentials_cred_id: src.ManagedEnv.Clustercredentials_id,
+			}
+			err = dbQueries.GetClusterCredentialsById(ctx, clusterCreds)
+			Expect(err).To(BeNil())
+			Expect(clusterCreds.Serviceaccount_bearer_token).To(Equal("my-token"))
+			Expect(clusterCreds.Serviceaccount_ns).To(BeEmpty())
+
+			By("verifying that no service account was installed")
+			saList := corev1.ServiceAccountList{}
+			err = k8sClient.List(ctx, &saList)
+			Expect(err).To(BeNil())
+			Expect(saList.Items).To(BeEmpty())
+
+			By("calling reconcile on an unchanged resource, and verifying the cluster credentials are not replaced")
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv.Clustercredentials_id).To(Equal(clusterCreds.Clustercredentials_cred_id))
+
+			By("updating the token of the Secret, and verifying the cluster credentials are replaced")
+			secret.Data[sharedutil.ManagedEnvironmentSecretTokenKey] = ([]byte)("my-new-token")
+			err = k8sClient.Update(ctx, &secret)
+			Expect(err).To(BeNil())
+
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv.Clustercredentials_id).ToNot(Equal(clusterCreds.Clustercredentials_cred_id))
+
+			newClusterCreds := &db.ClusterCredentials{
+				Clustercredentials_cred_id: src.ManagedEnv.Clustercredentials_id,
+			}
+			err = dbQueries.GetClusterCredentialsById(ctx, newClusterCreds)
+			Expect(err).To(BeNil())
+			Expect(newClusterCreds.Serviceaccount_bearer_token).To(Equal("my-new-token"))
+		})
+
+		It("should rotate the service account token of the managed environment, create an operation, and then revoke the old token", func() {
+
+			tokenExpiry := metav1.NewTime(time.Now().Add(ServiceAccountTokenExpiration).Truncate(time.Second))
+
+			fakeClientset := kubefake.NewSimpleClientset()
+			fakeClientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				tokenRequest := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+				tokenRequest.Status = authenticationv1.TokenRequestStatus{
+					Token:               "my-rotated-token",
+					ExpirationTimestamp: tokenExpiry,
+				}
+				return true, tokenRequest, nil
+			})
+			mockFactory.fakeClientset = fakeClientset
+
+			managedEnv, secret := buildManagedEnvironmentForSRL()
+			managedEnv.UID = "test-" + uuid.NewUUID()
+			secret.UID = "test-" + uuid.NewUUID()
+			eventloop_test_util.StartServiceAccountListenerOnFakeClient(ctx, string(managedEnv.UID), k8sClient)
+
+			err := k8sClient.Create(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Create(ctx, &secret)
+			Expect(err).To(BeNil())
+
+			By("calling managed environment for the first time, and verifying a long-lived token is initially used")
+
+			src, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			verifyResult(managedEnv, src)
+
+			oldClusterCreds := &db.ClusterCredentials{
+				Clustercredentials_cred_id: src.ManagedEnv.Clustercredentials_id,
+			}
+			err = dbQueries.GetClusterCredentialsById(ctx, oldClusterCreds)
+			Expect(err).To(BeNil())
+			Expect(oldClusterCreds.Serviceaccount_bearer_token_expiry.IsZero()).To(BeTrue())
+
+			Expect(len(getAllOperationsForResourceID(ctx, src.ManagedEnv.Managedenvironment_id, dbQueries))).To(Equal(0))
+
+			serviceAccountHasTokenSecret := func() bool {
+				secretList := corev1.SecretList{}
+				err := k8sClient.List(ctx, &secretList, client.InNamespace("kube-system"))
+				Expect(err).To(BeNil())
+				for _, tokenSecret := range secretList.Items {
+					if tokenSecret.Annotations[corev1.ServiceAccountNameKey] == sharedutil.GenerateServiceAccountName(string(managedEnv.UID)) {
+						return true
+					}
+				}
+				return false
+			}
+			Expect(serviceAccountHasTokenSecret()).To(BeTrue())
+
+			By("calling reconcile again, and verifying the token is rotated")
+
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			verifyResult(managedEnv, src)
+
+			err = dbQueries.GetClusterCredentialsById(ctx, oldClusterCreds)
+			Expect(db.IsResultNotFoundError(err)).To(BeTrue())
+
+			newClusterCreds := &db.ClusterCredentials{
+				Clustercredentials_cred_id: src.ManagedEnv.Clustercredentials_id,
+			}
+			err = dbQueries.GetClusterCredentialsById(ctx, newClusterCreds)
+			Expect(err).To(BeNil())
+			Expect(newClusterCreds.Serviceaccount_bearer_token).To(Equal("my-rotated-token"))
+			Expect(newClusterCreds.Serviceaccount_bearer_token_expiry.Equal(tokenExpiry.Time)).To(BeTrue())
+			Expect(newClusterCreds.Serviceaccount_ns).To(Equal(oldClusterCreds.Serviceaccount_ns))
+			Expect(newClusterCreds.Host).To(Equal(oldClusterCreds.Host))
+
+			By("verifying that an operation was created, so that the Argo CD cluster secret is updated")
+			managedEnvOperations := getAllOperationsForResourceID(ctx, src.ManagedEnv.Managedenvironment_id, dbQueries)
+			Expect(len(managedEnvOperations)).To(Equal(1))
+			Expect(managedEnvOperations[0].Resource_type).To(Equal(db.OperationResourceType_ManagedEnvironment))
+			Expect(managedEnvOperations[0].GC_expiration_time).To(Equal(serviceAccountTokenRotationOperationGCExpirationTime))
+			err = verifyOperationCRsExist(ctx, managedEnvOperations, k8sClient)
+			Expect(err).To(BeNil())
+
+			By("verifying that the long-lived token secret of the service account is not deleted until the operation has completed")
+			Consistently(serviceAccountHasTokenSecret, "1s", "100ms").Should(BeTrue())
+
+			By("simulating the cluster-agent, which completes the operation that updates the Argo CD cluster secret")
+			managedEnvOperations[0].State = db.OperationState_Completed
+			err = dbQueries.UpdateOperation(ctx, &managedEnvOperations[0])
+			Expect(err).To(BeNil())
+
+			Eventually(serviceAccountHasTokenSecret, "30s", "100ms").Should(BeFalse())
+
+			By("calling reconcile again, and verifying the token is not rotated again, as it has not yet reached half its lifetime")
+
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv.Clustercredentials_id).To(Equal(newClusterCreds.Clustercredentials_cred_id))
+			Expect(len(getAllOperationsForResourceID(ctx, src.ManagedEnv.Managedenvironment_id, dbQueries))).To(Equal(1))
+		})
+
 		It("should test the case where APICRMapping exists, but the managed env doesnt", func() {
 			managedEnv, secret := buildManagedEnvironmentForSRL()
 			managedEnv.UID = "test-" + uuid.NewUUID()
@@ -246,7 +611,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			Expect(err).To(BeNil())
 
 			src, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 			Expect(src.ManagedEnv).ToNot(BeNil())
 
@@ -274,7 +639,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 			By("first calling reconcile to create database entries for new managed env")
 			firstSrc, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 			Expect(firstSrc.ManagedEnv).ToNot(BeNil())
 
@@ -292,7 +657,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 				realFakeClient: k8sClient,
 			}
 			src, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 			Expect(src.ManagedEnv).ToNot(BeNil())
 			Expect(mockFactory.count).To(Equal(1))
@@ -324,7 +689,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 			By("first calling reconcile to create database entries for new managed env")
 			createRC, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 			Expect(createRC.ManagedEnv).ToNot(BeNil())
 
@@ -360,7 +725,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 			By("calling reconcile, after deleting the CR, to ensure the database entries are reconciled")
 			deleteRC, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 			Expect(deleteRC.ManagedEnv).To(BeNil())
 
@@ -411,7 +776,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 			By("calling reconcile on the managed env, which is missing a secret")
 			createRC, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).ToNot(BeNil())
 			Expect(createRC.ManagedEnv).To(BeNil())
 
@@ -435,7 +800,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 			By("first calling reconcile to create database entries for new managed env")
 			createRC, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).To(BeNil())
 			Expect(createRC.ManagedEnv).ToNot(BeNil())
 
@@ -444,12 +809,395 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 			By("call reconcile again, but without the cluster secret existing")
 			createRC, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
-				false, *namespace, mockFactory, dbQueries, log)
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
 			Expect(err).ToNot(BeNil())
 			Expect(createRC.ManagedEnv).To(BeNil())
 
 		})
 
+		It("should clean up the service account of a managed environment that is being deleted, then remove its finalizer and database entries", func() {
+
+			managedEnv, secret := buildManagedEnvironmentForSRL()
+			managedEnv.UID = "test-" + uuid.NewUUID()
+			secret.UID = "test-" + uuid.NewUUID()
+			eventloop_test_util.StartServiceAccountListenerOnFakeClient(ctx, string(managedEnv.UID), k8sClient)
+
+			err := k8sClient.Create(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			err = k8sClient.Create(ctx, &secret)
+			Expect(err).To(BeNil())
+
+			By("first calling reconcile to create database entries and the service account for new managed env")
+			src, err := internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, mockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv).ToNot(BeNil())
+
+			managedEnvRow := &db.ManagedEnvironment{
+				Managedenvironment_id: src.ManagedEnv.Managedenvironment_id,
+			}
+
+			serviceAccount := &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      sharedutil.GenerateServiceAccountName(string(managedEnv.UID)),
+					Namespace: serviceAccountNamespaceKubeSystem,
+				},
+			}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(serviceAccount), serviceAccount)
+			Expect(err).To(BeNil())
+
+			By("adding the finalizer to the managed environment, since we installed a service account")
+			addManagedEnvironmentFinalizer(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace, *src.ManagedEnv, dbQueries, log)
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&managedEnv), &managedEnv)
+			Expect(err).To(BeNil())
+			Expect(managedEnv.Finalizers).To(ContainElement(managedgitopsv1alpha1.ManagedEnvironmentServiceAccountCleanupFinalizer))
+
+			By("deleting the managed environment, and simulating a failure to clean up its service account")
+			err = k8sClient.Delete(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			mockCtrl := gomock.NewController(GinkgoT())
+			defer mockCtrl.Finish()
+			mockClient := mocks.NewMockClient(mockCtrl)
+			mockClient.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("fake unable to delete")).AnyTimes()
+			mockClient.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("fake unable to list")).AnyTimes()
+
+			failingMockFactory := &SimulateFailingClientMockSRLK8sClientFactory{
+				failingClient:  mockClient,
+				realFakeClient: k8sClient,
+			}
+
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, failingMockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).ToNot(BeNil())
+			Expect(src.ManagedEnv).To(BeNil())
+
+			By("verifying the failure is reported in the status, and the managed environment still exists")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&managedEnv), &managedEnv)
+			Expect(err).To(BeNil())
+			Expect(managedEnv.Finalizers).To(ContainElement(managedgitopsv1alpha1.ManagedEnvironmentServiceAccountCleanupFinalizer))
+
+			condition := meta.FindStatusCondition(managedEnv.Status.Conditions,
+				managedgitopsv1alpha1.ManagedEnvironmentConditionServiceAccountCleanupSucceeded)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(managedgitopsv1alpha1.ManagedEnvironmentReasonUnableToCleanUpServiceAccount))
+			Expect(condition.Message).To(ContainSubstring("fake unable to delete"))
+
+			By("calling reconcile again, which should succeed in cleaning up the service account")
+			src, err = internalProcessMessage_ReconcileSharedManagedEnv(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				false, *namespace, failingMockFactory, taskRetryLoop, dbQueries, log)
+			Expect(err).To(BeNil())
+			Expect(src.ManagedEnv).To(BeNil())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(serviceAccount), serviceAccount)
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			clusterRole := &rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: sharedutil.ArgoCDManagerClusterRoleNamePrefix + string(managedEnv.UID),
+				},
+			}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterRole), clusterRole)
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			By("verifying the managed environment and its database entries have been deleted")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&managedEnv), &managedEnv)
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			err = dbQueries.GetManagedEnvironmentById(ctx, managedEnvRow)
+			Expect(db.IsResultNotFoundError(err)).To(BeTrue())
+		})
+
+	})
+
+	Context("updateManagedEnvironmentConnectionStatus test", func() {
+
+		It("should set the ConnectionInitializationSucceeded condition based on the result of reconciling the managed environment", func() {
+
+			ctx := context.Background()
+			log := logf.FromContext(ctx)
+
+			scheme, argocdNamespace, kubesystemNamespace, namespace, err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+
+			k8sClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(namespace, argocdNamespace, kubesystemNamespace).
+				Build()
+
+			managedEnv, _ := buildManagedEnvironmentForSRL()
+			err = k8sClient.Create(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+
+			By("reporting a failure to connect")
+			updateManagedEnvironmentConnectionStatus(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace,
+				fmt.Errorf("unable to connect"), log)
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&managedEnv), &managedEnv)
+			Expect(err).To(BeNil())
+
+			condition := meta.FindStatusCondition(managedEnv.Status.Conditions,
+				managedgitopsv1alpha1.ManagedEnvironmentConditionConnectionInitializationSucceeded)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(managedgitopsv1alpha1.ManagedEnvironmentReasonUnableToInitializeConnection))
+			Expect(condition.Message).To(Equal("unable to connect"))
+
+			By("reporting a failure to retrieve the Secret, and a database error, with their own reasons")
+			for reason, reconcileErr := range map[string]error{
+				managedgitopsv1alpha1.ManagedEnvironmentReasonUnableToRetrieveSecret: newManagedEnvironmentConnectionError(
+					managedgitopsv1alpha1.ManagedEnvironmentReasonUnableToRetrieveSecret, fmt.Errorf("secret not found")),
+				managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError: fmt.Errorf("unable to create managed environment: %w",
+					newManagedEnvironmentConnectionError(managedgitopsv1alpha1.ManagedEnvironmentReasonDatabaseError, fmt.Errorf("db down"))),
+			} {
+				updateManagedEnvironmentConnectionStatus(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace, reconcileErr, log)
+
+				err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&managedEnv), &managedEnv)
+				Expect(err).To(BeNil())
+
+				condition = meta.FindStatusCondition(managedEnv.Status.Conditions,
+					managedgitopsv1alpha1.ManagedEnvironmentConditionConnectionInitializationSucceeded)
+				Expect(condition).ToNot(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Reason).To(Equal(reason))
+				Expect(condition.Message).To(Equal(reconcileErr.Error()))
+			}
+
+			By("reporting a successful connection")
+			updateManagedEnvironmentConnectionStatus(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace, nil, log)
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&managedEnv), &managedEnv)
+			Expect(err).To(BeNil())
+
+			condition = meta.FindStatusCondition(managedEnv.Status.Conditions,
+				managedgitopsv1alpha1.ManagedEnvironmentConditionConnectionInitializationSucceeded)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(managedgitopsv1alpha1.ManagedEnvironmentReasonSucceeded))
+
+			By("ensuring no error occurs if the managed environment no longer exists")
+			err = k8sClient.Delete(ctx, &managedEnv)
+			Expect(err).To(BeNil())
+			updateManagedEnvironmentConnectionStatus(ctx, k8sClient, managedEnv.Name, managedEnv.Namespace, nil, log)
+		})
+	})
+
+	Context("TLS and namespace configuration of managed environments test", func() {
+
+		var managedEnv managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment
+		var kubeConfig *clientcmdapi.Config
+		var contextName string
+
+		BeforeEach(func() {
+			var secret corev1.Secret
+			managedEnv, secret = buildManagedEnvironmentForSRL()
+
+			var err error
+			kubeConfig, contextName, err = loadKubeConfigFromManagedEnvironmentSecret(managedEnv, secret)
+			Expect(err).To(BeNil())
+			Expect(contextName).To(Equal("default/api-fake-unit-test-data-origin-ci-int-gce-dev-rhcloud-com:6443/kube:admin"))
+		})
+
+		It("should return an empty CA bundle if neither the managed environment nor the kubeconfig specify one", func() {
+			Expect(getManagedEnvironmentCABundle(managedEnv, kubeConfig, contextName)).To(BeEmpty())
+		})
+
+		It("should return the certificate authority data of the matching kubeconfig cluster, if the managed environment does not specify a CA bundle", func() {
+			kubeConfig.Clusters["api-fake-unit-test-data-origin-ci-int-gce-dev-rhcloud-com:6443"].CertificateAuthorityData = []byte("kubeconfig-ca")
+			kubeConfig.Clusters["api2-fake-unit-test-data-origin-ci-int-gce-dev-rhcloud-com:6443"].CertificateAuthorityData = []byte("other-ca")
+
+			Expect(getManagedEnvironmentCABundle(managedEnv, kubeConfig, contextName)).To(Equal("kubeconfig-ca"))
+		})
+
+		It("should prefer the CA bundle of the managed environment over that of the kubeconfig", func() {
+			kubeConfig.Clusters["api-fake-unit-test-data-origin-ci-int-gce-dev-rhcloud-com:6443"].CertificateAuthorityData = []byte("kubeconfig-ca")
+			managedEnv.Spec.CABundle = "managed-env-ca"
+
+			Expect(getManagedEnvironmentCABundle(managedEnv, kubeConfig, contextName)).To(Equal("managed-env-ca"))
+		})
+
+		It("should return the sorted, de-duplicated, namespaces of the managed environment", func() {
+			Expect(getManagedEnvironmentNamespaces(managedEnv)).To(BeEmpty())
+
+			managedEnv.Spec.Namespaces = []string{"namespace-b", "namespace-a", "", "namespace-b"}
+			Expect(getManagedEnvironmentNamespaces(managedEnv)).To(Equal([]string{"namespace-a", "namespace-b"}))
+		})
+
+		It("should only set the CA data of the REST config when TLS verification is enabled", func() {
+			restConfig := &rest.Config{}
+			restConfig.CAFile = "/path/from/kubeconfig"
+			restConfig.Insecure = true
+
+			configureTLSClientConfig(restConfig, "my-ca", false)
+			Expect(restConfig.Insecure).To(BeFalse())
+			Expect(restConfig.CAFile).To(BeEmpty())
+			Expect(restConfig.CAData).To(Equal([]byte("my-ca")))
+
+			configureTLSClientConfig(restConfig, "my-ca", true)
+			Expect(restConfig.Insecure).To(BeTrue())
+			Expect(restConfig.CAData).To(BeNil())
+		})
+	})
+
+	Context("isServiceAccountTokenRotationRequired test", func() {
+
+		It("should only rotate the tokens of service accounts, once less than half of their lifetime remains", func() {
+
+			clusterCreds := db.ClusterCredentials{
+				Serviceaccount_bearer_token: "my-token",
+				Serviceaccount_ns:           "kube-system",
+			}
+
+			By("rotating a token that does not expire")
+			Expect(isServiceAccountTokenRotationRequired(clusterCreds)).To(BeTrue())
+
+			By("not rotating a token that was recently requested")
+			clusterCreds.Serviceaccount_bearer_token_expiry = time.Now().Add(ServiceAccountTokenExpiration)
+			Expect(isServiceAccountTokenRotationRequired(clusterCreds)).To(BeFalse())
+
+			By("rotating a token that has less than half of its lifetime remaining")
+			clusterCreds.Serviceaccount_bearer_token_expiry = time.Now().Add(ServiceAccountTokenExpiration/2 - time.Minute)
+			Expect(isServiceAccountTokenRotationRequired(clusterCreds)).To(BeTrue())
+
+			By("not rotating credentials that were provided directly by the user")
+			clusterCreds.Serviceaccount_ns = ""
+			clusterCreds.Serviceaccount_bearer_token_expiry = time.Time{}
+			Expect(isServiceAccountTokenRotationRequired(clusterCreds)).To(BeFalse())
+		})
+	})
+
+	Context("getManagedEnvironmentSecretCredentials test", func() {
+
+		var managedEnv managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment
+		var tokenSecret corev1.Secret
+
+		BeforeEach(func() {
+			managedEnv, tokenSecret = buildManagedEnvironmentForSRL()
+			tokenSecret.Data = map[string][]byte{
+				sharedutil.ManagedEnvironmentSecretTokenKey:  ([]byte)("my-token"),
+				sharedutil.ManagedEnvironmentSecretCACertKey: ([]byte)("my-ca"),
+			}
+		})
+
+		It("should install a service account by default, if the Secret contains a kubeconfig", func() {
+			_, kubeConfigSecret := buildManagedEnvironmentForSRL()
+
+			secretCredentials, err := getManagedEnvironmentSecretCredentials(managedEnv, kubeConfigSecret)
+			Expect(err).To(BeNil())
+			Expect(secretCredentials.createServiceAccount).To(BeTrue())
+			Expect(secretCredentials.restConfig.Host).To(Equal(managedEnv.Spec.APIURL))
+			Expect(secretCredentials.restConfig.BearerToken).To(Equal("sha256~ABCdEF1gHiJKlMnoP-Q19qrTuv1_W9X2YZABCDefGH4"))
+
+			By("disabling service account installation, and verifying the kubeconfig credentials are used directly")
+			createNewServiceAccount := false
+			managedEnv.Spec.CreateNewServiceAccount = &createNewServiceAccount
+
+			secretCredentials, err = getManagedEnvironmentSecretCredentials(managedEnv, kubeConfigSecret)
+			Expect(err).To(BeNil())
+			Expect(secretCredentials.createServiceAccount).To(BeFalse())
+		})
+
+		It("should only disable TLS verification if it is enabled by the managed environment, or by the cluster of the kubeconfig", func() {
+			_, kubeConfigSecret := buildManagedEnvironmentForSRL()
+
+			By("using a kubeconfig whose cluster has 'insecure-skip-tls-verify' set")
+			secretCredentials, err := getManagedEnvironmentSecretCredentials(managedEnv, kubeConfigSecret)
+			Expect(err).To(BeNil())
+			Expect(secretCredentials.allowInsecureSkipTLSVerify).To(BeTrue())
+			Expect(secretCredentials.restConfig.Insecure).To(BeTrue())
+
+			By("using a Secret that does not contain a kubeconfig")
+			secretCredentials, err = getManagedEnvironmentSecretCredentials(managedEnv, tokenSecret)
+			Expect(err).To(BeNil())
+			Expect(secretCredentials.allowInsecureSkipTLSVerify).To(BeFalse())
+			Expect(secretCredentials.restConfig.Insecure).To(BeFalse())
+
+			By("disabling TLS verification in the managed environment")
+			managedEnv.Spec.AllowInsecureSkipTLSVerify = true
+			secretCredentials, err = getManagedEnvironmentSecretCredentials(managedEnv, tokenSecret)
+			Expect(err).To(BeNil())
+			Expect(secretCredentials.allowInsecureSkipTLSVerify).To(BeTrue())
+			Expect(secretCredentials.restConfig.Insecure).To(BeTrue())
+			Expect(secretCredentials.restConfig.CAData).To(BeEmpty())
+		})
+
+		It("should use the bearer token and CA of the Secret directly, by default, if the Secret does not contain a kubeconfig", func() {
+			secretCredentials, err := getManagedEnvironmentSecretCredentials(managedEnv, tokenSecret)
+			Expect(err).To(BeNil())
+			Expect(secretCredentials.createServiceAccount).To(BeFalse())
+			Expect(secretCredentials.restConfig.Host).To(Equal(managedEnv.Spec.APIURL))
+			Expect(secretCredentials.restConfig.BearerToken).To(Equal("my-token"))
+			Expect(secretCredentials.caBundle).To(Equal("my-ca"))
+			Expect(secretCredentials.restConfig.CAData).To(Equal([]byte("my-ca")))
+
+			By("installing a service account, if requested")
+			createNewServiceAccount := true
+			managedEnv.Spec.CreateNewServiceAccount = &createNewServiceAccount
+
+			secretCredentials, err = getManagedEnvironmentSecretCredentials(managedEnv, tokenSecret)
+			Expect(err).To(BeNil())
+			Expect(secretCredentials.createServiceAccount).To(BeTrue())
+		})
+
+		It("should use the client certificate and key of the Secret", func() {
+			tokenSecret.Data = map[string][]byte{
+				sharedutil.ManagedEnvironmentSecretClientCertKey: ([]byte)("my-cert"),
+				sharedutil.ManagedEnvironmentSecretClientKeyKey:  ([]byte)("my-key"),
+			}
+
+			secretCredentials, err := getManagedEnvironmentSecretCredentials(managedEnv, tokenSecret)
+			Expect(err).To(BeNil())
+			Expect(secretCredentials.restConfig.BearerToken).To(BeEmpty())
+			Expect(secretCredentials.restConfig.CertData).To(Equal([]byte("my-cert")))
+			Expect(secretCredentials.restConfig.KeyData).To(Equal([]byte("my-key")))
+			Expect(secretCredentials.caBundle).To(BeEmpty())
+		})
+
+		It("should return an error if the Secret does not contain valid credentials", func() {
+			By("specifying a Secret with no credentials")
+			tokenSecret.Data = map[string][]byte{}
+			_, err := getManagedEnvironmentSecretCredentials(managedEnv, tokenSecret)
+			Expect(err).ToNot(BeNil())
+
+			By("specifying a Secret with a client certificate, but no key")
+			tokenSecret.Data = map[string][]byte{
+				sharedutil.ManagedEnvironmentSecretClientCertKey: ([]byte)("my-cert"),
+			}
+			_, err = getManagedEnvironmentSecretCredentials(managedEnv, tokenSecret)
+			Expect(err).ToNot(BeNil())
+
+			By("specifying a Secret of the wrong type")
+			tokenSecret.Data = map[string][]byte{
+				sharedutil.ManagedEnvironmentSecretTokenKey: ([]byte)("my-token"),
+			}
+			tokenSecret.Type = corev1.SecretTypeOpaque
+			_, err = getManagedEnvironmentSecretCredentials(managedEnv, tokenSecret)
+			Expect(err).ToNot(BeNil())
+		})
+
+		It("should only match cluster credentials that are consistent with the managed environment and Secret", func() {
+			secretCredentials, err := getManagedEnvironmentSecretCredentials(managedEnv, tokenSecret)
+			Expect(err).To(BeNil())
+
+			clusterCreds := db.ClusterCredentials{
+				Host:                        managedEnv.Spec.APIURL,
+				Serviceaccount_bearer_token: "my-token",
+				Ca_bundle:                   "my-ca",
+			}
+			Expect(clusterCredentialsMatchManagedEnvironment(clusterCreds, managedEnv, secretCredentials)).To(BeTrue())
+
+			By("changing the token of the Secret")
+			secretCredentials.restConfig.BearerToken = "my-new-token"
+			Expect(clusterCredentialsMatchManagedEnvironment(clusterCreds, managedEnv, secretCredentials)).To(BeFalse())
+
+			By("using the credentials of an installed service account, whose token does not need to match that of the Secret")
+			secretCredentials.createServiceAccount = true
+			clusterCreds.Serviceaccount_ns = "kube-system"
+			Expect(clusterCredentialsMatchManagedEnvironment(clusterCreds, managedEnv, secretCredentials)).To(BeTrue())
+		})
 	})
 })
 
@@ -546,16 +1294,38 @@ func getAllOperationsForResourceID(ctx context.Context, resourceID string, dbQue
 
 type MockSRLK8sClientFactory struct {
 	fakeClient client.Client
+
+	// fakeClientset is optional: if nil, an error is returned when a clientset is requested
+	fakeClientset kubernetes.Interface
 }
 
 func (f MockSRLK8sClientFactory) BuildK8sClient(restConfig *rest.Config) (client.Client, error) {
 	return f.fakeClient, nil
 }
 
+func (f MockSRLK8sClientFactory) BuildK8sClientset(restConfig *rest.Config) (kubernetes.Interface, error) {
+	if f.fakeClientset == nil {
+		return nil, fmt.Errorf("a clientset was not provided to the mock")
+	}
+	return f.fakeClientset, nil
+}
+
 func (f MockSRLK8sClientFactory) GetK8sClientForGitOpsEngineInstance(gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error) {
 	return f.fakeClient, nil
 }
 
+// failingRoleDeletionClient delegates to the wrapped client, but fails to delete Roles
+type failingRoleDeletionClient struct {
+	client.Client
+}
+
+func (c failingRoleDeletionClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if _, isRole := obj.(*rbacv1.Role); isRole {
+		return fmt.Errorf("simulated failure to delete Role")
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
 type SimulateFailingClientMockSRLK8sClientFactory struct {
 	count          int
 	failingClient  client.Client
@@ -571,6 +1341,10 @@ func (f *SimulateFailingClientMockSRLK8sClientFactory) BuildK8sClient(restConfig
 	return f.realFakeClient, nil
 }
 
+func (f *SimulateFailingClientMockSRLK8sClientFactory) BuildK8sClientset(restConfig *rest.Config) (kubernetes.Interface, error) {
+	return nil, fmt.Errorf("a clientset is not supported by this mock")
+}
+
 func (f *SimulateFailingClientMockSRLK8sClientFactory) GetK8sClientForGitOpsEngineInstance(gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error) {
 	return f.realFakeClient, nil
 }
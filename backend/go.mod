@@ -20,7 +20,7 @@ require (
 	k8s.io/apimachinery v0.23.0
 	k8s.io/client-go v0.23.0
 	sigs.k8s.io/controller-runtime v0.11.0
-
+	sigs.k8s.io/yaml v1.3.0
 )
 
 require (
@@ -92,7 +92,6 @@ require (
 	mellium.im/sasl v0.2.1 // indirect
 	sigs.k8s.io/json v0.0.0-20211020170558-c049b76a60c6 // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.2.0 // indirect
-	sigs.k8s.io/yaml v1.3.0 // indirect
 )
 
 replace github.com/redhat-appstudio/managed-gitops/backend-shared => ../backend-shared
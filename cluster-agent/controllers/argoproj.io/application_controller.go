@@ -17,16 +17,16 @@ limitations under the License.
 package argoprojio
 
 import (
-	"bytes"
-	"compress/gzip"
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 
 	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
 	"github.com/go-logr/logr"
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/config/db"
 	cache "github.com/redhat-appstudio/managed-gitops/backend-shared/config/db/util"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
@@ -36,6 +36,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	sigsyaml "sigs.k8s.io/yaml"
 )
 
 // ApplicationReconciler reconciles a Application object
@@ -108,7 +109,8 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	applicationState := &db.ApplicationState{
 		Applicationstate_application_id: applicationDB.Application_id,
 	}
-	if _, _, errGet := r.Cache.GetApplicationStateById(ctx, applicationState.Applicationstate_application_id); errGet != nil {
+	existingApplicationState, _, errGet := r.Cache.GetApplicationStateById(ctx, applicationState.Applicationstate_application_id)
+	if errGet != nil {
 		if db.IsResultNotFoundError(errGet) {
 
 			// 3a) ApplicationState doesn't exist: so create it
@@ -119,11 +121,9 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			applicationState.Revision = db.TruncateVarchar(app.Status.Sync.Revision, db.ApplicationStateRevisionLength)
 			sanitizeHealthAndStatus(applicationState)
 
-			// Get the list of resources created by deployment and convert it into a compressed YAML string.
-			var err error
-			applicationState.Resources, err = compressResourceData(app.Status.Resources)
-			if err != nil {
-				log.Error(err, "unable to compress resource data into byte array.")
+			// Get the list of resources created by deployment, and the operation state/history, and convert them into compressed YAML strings.
+			if err := setApplicationStateStatusFields(applicationState, nil, app); err != nil {
+				log.Error(err, "unable to compress application status data into byte array.")
 				return ctrl.Result{}, err
 			}
 
@@ -147,11 +147,10 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	applicationState.Revision = db.TruncateVarchar(app.Status.Sync.Revision, db.ApplicationStateRevisionLength)
 	sanitizeHealthAndStatus(applicationState)
 
-	// Get the list of resources created by deployment and convert it into a compressed YAML string.
-	var err error
-	applicationState.Resources, err = compressResourceData(app.Status.Resources)
-	if err != nil {
-		log.Error(err, "unable to compress resource data into byte array.")
+	// Get the list of resources created by deployment, and the operation state/history, and convert them into compressed YAML strings.
+	// - The previous history is passed, so that entries (and who initiated them) are retained across reconciles.
+	if err := setApplicationStateStatusFields(applicationState, existingApplicationState.History, app); err != nil {
+		log.Error(err, "unable to compress application status data into byte array.")
 		return ctrl.Result{}, err
 	}
 
@@ -164,6 +163,40 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 }
 
+// setApplicationStateStatusFields sets the fields of the ApplicationState that are derived from the status of the Argo CD
+// Application, other than health and sync status: the resources, the operation state, the history, and the reconciled at time.
+// - previousHistory is the (compressed) history that was previously stored in the ApplicationState, if any.
+func setApplicationStateStatusFields(applicationState *db.ApplicationState, previousHistory []byte, app appv1.Application) error {
+	var err error
+
+	applicationState.Resources, err = compressResourceData(app.Status.Resources)
+	if err != nil {
+		return err
+	}
+
+	applicationState.Operation_state, err = compressOperationState(app.Status.OperationState)
+	if err != nil {
+		return err
+	}
+
+	history, err := mergeApplicationHistory(previousHistory, app)
+	if err != nil {
+		return err
+	}
+
+	applicationState.History, err = compressHistory(history)
+	if err != nil {
+		return err
+	}
+
+	applicationState.Reconciled_at = time.Time{}
+	if app.Status.ReconciledAt != nil {
+		applicationState.Reconciled_at = app.Status.ReconciledAt.Time
+	}
+
+	return nil
+}
+
 func sanitizeHealthAndStatus(applicationState *db.ApplicationState) {
 
 	if applicationState.Health == "" {
@@ -204,7 +237,6 @@ func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 // Convert ResourceStatus Array into String and then compress it into Byte Array​
 func compressResourceData(resources []appv1.ResourceStatus) ([]byte, error) {
 	var byteArr []byte
-	var buffer bytes.Buffer
 
 	// Convert ResourceStatus object into String.
 	resourceStr, err := yaml.Marshal(&resources)
@@ -212,21 +244,110 @@ func compressResourceData(resources []appv1.ResourceStatus) ([]byte, error) {
 		return byteArr, fmt.Errorf("unable to Marshal resource data. %v", err)
 	}
 
-	// Compress string data
-	gzipWriter, err := gzip.NewWriterLevel(&buffer, gzip.BestSpeed)
+	return controllers.CompressData(resourceStr)
+}
+
+// compressOperationState converts the OperationState of an Argo CD Application into YAML, and then compresses it into
+// a byte array. Returns nil if the Application has no OperationState (no operation has been performed yet).
+func compressOperationState(operationState *appv1.OperationState) ([]byte, error) {
+	if operationState == nil {
+		return nil, nil
+	}
+
+	// The sigs.k8s.io/yaml package is used here (rather than gopkg.in/yaml.v2), as it respects the JSON field names and
+	// marshaling behaviour of the API types (for example, of metav1.Time).
+	operationStateStr, err := sigsyaml.Marshal(operationState)
 	if err != nil {
-		return byteArr, fmt.Errorf("unable to create Buffer writer. %v", err)
+		return nil, fmt.Errorf("unable to Marshal operation state. %v", err)
 	}
 
-	_, err = gzipWriter.Write([]byte(string(resourceStr)))
+	return controllers.CompressData(operationStateStr)
+}
 
-	if err != nil {
-		return byteArr, fmt.Errorf("unable to compress resource string. %v", err)
+// applicationHistoryLimit is the maximum number of sync history entries that are retained for each Application.
+const applicationHistoryLimit = 10
+
+// mergeApplicationHistory merges the sync history of an Argo CD Application into the history that was previously
+// recorded in the ApplicationState, and returns the result, ordered by ID, and bounded to the most recent
+// 'applicationHistoryLimit' entries.
+//
+// Argo CD does not record who initiated each sync in the history, only in the operation state of the most recent
+// operation. So, when a new entry is seen, we record the initiator of the current operation with it (if the entry
+// corresponds to that operation), and then retain that information in later reconciles.
+func mergeApplicationHistory(previousHistory []byte, app appv1.Application) ([]managedgitopsv1alpha1.RevisionHistory, error) {
+
+	var previous []managedgitopsv1alpha1.RevisionHistory
+	if len(previousHistory) > 0 {
+		historyStr, err := controllers.DecompressData(previousHistory)
+		if err != nil {
+			return nil, err
+		}
+		if err := sigsyaml.Unmarshal(historyStr, &previous); err != nil {
+			return nil, fmt.Errorf("unable to Unmarshal previous history. %v", err)
+		}
+	}
+
+	// Convert the Argo CD history into our own type: the JSON field names of both are the same.
+	var current []managedgitopsv1alpha1.RevisionHistory
+	if len(app.Status.History) > 0 {
+		historyStr, err := sigsyaml.Marshal(app.Status.History)
+		if err != nil {
+			return nil, fmt.Errorf("unable to Marshal history. %v", err)
+		}
+		if err := sigsyaml.Unmarshal(historyStr, &current); err != nil {
+			return nil, fmt.Errorf("unable to Unmarshal history. %v", err)
+		}
+	}
+
+	merged := map[int64]managedgitopsv1alpha1.RevisionHistory{}
+	for _, entry := range previous {
+		merged[entry.ID] = entry
+	}
+
+	for _, entry := range current {
+
+		if previousEntry, exists := merged[entry.ID]; exists && previousEntry.DeployedAt.Equal(&entry.DeployedAt) {
+			// We have already seen this entry, so retain the initiator that we recorded at the time.
+			entry.InitiatedBy = previousEntry.InitiatedBy
+
+		} else if operationState := app.Status.OperationState; operationState != nil && entry.DeployStartedAt != nil &&
+			entry.DeployStartedAt.Equal(&operationState.StartedAt) {
+			// This is a new entry, which was created by the current operation.
+			entry.InitiatedBy = &managedgitopsv1alpha1.OperationInitiator{
+				Username:  operationState.Operation.InitiatedBy.Username,
+				Automated: operationState.Operation.InitiatedBy.Automated,
+			}
+		}
+
+		merged[entry.ID] = entry
 	}
 
-	if err := gzipWriter.Close(); err != nil {
-		return byteArr, fmt.Errorf("unable to close gzip writer connection. %v", err)
+	res := make([]managedgitopsv1alpha1.RevisionHistory, 0, len(merged))
+	for _, entry := range merged {
+		res = append(res, entry)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+
+	if len(res) > applicationHistoryLimit {
+		res = res[len(res)-applicationHistoryLimit:]
+	}
+
+	return res, nil
+}
+
+// compressHistory converts the sync history of an Application into YAML, and then compresses it into a byte
+// array. Returns nil if there is no history.
+func compressHistory(history []managedgitopsv1alpha1.RevisionHistory) ([]byte, error) {
+	if len(history) == 0 {
+		return nil, nil
+	}
+
+	historyStr, err := sigsyaml.Marshal(history)
+	if err != nil {
+		return nil, fmt.Errorf("unable to Marshal history. %v", err)
 	}
 
-	return buffer.Bytes(), nil
+	return controllers.CompressData(historyStr)
 }
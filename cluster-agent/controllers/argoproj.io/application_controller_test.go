@@ -1,7 +1,12 @@
 package argoprojio
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"fmt"
+	"io"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -24,6 +29,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	sigsyaml "sigs.k8s.io/yaml"
 )
 
 var _ = Describe("Application Controller", func() {
@@ -457,6 +463,196 @@ var _ = Describe("Application Controller", func() {
 			Expect(byteArr).NotTo(BeEmpty())
 		})
 	})
+
+	Context("Test compressOperationState and compressHistory functions", func() {
+
+		// decompress is the inverse of controllers.CompressData
+		decompress := func(byteArr []byte) []byte {
+			gzipReader, err := gzip.NewReader(bytes.NewBuffer(byteArr))
+			Expect(err).To(BeNil())
+			res, err := io.ReadAll(gzipReader)
+			Expect(err).To(BeNil())
+			return res
+		}
+
+		startedAt := metav1.NewTime(time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC))
+		finishedAt := metav1.NewTime(time.Date(2022, 6, 1, 10, 1, 0, 0, time.UTC))
+
+		It("Should return nil if there is no operation state or history", func() {
+			byteArr, err := compressOperationState(nil)
+			Expect(err).To(BeNil())
+			Expect(byteArr).To(BeNil())
+
+			byteArr, err = compressHistory(nil)
+			Expect(err).To(BeNil())
+			Expect(byteArr).To(BeNil())
+		})
+
+		It("Should compress the operation state, such that it can be read as a GitOpsDeployment OperationState", func() {
+			operationState := &appv1.OperationState{
+				Phase:   "Failed",
+				Message: "one or more synchronization tasks completed unsuccessfully",
+				SyncResult: &appv1.SyncOperationResult{
+					Revision: "abc123",
+					Resources: appv1.ResourceResults{
+						{
+							Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "jane", Name: "component-a",
+							Status: "SyncFailed", Message: "the server could not find the requested resource",
+							SyncPhase: "Sync",
+						},
+						{
+							Group: "batch", Version: "v1", Kind: "Job", Namespace: "jane", Name: "db-migration",
+							HookType: "PreSync", HookPhase: "Succeeded", SyncPhase: "PreSync",
+						},
+					},
+				},
+				StartedAt:  startedAt,
+				FinishedAt: &finishedAt,
+				RetryCount: 2,
+			}
+
+			byteArr, err := compressOperationState(operationState)
+			Expect(err).To(BeNil())
+			Expect(byteArr).NotTo(BeEmpty())
+
+			var res managedgitopsv1alpha1.OperationState
+			err = sigsyaml.Unmarshal(decompress(byteArr), &res)
+			Expect(err).To(BeNil())
+
+			Expect(res.Phase).To(Equal(managedgitopsv1alpha1.OperationPhaseFailed))
+			Expect(res.Message).To(Equal(operationState.Message))
+			Expect(res.StartedAt.Equal(&startedAt)).To(BeTrue())
+			Expect(res.FinishedAt.Equal(&finishedAt)).To(BeTrue())
+			Expect(res.RetryCount).To(Equal(int64(2)))
+			Expect(res.SyncResult.Revision).To(Equal("abc123"))
+			Expect(res.SyncResult.Resources).To(Equal([]managedgitopsv1alpha1.ResourceResult{
+				{
+					Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "jane", Name: "component-a",
+					Status: "SyncFailed", Message: "the server could not find the requested resource",
+					SyncPhase: "Sync",
+				},
+				{
+					Group: "batch", Version: "v1", Kind: "Job", Namespace: "jane", Name: "db-migration",
+					HookType: "PreSync", HookPhase: managedgitopsv1alpha1.OperationPhaseSucceeded, SyncPhase: "PreSync",
+				},
+			}))
+		})
+
+		It("Should compress the history, such that it can be read as a GitOpsDeployment RevisionHistory", func() {
+			history := appv1.RevisionHistories{
+				{
+					ID:              1,
+					Revision:        "abc123",
+					DeployStartedAt: &startedAt,
+					DeployedAt:      finishedAt,
+					Source: appv1.ApplicationSource{
+						RepoURL:        "https://github.com/redhat-appstudio/managed-gitops",
+						Path:           "resources/test-data/sample-gitops-repository/environments/overlays/dev",
+						TargetRevision: "HEAD",
+					},
+				},
+			}
+
+			merged, err := mergeApplicationHistory(nil, appv1.Application{Status: appv1.ApplicationStatus{History: history}})
+			Expect(err).To(BeNil())
+
+			byteArr, err := compressHistory(merged)
+			Expect(err).To(BeNil())
+			Expect(byteArr).NotTo(BeEmpty())
+
+			var res []managedgitopsv1alpha1.RevisionHistory
+			err = sigsyaml.Unmarshal(decompress(byteArr), &res)
+			Expect(err).To(BeNil())
+
+			Expect(res).To(HaveLen(1))
+			Expect(res[0].ID).To(Equal(int64(1)))
+			Expect(res[0].Revision).To(Equal("abc123"))
+			Expect(res[0].DeployStartedAt.Equal(&startedAt)).To(BeTrue())
+			Expect(res[0].DeployedAt.Equal(&finishedAt)).To(BeTrue())
+			Expect(res[0].Source).To(Equal(managedgitopsv1alpha1.ApplicationSource{
+				RepoURL:        history[0].Source.RepoURL,
+				Path:           history[0].Source.Path,
+				TargetRevision: history[0].Source.TargetRevision,
+			}))
+			Expect(res[0].InitiatedBy).To(BeNil())
+		})
+
+		It("Should record the initiator of new history entries, and retain it in later reconciles", func() {
+			app := appv1.Application{
+				Status: appv1.ApplicationStatus{
+					History: appv1.RevisionHistories{
+						{ID: 0, Revision: "abc123", DeployStartedAt: &startedAt, DeployedAt: finishedAt},
+					},
+					OperationState: &appv1.OperationState{
+						Operation: appv1.Operation{
+							InitiatedBy: appv1.OperationInitiator{Username: "jane"},
+						},
+						StartedAt: startedAt,
+					},
+				},
+			}
+
+			By("merging the history with no previous history")
+			merged, err := mergeApplicationHistory(nil, app)
+			Expect(err).To(BeNil())
+			Expect(merged).To(HaveLen(1))
+			Expect(merged[0].InitiatedBy).To(Equal(&managedgitopsv1alpha1.OperationInitiator{Username: "jane"}))
+
+			previousHistory, err := compressHistory(merged)
+			Expect(err).To(BeNil())
+
+			By("merging the history after a new automated sync has occurred")
+			secondStartedAt := metav1.NewTime(startedAt.Add(time.Hour))
+			secondFinishedAt := metav1.NewTime(finishedAt.Add(time.Hour))
+
+			app.Status.History = append(app.Status.History, appv1.RevisionHistory{
+				ID: 1, Revision: "def456", DeployStartedAt: &secondStartedAt, DeployedAt: secondFinishedAt,
+			})
+			app.Status.OperationState = &appv1.OperationState{
+				Operation: appv1.Operation{
+					InitiatedBy: appv1.OperationInitiator{Automated: true},
+				},
+				StartedAt: secondStartedAt,
+			}
+
+			merged, err = mergeApplicationHistory(previousHistory, app)
+			Expect(err).To(BeNil())
+			Expect(merged).To(HaveLen(2))
+			Expect(merged[0].Revision).To(Equal("abc123"))
+			Expect(merged[0].InitiatedBy).To(Equal(&managedgitopsv1alpha1.OperationInitiator{Username: "jane"}))
+			Expect(merged[1].Revision).To(Equal("def456"))
+			Expect(merged[1].InitiatedBy).To(Equal(&managedgitopsv1alpha1.OperationInitiator{Automated: true}))
+		})
+
+		It("Should retain entries that are no longer in the Application history, up to the limit", func() {
+			var previous []managedgitopsv1alpha1.RevisionHistory
+			for i := 0; i < applicationHistoryLimit; i++ {
+				previous = append(previous, managedgitopsv1alpha1.RevisionHistory{
+					ID:         int64(i),
+					Revision:   fmt.Sprintf("revision-%d", i),
+					DeployedAt: metav1.NewTime(finishedAt.Add(time.Duration(i) * time.Minute)),
+				})
+			}
+			previousHistory, err := compressHistory(previous)
+			Expect(err).To(BeNil())
+
+			// The Application only contains the most recent entry
+			newEntryDeployedAt := metav1.NewTime(finishedAt.Add(time.Hour))
+			app := appv1.Application{
+				Status: appv1.ApplicationStatus{
+					History: appv1.RevisionHistories{
+						{ID: int64(applicationHistoryLimit), Revision: "latest", DeployedAt: newEntryDeployedAt},
+					},
+				},
+			}
+
+			merged, err := mergeApplicationHistory(previousHistory, app)
+			Expect(err).To(BeNil())
+			Expect(merged).To(HaveLen(applicationHistoryLimit))
+			Expect(merged[0].ID).To(Equal(int64(1)))
+			Expect(merged[applicationHistoryLimit-1].Revision).To(Equal("latest"))
+		})
+	})
 })
 
 var _ = Describe("Namespace Reconciler Tests.", func() {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 
 	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
@@ -148,7 +149,20 @@ func runNamespaceReconcile(ctx context.Context, dbQueries db.DatabaseQueries, cl
 			}
 
 			// At this point we have the applications from ArgoCD and DB, now compare them to check if they are not in Sync.
-			if compareApplications(applicationFromArgoCD, applicationFromDB, log) {
+			// - Multi-source Applications, and Applications with kustomize patches, cannot be compared using the Argo CD
+			//   API types of the cluster-agent (which predate those fields), so they are compared as unstructured objects.
+			var isUpdateNeeded bool
+			if controllers.IsUnstructuredApplicationSpec(applicationFromDB.Spec) {
+				isUpdateNeeded, err = compareUnstructuredApplication(ctx, namespacedName, applicationFromDB, client, log)
+				if err != nil {
+					log.Error(err, "Error occurred in Namespace Reconciler while comparing application: "+applicationRowFromDB.Application_id)
+					continue
+				}
+			} else {
+				isUpdateNeeded = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			}
+
+			if isUpdateNeeded {
 				log.Info("Argo application is not in Sync with DB, updating Argo CD App. Application:" + applicationRowFromDB.Application_id)
 			} else {
 				log.V(sharedutil.LogLevel_Debug).Info("Argo application is in Sync with DB, Application:" + applicationRowFromDB.Application_id)
@@ -188,6 +202,35 @@ func runNamespaceReconcile(ctx context.Context, dbQueries db.DatabaseQueries, cl
 		"Next iteration will be triggered after %v Minutes", time.Now().String(), namespaceReconcilerInterval))
 }
 
+// compareUnstructuredApplication compares the Argo CD Application with the given name, retrieved as an unstructured
+// object, with the DB entry. It returns true if the Application needs to be updated.
+func compareUnstructuredApplication(ctx context.Context, namespacedName types.NamespacedName, applicationFromDB fauxargocd.FauxApplication,
+	k8sClient client.Client, log logr.Logger) (bool, error) {
+
+	applicationFromArgoCD := &unstructured.Unstructured{}
+	applicationFromArgoCD.SetGroupVersionKind(appv1.ApplicationSchemaGroupVersionKind)
+	if err := k8sClient.Get(ctx, namespacedName, applicationFromArgoCD); err != nil {
+		return false, fmt.Errorf("unable to retrieve Argo CD Application '%s': %v", namespacedName.Name, err)
+	}
+
+	specFromArgoCD, _, err := unstructured.NestedMap(applicationFromArgoCD.Object, "spec")
+	if err != nil {
+		return false, fmt.Errorf("unable to retrieve spec of Argo CD Application '%s': %v", namespacedName.Name, err)
+	}
+
+	specFromDB, err := controllers.ConvertFauxApplicationSpecToUnstructured(applicationFromDB.Spec)
+	if err != nil {
+		return false, err
+	}
+
+	diff := controllers.DiffUnstructuredApplicationSpec(specFromArgoCD, specFromDB)
+	for _, field := range diff {
+		log.Info(field + " field in ArgoCD and DB entry is not in Sync.")
+	}
+
+	return len(diff) > 0, nil
+}
+
 // compareApplications compares Application objects, since both objects are of different types we can not use == operator for comparison.
 func compareApplications(applicationFromArgoCD appv1.Application, applicationFromDB fauxargocd.FauxApplication, log logr.Logger) bool {
 
@@ -240,6 +283,25 @@ func compareApplications(applicationFromArgoCD appv1.Application, applicationFro
 		isTargetRevisionUpdateNeeded = true
 	}
 
+	var isChartUpdateNeeded bool
+	if applicationFromArgoCD.Spec.Source.Chart != applicationFromDB.Spec.Source.Chart {
+		log.Info("Chart field in ArgoCD and DB entry is not in Sync.")
+		log.Info("Chart:= ArgoCD: " + applicationFromArgoCD.Spec.Source.Chart + "; DB: " + applicationFromDB.Spec.Source.Chart)
+		isChartUpdateNeeded = true
+	}
+
+	var isHelmUpdateNeeded bool
+	if !compareHelmSources(applicationFromArgoCD.Spec.Source.Helm, applicationFromDB.Spec.Source.Helm) {
+		log.Info("Helm field in ArgoCD and DB entry is not in Sync.")
+		isHelmUpdateNeeded = true
+	}
+
+	var isKustomizeUpdateNeeded bool
+	if !compareKustomizeSources(applicationFromArgoCD.Spec.Source.Kustomize, applicationFromDB.Spec.Source.Kustomize) {
+		log.Info("Kustomize field in ArgoCD and DB entry is not in Sync.")
+		isKustomizeUpdateNeeded = true
+	}
+
 	var isDestinationServerUpdateNeeded bool
 	if applicationFromArgoCD.Spec.Destination.Server != applicationFromDB.Spec.Destination.Server {
 		log.Info("Destination.Server field in ArgoCD and DB entry is not in Sync.")
@@ -268,33 +330,235 @@ func compareApplications(applicationFromArgoCD appv1.Application, applicationFro
 		isProjectUpdateNeeded = true
 	}
 
+	syncPolicyFromArgoCD := applicationFromArgoCD.Spec.SyncPolicy
+	if syncPolicyFromArgoCD == nil {
+		syncPolicyFromArgoCD = &appv1.SyncPolicy{}
+	}
+	syncPolicyFromDB := applicationFromDB.Spec.SyncPolicy
+	if syncPolicyFromDB == nil {
+		syncPolicyFromDB = &fauxargocd.SyncPolicy{}
+	}
+
+	var isAutomatedUpdateNeeded bool
+	if (syncPolicyFromArgoCD.Automated == nil) != (syncPolicyFromDB.Automated == nil) {
+		log.Info("Automated field in ArgoCD and DB entry is not in Sync.")
+		isAutomatedUpdateNeeded = true
+	}
+
+	automatedFromArgoCD := syncPolicyFromArgoCD.Automated
+	if automatedFromArgoCD == nil {
+		automatedFromArgoCD = &appv1.SyncPolicyAutomated{}
+	}
+	automatedFromDB := syncPolicyFromDB.Automated
+	if automatedFromDB == nil {
+		automatedFromDB = &fauxargocd.SyncPolicyAutomated{}
+	}
+
 	var isAutomatedPruneUpdateNeeded bool
-	if applicationFromArgoCD.Spec.SyncPolicy.Automated.Prune != applicationFromDB.Spec.SyncPolicy.Automated.Prune {
+	if automatedFromArgoCD.Prune != automatedFromDB.Prune {
 		log.Info("Prune field in ArgoCD and DB entry is not in Sync.")
 		isAutomatedPruneUpdateNeeded = true
 	}
 
 	var isAutomatedSelfHealUpdateNeeded bool
-	if applicationFromArgoCD.Spec.SyncPolicy.Automated.SelfHeal != applicationFromDB.Spec.SyncPolicy.Automated.SelfHeal {
+	if automatedFromArgoCD.SelfHeal != automatedFromDB.SelfHeal {
 		log.Info("SelfHeal field in ArgoCD and DB entry is not in Sync.")
 		isAutomatedSelfHealUpdateNeeded = true
 	}
 
 	var isAutomatedAllowEmptyUpdateNeeded bool
-	if applicationFromArgoCD.Spec.SyncPolicy.Automated.AllowEmpty != applicationFromDB.Spec.SyncPolicy.Automated.AllowEmpty {
+	if automatedFromArgoCD.AllowEmpty != automatedFromDB.AllowEmpty {
 		log.Info("AllowEmpty field in ArgoCD and DB entry is not in Sync.")
 		isAutomatedAllowEmptyUpdateNeeded = true
 	}
 
+	var isSyncOptionsUpdateNeeded bool
+	if !compareStringSlices(syncPolicyFromArgoCD.SyncOptions, syncPolicyFromDB.SyncOptions) {
+		log.Info("SyncOptions field in ArgoCD and DB entry is not in Sync.")
+		log.Info(fmt.Sprintf("SyncOptions:= ArgoCD: %v; DB: %v", syncPolicyFromArgoCD.SyncOptions, syncPolicyFromDB.SyncOptions))
+		isSyncOptionsUpdateNeeded = true
+	}
+
+	var isRetryUpdateNeeded bool
+	if !compareRetryStrategies(syncPolicyFromArgoCD.Retry, syncPolicyFromDB.Retry) {
+		log.Info("Retry field in ArgoCD and DB entry is not in Sync.")
+		isRetryUpdateNeeded = true
+	}
+
+	var isIgnoreDifferencesUpdateNeeded bool
+	if !compareIgnoreDifferences(applicationFromArgoCD.Spec.IgnoreDifferences, applicationFromDB.Spec.IgnoreDifferences) {
+		log.Info("IgnoreDifferences field in ArgoCD and DB entry is not in Sync.")
+		isIgnoreDifferencesUpdateNeeded = true
+	}
+
 	// If any of the above steps have been performed, then we need to update the application.
 	isUpdateNeeded := isAPIVersionUpdateNeeded || isKindUpdateNeeded || isNameUpdateNeeded ||
 		isNamespaceUpdateNeeded || isRepoUrlUpdateNeeded || isPathUpdateNeeded || isTargetRevisionUpdateNeeded ||
+		isChartUpdateNeeded || isHelmUpdateNeeded || isKustomizeUpdateNeeded ||
 		isDestinationServerUpdateNeeded || isDestinationNamespaceUpdateNeeded || isDestinationNameUpdateNeeded ||
-		isProjectUpdateNeeded || isAutomatedPruneUpdateNeeded || isAutomatedSelfHealUpdateNeeded || isAutomatedAllowEmptyUpdateNeeded
+		isProjectUpdateNeeded || isAutomatedUpdateNeeded || isAutomatedPruneUpdateNeeded || isAutomatedSelfHealUpdateNeeded ||
+		isAutomatedAllowEmptyUpdateNeeded || isSyncOptionsUpdateNeeded || isRetryUpdateNeeded || isIgnoreDifferencesUpdateNeeded
 
 	return isUpdateNeeded
 }
 
+// compareHelmSources returns true if the Helm options of the Argo CD Application match those of the DB entry.
+// A nil Helm source is considered equal to an empty one.
+func compareHelmSources(helmFromArgoCD *appv1.ApplicationSourceHelm, helmFromDB *fauxargocd.ApplicationSourceHelm) bool {
+
+	if helmFromArgoCD == nil {
+		helmFromArgoCD = &appv1.ApplicationSourceHelm{}
+	}
+	if helmFromDB == nil {
+		helmFromDB = &fauxargocd.ApplicationSourceHelm{}
+	}
+
+	if helmFromArgoCD.ReleaseName != helmFromDB.ReleaseName || helmFromArgoCD.Values != helmFromDB.Values {
+		return false
+	}
+
+	if !compareStringSlices(helmFromArgoCD.ValueFiles, helmFromDB.ValueFiles) {
+		return false
+	}
+
+	if len(helmFromArgoCD.Parameters) != len(helmFromDB.Parameters) {
+		return false
+	}
+	for i, paramFromArgoCD := range helmFromArgoCD.Parameters {
+		paramFromDB := helmFromDB.Parameters[i]
+		if paramFromArgoCD.Name != paramFromDB.Name || paramFromArgoCD.Value != paramFromDB.Value ||
+			paramFromArgoCD.ForceString != paramFromDB.ForceString {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareKustomizeSources returns true if the kustomize options of the Argo CD Application match those of the DB entry.
+// A nil kustomize source is considered equal to an empty one.
+//
+// Kustomize patches are not compared, as they are not supported by the version of the Argo CD API used by the cluster
+// agent: Applications with kustomize patches are instead compared by compareUnstructuredApplication.
+func compareKustomizeSources(kustomizeFromArgoCD *appv1.ApplicationSourceKustomize, kustomizeFromDB *fauxargocd.ApplicationSourceKustomize) bool {
+
+	if kustomizeFromArgoCD == nil {
+		kustomizeFromArgoCD = &appv1.ApplicationSourceKustomize{}
+	}
+	if kustomizeFromDB == nil {
+		kustomizeFromDB = &fauxargocd.ApplicationSourceKustomize{}
+	}
+
+	if kustomizeFromArgoCD.NamePrefix != kustomizeFromDB.NamePrefix || kustomizeFromArgoCD.NameSuffix != kustomizeFromDB.NameSuffix ||
+		kustomizeFromArgoCD.Version != kustomizeFromDB.Version {
+		return false
+	}
+
+	if len(kustomizeFromArgoCD.Images) != len(kustomizeFromDB.Images) {
+		return false
+	}
+	for i := range kustomizeFromArgoCD.Images {
+		if string(kustomizeFromArgoCD.Images[i]) != string(kustomizeFromDB.Images[i]) {
+			return false
+		}
+	}
+
+	return compareStringMaps(kustomizeFromArgoCD.CommonLabels, kustomizeFromDB.CommonLabels) &&
+		compareStringMaps(kustomizeFromArgoCD.CommonAnnotations, kustomizeFromDB.CommonAnnotations)
+}
+
+// compareRetryStrategies returns true if the retry strategy of the Argo CD Application matches that of the DB entry.
+func compareRetryStrategies(retryFromArgoCD *appv1.RetryStrategy, retryFromDB *fauxargocd.RetryStrategy) bool {
+
+	if retryFromArgoCD == nil || retryFromDB == nil {
+		return retryFromArgoCD == nil && retryFromDB == nil
+	}
+
+	if retryFromArgoCD.Limit != retryFromDB.Limit {
+		return false
+	}
+
+	if retryFromArgoCD.Backoff == nil || retryFromDB.Backoff == nil {
+		return retryFromArgoCD.Backoff == nil && retryFromDB.Backoff == nil
+	}
+
+	backoffFromArgoCD, backoffFromDB := retryFromArgoCD.Backoff, retryFromDB.Backoff
+
+	if backoffFromArgoCD.Duration != backoffFromDB.Duration || backoffFromArgoCD.MaxDuration != backoffFromDB.MaxDuration {
+		return false
+	}
+
+	if backoffFromArgoCD.Factor == nil || backoffFromDB.Factor == nil {
+		return backoffFromArgoCD.Factor == nil && backoffFromDB.Factor == nil
+	}
+
+	return *backoffFromArgoCD.Factor == *backoffFromDB.Factor
+}
+
+// compareIgnoreDifferences returns true if the ignoreDifferences of the Argo CD Application match those of the DB entry.
+func compareIgnoreDifferences(ignoreDifferencesFromArgoCD []appv1.ResourceIgnoreDifferences,
+	ignoreDifferencesFromDB []fauxargocd.ResourceIgnoreDifferences) bool {
+
+	if len(ignoreDifferencesFromArgoCD) != len(ignoreDifferencesFromDB) {
+		return false
+	}
+
+	for i, fromArgoCD := range ignoreDifferencesFromArgoCD {
+		fromDB := ignoreDifferencesFromDB[i]
+
+		if fromArgoCD.Group != fromDB.Group || fromArgoCD.Kind != fromDB.Kind || fromArgoCD.Name != fromDB.Name ||
+			fromArgoCD.Namespace != fromDB.Namespace {
+			return false
+		}
+
+		if !compareStringSlices(fromArgoCD.JSONPointers, fromDB.JSONPointers) ||
+			!compareStringSlices(fromArgoCD.JQPathExpressions, fromDB.JQPathExpressions) {
+			return false
+		}
+
+		// ManagedFieldsManagers are not set by the GitOps Service, so they should not be set on the Argo CD Application.
+		if len(fromArgoCD.ManagedFieldsManagers) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareStringSlices returns true if both slices contain the same values, in the same order. A nil slice is
+// considered equal to an empty one.
+func compareStringSlices(sliceA []string, sliceB []string) bool {
+
+	if len(sliceA) != len(sliceB) {
+		return false
+	}
+
+	for i := range sliceA {
+		if sliceA[i] != sliceB[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareStringMaps returns true if both maps contain the same keys and values. A nil map is considered equal to an
+// empty one.
+func compareStringMaps(mapA map[string]string, mapB map[string]string) bool {
+
+	if len(mapA) != len(mapB) {
+		return false
+	}
+
+	for key, valueA := range mapA {
+		if valueB, exists := mapB[key]; !exists || valueA != valueB {
+			return false
+		}
+	}
+
+	return true
+}
+
 func cleanK8sOperations(ctx context.Context, dbq db.DatabaseQueries, client client.Client, log logr.Logger) {
 	// Get list of Operations from cluster.
 	listOfK8sOperation := v1alpha1.OperationList{}
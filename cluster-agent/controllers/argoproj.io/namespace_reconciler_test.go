@@ -9,8 +9,12 @@ import (
 	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/config/db"
 	dbutil "github.com/redhat-appstudio/managed-gitops/backend-shared/config/db/util"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/operations"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -59,6 +63,56 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 		})
 	})
 
+	Context("Testing for compareUnstructuredApplication function.", func() {
+		It("Should only report multi-source applications as out of sync when their spec differs from the DB entry.", func() {
+
+			ctx := context.Background()
+			log := log.FromContext(ctx)
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+
+			// The Argo CD API types are intentionally not added to the scheme: the fake client would otherwise convert
+			// the Application into those types, which would drop '.spec.sources'.
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace, argocdNamespace, kubesystemNamespace).Build()
+
+			applicationFromDB := fauxargocd.FauxApplication{
+				Spec: fauxargocd.FauxApplicationSpec{
+					Sources: fauxargocd.ApplicationSources{
+						{RepoURL: "https://github.com/example/repo", Path: "resources", TargetRevision: "main"},
+						{RepoURL: "https://github.com/example/values", TargetRevision: "main", Ref: "values"},
+					},
+					Destination: fauxargocd.ApplicationDestination{Name: "in-cluster", Namespace: "my-namespace"},
+					Project:     "default",
+				},
+			}
+
+			spec, err := controllers.ConvertFauxApplicationSpecToUnstructured(applicationFromDB.Spec)
+			Expect(err).To(BeNil())
+
+			applicationFromArgoCD := &unstructured.Unstructured{}
+			applicationFromArgoCD.SetGroupVersionKind(appv1.ApplicationSchemaGroupVersionKind)
+			applicationFromArgoCD.SetName("my-application")
+			applicationFromArgoCD.SetNamespace(argocdNamespace.Name)
+			applicationFromArgoCD.Object["spec"] = spec
+			err = k8sClient.Create(ctx, applicationFromArgoCD)
+			Expect(err).To(BeNil())
+
+			namespacedName := types.NamespacedName{Name: applicationFromArgoCD.GetName(), Namespace: applicationFromArgoCD.GetNamespace()}
+
+			By("verifying that an Application that matches the DB entry is in sync")
+			isUpdateNeeded, err := compareUnstructuredApplication(ctx, namespacedName, applicationFromDB, k8sClient, log)
+			Expect(err).To(BeNil())
+			Expect(isUpdateNeeded).To(BeFalse())
+
+			By("verifying that an Application whose sources differ from the DB entry is out of sync")
+			applicationFromDB.Spec.Sources = applicationFromDB.Spec.Sources[:1]
+			isUpdateNeeded, err = compareUnstructuredApplication(ctx, namespacedName, applicationFromDB, k8sClient, log)
+			Expect(err).To(BeNil())
+			Expect(isUpdateNeeded).To(BeTrue())
+		})
+	})
+
 	Context("Testing for CompareApplications function.", func() {
 		It("Should compare applications.", func() {
 
@@ -142,6 +196,268 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 			Expect(result).To(BeTrue())
 			applicationFromArgoCD.Spec.SyncPolicy.Automated.AllowEmpty = applicationFromDB.Spec.SyncPolicy.Automated.AllowEmpty
 		})
+
+		It("Should compare the sync policy of applications.", func() {
+
+			applicationFromDB, _, applicationFromArgoCD, err := createDummyApplicationData()
+			Expect(err).To(BeNil())
+
+			var ctx context.Context
+			log := log.FromContext(ctx)
+
+			factor := int64(2)
+			applicationFromDB.Spec.SyncPolicy.SyncOptions = fauxargocd.SyncOptions{"CreateNamespace=true"}
+			applicationFromDB.Spec.SyncPolicy.Retry = &fauxargocd.RetryStrategy{
+				Limit:   5,
+				Backoff: &fauxargocd.Backoff{Duration: "5s", Factor: &factor, MaxDuration: "3m"},
+			}
+
+			result := compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+
+			applicationFromArgoCD.Spec.SyncPolicy.SyncOptions = appv1.SyncOptions{"CreateNamespace=true"}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue(), "the retry strategy is still not in sync")
+
+			argoFactor := int64(2)
+			applicationFromArgoCD.Spec.SyncPolicy.Retry = &appv1.RetryStrategy{
+				Limit:   5,
+				Backoff: &appv1.Backoff{Duration: "5s", Factor: &argoFactor, MaxDuration: "3m"},
+			}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeFalse())
+
+			// Set different value in each field then revert them, otherwise next field wont be compared
+			applicationFromArgoCD.Spec.SyncPolicy.SyncOptions = appv1.SyncOptions{"CreateNamespace=true", "PruneLast=true"}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.SyncPolicy.SyncOptions = appv1.SyncOptions{"CreateNamespace=true"}
+
+			applicationFromArgoCD.Spec.SyncPolicy.Retry.Limit = 3
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.SyncPolicy.Retry.Limit = 5
+
+			argoFactor = 3
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			argoFactor = 2
+
+			applicationFromArgoCD.Spec.SyncPolicy.Retry.Backoff.MaxDuration = "1h"
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.SyncPolicy.Retry.Backoff.MaxDuration = "3m"
+
+			By("verifying that an application without an automated sync policy is compared without error")
+			applicationFromArgoCD.Spec.SyncPolicy.Automated = nil
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+
+			applicationFromDB.Spec.SyncPolicy.Automated = nil
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeFalse())
+
+			applicationFromArgoCD.Spec.SyncPolicy = nil
+			applicationFromDB.Spec.SyncPolicy = nil
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeFalse())
+		})
+
+		It("Should update an application which has been automated, if the DB entry is not automated (for example, because the GitOpsDeployment is suspended).", func() {
+
+			applicationFromDB, _, applicationFromArgoCD, err := createDummyApplicationData()
+			Expect(err).To(BeNil())
+
+			var ctx context.Context
+			log := log.FromContext(ctx)
+
+			applicationFromDB.Spec.SyncPolicy = nil
+
+			result := compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+
+			applicationFromArgoCD.Spec.SyncPolicy = nil
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeFalse())
+		})
+
+		It("Should compare the ignoreDifferences of applications.", func() {
+
+			applicationFromDB, _, applicationFromArgoCD, err := createDummyApplicationData()
+			Expect(err).To(BeNil())
+
+			var ctx context.Context
+			log := log.FromContext(ctx)
+
+			applicationFromDB.Spec.IgnoreDifferences = []fauxargocd.ResourceIgnoreDifferences{
+				{Group: "apps", Kind: "Deployment", Name: "component-a", JSONPointers: []string{"/spec/replicas"}},
+				{Kind: "Pod", JQPathExpressions: []string{".spec.containers[] | select(.name == \"istio-proxy\")"}},
+			}
+
+			result := compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+
+			applicationFromArgoCD.Spec.IgnoreDifferences = []appv1.ResourceIgnoreDifferences{
+				{Group: "apps", Kind: "Deployment", Name: "component-a", JSONPointers: []string{"/spec/replicas"}},
+				{Kind: "Pod", JQPathExpressions: []string{".spec.containers[] | select(.name == \"istio-proxy\")"}},
+			}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeFalse())
+
+			// Set different value in each field then revert them, otherwise next field wont be compared
+			applicationFromArgoCD.Spec.IgnoreDifferences[0].Name = "test"
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.IgnoreDifferences[0].Name = "component-a"
+
+			applicationFromArgoCD.Spec.IgnoreDifferences[0].JSONPointers = []string{"/spec/template"}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.IgnoreDifferences[0].JSONPointers = []string{"/spec/replicas"}
+
+			applicationFromArgoCD.Spec.IgnoreDifferences[1].JQPathExpressions = nil
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.IgnoreDifferences[1].JQPathExpressions = applicationFromDB.Spec.IgnoreDifferences[1].JQPathExpressions
+
+			applicationFromArgoCD.Spec.IgnoreDifferences[1].ManagedFieldsManagers = []string{"kube-controller-manager"}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.IgnoreDifferences[1].ManagedFieldsManagers = nil
+
+			applicationFromArgoCD.Spec.IgnoreDifferences = applicationFromArgoCD.Spec.IgnoreDifferences[0:1]
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+		})
+
+		It("Should compare the Helm chart source of applications.", func() {
+
+			applicationFromDB, _, applicationFromArgoCD, err := createDummyApplicationData()
+			Expect(err).To(BeNil())
+
+			var ctx context.Context
+			log := log.FromContext(ctx)
+
+			applicationFromDB.Spec.Source.Chart = "my-chart"
+			applicationFromDB.Spec.Source.Helm = &fauxargocd.ApplicationSourceHelm{
+				ValueFiles: []string{"values-prod.yaml"},
+				Parameters: []fauxargocd.HelmParameter{{Name: "image.tag", Value: "v1"}},
+				Values:     "replicaCount: 2\n",
+			}
+
+			result := compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+
+			applicationFromArgoCD.Spec.Source.Chart = "my-chart"
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue(), "the Helm options are still not in sync")
+
+			applicationFromArgoCD.Spec.Source.Helm = &appv1.ApplicationSourceHelm{
+				ValueFiles: []string{"values-prod.yaml"},
+				Parameters: []appv1.HelmParameter{{Name: "image.tag", Value: "v1"}},
+				Values:     "replicaCount: 2\n",
+			}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeFalse())
+
+			// Set different value in each field then revert them, otherwise next field wont be compared
+			applicationFromArgoCD.Spec.Source.Helm.ValueFiles = []string{"values-staging.yaml"}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Helm.ValueFiles = applicationFromDB.Spec.Source.Helm.ValueFiles
+
+			applicationFromArgoCD.Spec.Source.Helm.Parameters[0].ForceString = true
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Helm.Parameters[0].ForceString = false
+
+			applicationFromArgoCD.Spec.Source.Helm.Values = "replicaCount: 3\n"
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Helm.Values = applicationFromDB.Spec.Source.Helm.Values
+
+			applicationFromArgoCD.Spec.Source.Helm.ReleaseName = "test"
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Helm.ReleaseName = ""
+
+			By("verifying that an empty Helm source is considered equal to no Helm source")
+			applicationFromDB.Spec.Source.Helm = nil
+			applicationFromArgoCD.Spec.Source.Helm = &appv1.ApplicationSourceHelm{}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeFalse())
+		})
+
+		It("Should compare the kustomize options of applications.", func() {
+
+			applicationFromDB, _, applicationFromArgoCD, err := createDummyApplicationData()
+			Expect(err).To(BeNil())
+
+			var ctx context.Context
+			log := log.FromContext(ctx)
+
+			applicationFromDB.Spec.Source.Kustomize = &fauxargocd.ApplicationSourceKustomize{
+				NamePrefix:        "prod-",
+				NameSuffix:        "-v2",
+				Images:            fauxargocd.KustomizeImages{"my-image=quay.io/my-org/my-image:v2"},
+				CommonLabels:      map[string]string{"app": "my-app"},
+				CommonAnnotations: map[string]string{"description": "my app"},
+				Version:           "v4.5.7",
+			}
+
+			result := compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+
+			applicationFromArgoCD.Spec.Source.Kustomize = &appv1.ApplicationSourceKustomize{
+				NamePrefix:        "prod-",
+				NameSuffix:        "-v2",
+				Images:            appv1.KustomizeImages{"my-image=quay.io/my-org/my-image:v2"},
+				CommonLabels:      map[string]string{"app": "my-app"},
+				CommonAnnotations: map[string]string{"description": "my app"},
+				Version:           "v4.5.7",
+			}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeFalse())
+
+			// Set different value in each field then revert them, otherwise next field wont be compared
+			applicationFromArgoCD.Spec.Source.Kustomize.NamePrefix = "test"
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Kustomize.NamePrefix = applicationFromDB.Spec.Source.Kustomize.NamePrefix
+
+			applicationFromArgoCD.Spec.Source.Kustomize.NameSuffix = "test"
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Kustomize.NameSuffix = applicationFromDB.Spec.Source.Kustomize.NameSuffix
+
+			applicationFromArgoCD.Spec.Source.Kustomize.Images = appv1.KustomizeImages{"my-image=quay.io/my-org/my-image:v3"}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Kustomize.Images = appv1.KustomizeImages{"my-image=quay.io/my-org/my-image:v2"}
+
+			applicationFromArgoCD.Spec.Source.Kustomize.CommonLabels = map[string]string{"app": "test"}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Kustomize.CommonLabels = applicationFromDB.Spec.Source.Kustomize.CommonLabels
+
+			applicationFromArgoCD.Spec.Source.Kustomize.CommonAnnotations = map[string]string{"other": "my app"}
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Kustomize.CommonAnnotations = applicationFromDB.Spec.Source.Kustomize.CommonAnnotations
+
+			applicationFromArgoCD.Spec.Source.Kustomize.Version = "test"
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeTrue())
+			applicationFromArgoCD.Spec.Source.Kustomize.Version = applicationFromDB.Spec.Source.Kustomize.Version
+
+			By("verifying that kustomize sources with only patches are considered equal to no kustomize source")
+			applicationFromDB.Spec.Source.Kustomize = &fauxargocd.ApplicationSourceKustomize{
+				Patches: fauxargocd.KustomizePatches{{Patch: "spec:\n  replicas: 3\n"}},
+			}
+			applicationFromArgoCD.Spec.Source.Kustomize = nil
+			result = compareApplications(applicationFromArgoCD, applicationFromDB, log)
+			Expect(result).To(BeFalse())
+		})
 	})
 
 	Context("Testing CleanK8sOperations function", func() {
@@ -0,0 +1,144 @@
+package eventloop
+
+import (
+	"context"
+	"fmt"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/config/db"
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// processOperation_UnstructuredApplication creates or updates an Argo CD Application that has multiple sources, or that
+// has kustomize patches.
+//
+// The Argo CD API types used by the cluster-agent predate multi-source Applications ('.spec.sources') and kustomize
+// patches ('.spec.source.kustomize.patches'), and thus would drop those fields. Instead, these Applications are created
+// and updated as unstructured objects.
+//
+// Returns true if the task should be retried (eg due to failure), false otherwise.
+func processOperation_UnstructuredApplication(ctx context.Context, dbApplication db.Application,
+	specFieldApp fauxargocd.FauxApplication, dbQueries db.DatabaseQueries, argoCDNamespace corev1.Namespace,
+	eventClient client.Client, log logr.Logger) (bool, error) {
+
+	expectedSpec, err := controllers.ConvertFauxApplicationSpecToUnstructured(specFieldApp.Spec)
+	if err != nil {
+		log.Error(err, "SEVERE: unable to convert DB application spec field of Application: "+dbApplication.Name)
+		// We return nil here, with no retry, because there's likely nothing else that can be done to fix this.
+		return false, nil
+	}
+
+	// Before we create/update the application, make sure that the managed environment that the application points to exists
+	if specFieldApp.Spec.Destination.Name != ArgoCDDefaultDestinationInCluster {
+		if err := ensureManagedEnvironmentExists(ctx, dbApplication.Managed_environment_id, dbQueries, argoCDNamespace, eventClient, log); err != nil {
+			log.Error(err, "unable to ensure that managed environment exists")
+			return true, err
+		}
+	}
+
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(appv1.ApplicationSchemaGroupVersionKind)
+	app.SetName(dbApplication.Name)
+	app.SetNamespace(argoCDNamespace.Name)
+
+	if err := eventClient.Get(ctx, client.ObjectKeyFromObject(app), app); err != nil {
+
+		if !apierr.IsNotFound(err) {
+			log.Error(err, "Unexpected error when attempting to retrieve Argo CD Application CR")
+			return true, err
+		}
+
+		// The Application CR doesn't exist, so we need to create it
+		app.SetLabels(map[string]string{controllers.ArgoCDApplicationDatabaseIDLabel: dbApplication.Application_id})
+		app.Object["spec"] = expectedSpec
+
+		if err := eventClient.Create(ctx, app, &client.CreateOptions{}); err != nil {
+			log.Error(err, "unable to create Argo CD Application CR: "+app.GetName())
+			return true, err
+		}
+		sharedutil.LogAPIResourceChangeEvent(app.GetNamespace(), app.GetName(), app, sharedutil.ResourceCreated, log)
+
+		log.Info("Created Argo CD Application CR: " + app.GetName())
+
+		return false, nil
+	}
+
+	// The application CR exists, so check if there is any difference between it and the database entry.
+	existingSpec, _, err := unstructured.NestedMap(app.Object, "spec")
+	if err != nil {
+		log.Error(err, "SEVERE: unable to retrieve spec of Argo CD Application CR: "+app.GetName())
+		return false, nil
+	}
+	if existingSpec == nil {
+		existingSpec = map[string]interface{}{}
+	}
+
+	var specDiff string
+	for _, field := range controllers.DiffUnstructuredApplicationSpec(existingSpec, expectedSpec) {
+		specDiff = fmt.Sprintf("spec.%s fields differ", field)
+
+		if expectedValue, exists := expectedSpec[field]; exists {
+			existingSpec[field] = expectedValue
+		} else {
+			delete(existingSpec, field)
+		}
+	}
+
+	if specDiff == "" {
+		log.Info("no changes detected in application, so no update needed")
+		return false, nil
+	}
+
+	app.Object["spec"] = existingSpec
+
+	if err := eventClient.Update(ctx, app); err != nil {
+		log.Error(err, "unable to update application after difference detected: "+app.GetName())
+		// Retry if we were unable to update the Application, for example due to a conflict
+		return true, err
+	}
+	sharedutil.LogAPIResourceChangeEvent(app.GetNamespace(), app.GetName(), app, sharedutil.ResourceModified, log)
+
+	log.Info("Updated Argo CD Application CR: " + app.GetName() + ", diff was: " + specDiff)
+
+	return false, nil
+}
+
+// requiresUnstructuredApplication returns true if the Argo CD Application must be created/updated as an unstructured
+// object (see processOperation_UnstructuredApplication), which is the case if the spec from the database has multiple
+// sources or kustomize patches (see controllers.IsUnstructuredApplicationSpec).
+//
+// It is also the case if the existing Application has kustomize patches: the Argo CD API types would not see those
+// patches, and thus would not remove them, after they are removed from the database spec.
+func requiresUnstructuredApplication(ctx context.Context, dbApplication db.Application, specFieldApp fauxargocd.FauxApplication,
+	argoCDNamespace corev1.Namespace, eventClient client.Client) (bool, error) {
+
+	if controllers.IsUnstructuredApplicationSpec(specFieldApp.Spec) {
+		return true, nil
+	}
+
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(appv1.ApplicationSchemaGroupVersionKind)
+	app.SetName(dbApplication.Name)
+	app.SetNamespace(argoCDNamespace.Name)
+
+	if err := eventClient.Get(ctx, client.ObjectKeyFromObject(app), app); err != nil {
+		if apierr.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to retrieve Argo CD Application '%s': %v", app.GetName(), err)
+	}
+
+	patches, _, err := unstructured.NestedSlice(app.Object, "spec", "source", "kustomize", "patches")
+	if err != nil {
+		return false, fmt.Errorf("unable to retrieve kustomize patches of Argo CD Application '%s': %v", app.GetName(), err)
+	}
+
+	return len(patches) > 0, nil
+}
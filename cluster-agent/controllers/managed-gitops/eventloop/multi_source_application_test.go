@@ -0,0 +1,200 @@
+package eventloop
+
+import (
+	"context"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/config/db"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("Multi-source Argo CD Application tests", func() {
+
+	Context("Testing processOperation_UnstructuredApplication function", func() {
+
+		var (
+			ctx             context.Context
+			k8sClient       client.Client
+			argoCDNamespace *corev1.Namespace
+			dbApplication   db.Application
+			specFieldApp    fauxargocd.FauxApplication
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+
+			// The Argo CD API types are intentionally not added to the scheme: the fake client would otherwise convert
+			// the Application into those types, which would drop '.spec.sources'.
+
+			argoCDNamespace = argocdNamespace
+
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace, argocdNamespace, kubesystemNamespace).Build()
+
+			dbApplication = db.Application{
+				Application_id: "test-my-application",
+				Name:           "my-application",
+			}
+
+			specFieldApp = fauxargocd.FauxApplication{
+				Spec: fauxargocd.FauxApplicationSpec{
+					Sources: fauxargocd.ApplicationSources{
+						{RepoURL: "https://github.com/example/repo", Path: "resources", TargetRevision: "main"},
+						{RepoURL: "https://github.com/example/values", TargetRevision: "main", Ref: "values"},
+					},
+					Destination: fauxargocd.ApplicationDestination{Name: ArgoCDDefaultDestinationInCluster, Namespace: "my-namespace"},
+					Project:     "default",
+				},
+			}
+		})
+
+		getApplication := func() *unstructured.Unstructured {
+			app := &unstructured.Unstructured{}
+			app.SetGroupVersionKind(appv1.ApplicationSchemaGroupVersionKind)
+			app.SetName(dbApplication.Name)
+			app.SetNamespace(argoCDNamespace.Name)
+
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(app), app)
+			Expect(err).To(BeNil())
+
+			return app
+		}
+
+		It("should create the Application if it doesn't exist, and update it when the sources change", func() {
+
+			By("creating the Application")
+			retry, err := processOperation_UnstructuredApplication(ctx, dbApplication, specFieldApp, nil, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(retry).To(BeFalse())
+
+			app := getApplication()
+			Expect(app.GetLabels()).To(HaveKeyWithValue(controllers.ArgoCDApplicationDatabaseIDLabel, dbApplication.Application_id))
+
+			sources, _, err := unstructured.NestedSlice(app.Object, "spec", "sources")
+			Expect(err).To(BeNil())
+			Expect(sources).To(HaveLen(2))
+
+			_, exists, err := unstructured.NestedMap(app.Object, "spec", "source")
+			Expect(err).To(BeNil())
+			Expect(exists).To(BeFalse())
+
+			By("setting a field that is not managed by the GitOps Service, which should be preserved on update")
+			err = unstructured.SetNestedField(app.Object, int64(5), "spec", "revisionHistoryLimit")
+			Expect(err).To(BeNil())
+			err = k8sClient.Update(ctx, app)
+			Expect(err).To(BeNil())
+
+			By("updating the Application after a source is removed")
+			specFieldApp.Spec.Sources = specFieldApp.Spec.Sources[:1]
+
+			retry, err = processOperation_UnstructuredApplication(ctx, dbApplication, specFieldApp, nil, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(retry).To(BeFalse())
+
+			app = getApplication()
+			sources, _, err = unstructured.NestedSlice(app.Object, "spec", "sources")
+			Expect(err).To(BeNil())
+			Expect(sources).To(HaveLen(1))
+
+			revisionHistoryLimit, exists, err := unstructured.NestedInt64(app.Object, "spec", "revisionHistoryLimit")
+			Expect(err).To(BeNil())
+			Expect(exists).To(BeTrue())
+			Expect(revisionHistoryLimit).To(Equal(int64(5)))
+
+			By("verifying that no update is made when nothing has changed")
+			resourceVersion := app.GetResourceVersion()
+
+			retry, err = processOperation_UnstructuredApplication(ctx, dbApplication, specFieldApp, nil, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(retry).To(BeFalse())
+
+			Expect(getApplication().GetResourceVersion()).To(Equal(resourceVersion))
+		})
+
+		It("should set the kustomize patches of a single-source Application, and remove them once they are removed from the database", func() {
+
+			specFieldApp.Spec.Sources = nil
+			specFieldApp.Spec.Source = fauxargocd.ApplicationSource{
+				RepoURL:        "https://github.com/example/repo",
+				Path:           "components/componentA/overlays/staging",
+				TargetRevision: "main",
+				Kustomize: &fauxargocd.ApplicationSourceKustomize{
+					Patches: fauxargocd.KustomizePatches{
+						{
+							Patch: "- op: replace\n  path: /spec/replicas\n  value: 3\n",
+							Target: &fauxargocd.KustomizeSelector{
+								KustomizeResId: fauxargocd.KustomizeResId{
+									KustomizeGvk: fauxargocd.KustomizeGvk{Group: "apps", Version: "v1", Kind: "Deployment"},
+									Name:         "component-a",
+								},
+							},
+						},
+					},
+				},
+			}
+
+			By("verifying that an Application with kustomize patches is handled as unstructured")
+			requiresUnstructured, err := requiresUnstructuredApplication(ctx, dbApplication, specFieldApp, *argoCDNamespace, k8sClient)
+			Expect(err).To(BeNil())
+			Expect(requiresUnstructured).To(BeTrue())
+
+			By("creating the Application")
+			retry, err := processOperation_UnstructuredApplication(ctx, dbApplication, specFieldApp, nil, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(retry).To(BeFalse())
+
+			app := getApplication()
+
+			patches, exists, err := unstructured.NestedSlice(app.Object, "spec", "source", "kustomize", "patches")
+			Expect(err).To(BeNil())
+			Expect(exists).To(BeTrue())
+			Expect(patches).To(HaveLen(1))
+
+			patch, ok := patches[0].(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			Expect(patch).To(HaveKeyWithValue("patch", specFieldApp.Spec.Source.Kustomize.Patches[0].Patch))
+			Expect(patch).To(HaveKeyWithValue("target", map[string]interface{}{
+				"group":   "apps",
+				"version": "v1",
+				"kind":    "Deployment",
+				"name":    "component-a",
+			}))
+
+			_, exists, err = unstructured.NestedSlice(app.Object, "spec", "sources")
+			Expect(err).To(BeNil())
+			Expect(exists).To(BeFalse())
+
+			By("removing the kustomize patches from the database spec")
+			specFieldApp.Spec.Source.Kustomize = nil
+
+			// The existing Application still has the patches, so it must still be handled as unstructured, to remove them.
+			requiresUnstructured, err = requiresUnstructuredApplication(ctx, dbApplication, specFieldApp, *argoCDNamespace, k8sClient)
+			Expect(err).To(BeNil())
+			Expect(requiresUnstructured).To(BeTrue())
+
+			retry, err = processOperation_UnstructuredApplication(ctx, dbApplication, specFieldApp, nil, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(retry).To(BeFalse())
+
+			_, exists, err = unstructured.NestedMap(getApplication().Object, "spec", "source", "kustomize")
+			Expect(err).To(BeNil())
+			Expect(exists).To(BeFalse())
+
+			requiresUnstructured, err = requiresUnstructuredApplication(ctx, dbApplication, specFieldApp, *argoCDNamespace, k8sClient)
+			Expect(err).To(BeNil())
+			Expect(requiresUnstructured).To(BeFalse())
+		})
+	})
+})
@@ -14,7 +14,10 @@ import (
 	dbutil "github.com/redhat-appstudio/managed-gitops/backend-shared/config/db/util"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	argosharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/argocd"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
 	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/utils"
+	goyaml "gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -52,7 +55,10 @@ func NewOperationEventLoop() *OperationEventLoop {
 	res := &OperationEventLoop{}
 	res.eventLoopInputChannel = channel
 
-	go operationEventLoopRouter(channel)
+	// The credential service is used to log in to Argo CD, for operations that require the Argo CD API (for example, sync).
+	credentialService := utils.NewCredentialService(nil, false)
+
+	go operationEventLoopRouter(channel, credentialService)
 
 	return res
 
@@ -69,7 +75,7 @@ func (evl *OperationEventLoop) EventReceived(req ctrl.Request, client client.Cli
 	evl.eventLoopInputChannel <- event
 }
 
-func operationEventLoopRouter(input chan operationEventLoopEvent) {
+func operationEventLoopRouter(input chan operationEventLoopEvent, credentialService *utils.CredentialService) {
 
 	ctx := context.Background()
 
@@ -90,7 +96,8 @@ func operationEventLoopRouter(input chan operationEventLoopEvent) {
 				request: newEvent.request,
 				client:  newEvent.client,
 			},
-			log: log,
+			credentialService: credentialService,
+			log:               log,
 		}
 		taskRetryLoop.AddTaskIfNotPresent(mapKey, task, sharedutil.ExponentialBackoff{Factor: 2, Min: time.Millisecond * 200, Max: time.Second * 10, Jitter: true})
 
@@ -100,8 +107,9 @@ func operationEventLoopRouter(input chan operationEventLoopEvent) {
 
 // processOperationEventTask takes as input an Operation resource event, and processes it based on the contents of that event.
 type processOperationEventTask struct {
-	event operationEventLoopEvent
-	log   logr.Logger
+	event             operationEventLoopEvent
+	credentialService *utils.CredentialService
+	log               logr.Logger
 }
 
 // PerformTask takes as input an Operation resource event, and processes it based on the contents of that event.
@@ -311,6 +319,16 @@ func (task *processOperationEventTask) internalPerformTask(taskContext context.C
 
 		return &dbOperation, shouldRetry, err
 
+	} else if dbOperation.Resource_type == db.OperationResourceType_SyncOperation {
+		shouldRetry, err := processOperation_SyncOperation(taskContext, dbOperation, *operationCR, dbQueries, *argoCDNamespace,
+			task.credentialService, eventClient, log)
+
+		if err != nil {
+			log.Error(err, "error occurred on processing the sync operation")
+		}
+
+		return &dbOperation, shouldRetry, err
+
 	} else {
 		log.Error(nil, "SEVERE: unrecognized resource type: "+dbOperation.Resource_type)
 		return &dbOperation, false, nil
@@ -323,10 +341,11 @@ func (task *processOperationEventTask) internalPerformTask(taskContext context.C
 func processOperation_ManagedEnvironment(ctx context.Context, dbOperation db.Operation, crOperation operation.Operation,
 	dbQueries db.DatabaseQueries, argoCDNamespace corev1.Namespace, eventClient client.Client, log logr.Logger) (bool, error) {
 
-	// The only operation we currently support for managed environment is deletion (creation is handled by Application operations).
-	// Thus, we expect the ManagedEnvironment database entry here to not be found.
+	// If the ManagedEnvironment database entry exists, the Argo CD cluster secret of the managed environment is
+	// created/updated to match it (for example, after the cluster credentials of the managed environment were rotated).
+	// If the database entry doesn't exist, the managed environment was deleted, and so the cluster secret is deleted.
 
-	// 1) Make sure the managed environment db entry DOESN'T exist (see above)
+	// 1) If the managed env db entry exists, ensure the cluster secret is up to date with it (see above)
 	{
 		managedEnv := &db.ManagedEnvironment{
 			Managedenvironment_id: dbOperation.Resource_id, // managed env id referencing managed env row
@@ -336,8 +355,10 @@ func processOperation_ManagedEnvironment(ctx context.Context, dbOperation db.Ope
 				return true, fmt.Errorf("an unexpected error occcurred on retrieving managed env: %v", err)
 			}
 		} else {
-			// The database entry still exists, so return an error
-			return false, fmt.Errorf("managed environment still exists in the database")
+			if err := ensureManagedEnvironmentExists(ctx, managedEnv.Managedenvironment_id, dbQueries, argoCDNamespace, eventClient, log); err != nil {
+				return true, fmt.Errorf("unable to ensure that managed environment exists: %v", err)
+			}
+			return false, nil
 		}
 	}
 
@@ -451,6 +472,28 @@ func processOperation_Application(ctx context.Context, dbOperation db.Operation,
 		}
 	}
 
+	// Multi-source Applications, and Applications with kustomize patches, are handled separately: see
+	// processOperation_UnstructuredApplication for details.
+	{
+		specFieldApp := fauxargocd.FauxApplication{}
+		if err := goyaml.Unmarshal([]byte(dbApplication.Spec_field), &specFieldApp); err != nil {
+			log.Error(err, "SEVERE: unable to unmarshal DB application spec field: "+dbApplication.Name)
+			// We return nil here, with no retry, because there's likely nothing else that can be done to fix this.
+			return false, nil
+		}
+
+		requiresUnstructured, err := requiresUnstructuredApplication(ctx, *dbApplication, specFieldApp, argoCDNamespace, eventClient)
+		if err != nil {
+			log.Error(err, "unable to determine whether the Argo CD Application should be handled as unstructured")
+			return true, err
+		}
+
+		if requiresUnstructured {
+			return processOperation_UnstructuredApplication(ctx, *dbApplication, specFieldApp, dbQueries, argoCDNamespace,
+				eventClient, log)
+		}
+	}
+
 	app := &appv1.Application{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      dbApplication.Name,
@@ -478,7 +521,7 @@ func processOperation_Application(ctx context.Context, dbOperation db.Operation,
 
 			// Before we create the application, make sure that the managed environment exists that the application points to
 			if app.Spec.Destination.Name != ArgoCDDefaultDestinationInCluster {
-				if err := ensureManagedEnvironmentExists(ctx, *dbApplication, dbQueries, argoCDNamespace, eventClient, log); err != nil {
+				if err := ensureManagedEnvironmentExists(ctx, dbApplication.Managed_environment_id, dbQueries, argoCDNamespace, eventClient, log); err != nil {
 					log.Error(err, "unable to ensure that managed environment exists")
 					return true, err
 				}
@@ -527,6 +570,8 @@ func processOperation_Application(ctx context.Context, dbOperation db.Operation,
 		specDiff = "spec project fields differ"
 	} else if !reflect.DeepEqual(specFieldApp.Spec.SyncPolicy, app.Spec.SyncPolicy) {
 		specDiff = "sync policy fields differ"
+	} else if !reflect.DeepEqual(specFieldApp.Spec.IgnoreDifferences, app.Spec.IgnoreDifferences) {
+		specDiff = "ignore differences fields differ"
 	}
 
 	if specDiff != "" {
@@ -534,6 +579,7 @@ func processOperation_Application(ctx context.Context, dbOperation db.Operation,
 		app.Spec.Source = specFieldApp.Spec.Source
 		app.Spec.Project = specFieldApp.Spec.Project
 		app.Spec.SyncPolicy = specFieldApp.Spec.SyncPolicy
+		app.Spec.IgnoreDifferences = specFieldApp.Spec.IgnoreDifferences
 		if err := eventClient.Update(ctx, app); err != nil {
 			log.Error(err, "unable to update application after difference detected: "+app.Name)
 			// Retry if we were unable to update the Application, for example due to a conflict
@@ -549,7 +595,7 @@ func processOperation_Application(ctx context.Context, dbOperation db.Operation,
 
 	// Finally, ensure that the managed-environment secret is still up to date
 	if app.Spec.Destination.Name != ArgoCDDefaultDestinationInCluster {
-		if err := ensureManagedEnvironmentExists(ctx, *dbApplication, dbQueries, argoCDNamespace, eventClient, log); err != nil {
+		if err := ensureManagedEnvironmentExists(ctx, dbApplication.Managed_environment_id, dbQueries, argoCDNamespace, eventClient, log); err != nil {
 			log.Error(err, "unable to ensure that managed environment exists")
 			return true, err
 		}
@@ -558,24 +604,25 @@ func processOperation_Application(ctx context.Context, dbOperation db.Operation,
 	return false, nil
 }
 
-// ensureManagedEnvironmentExists ensures that the managed environment described by 'application' is defined as an Argo CD
-// cluster secret, in the Argo CD namespace.
-func ensureManagedEnvironmentExists(ctx context.Context, application db.Application, dbQueries db.DatabaseQueries,
+// ensureManagedEnvironmentExists ensures that the managed environment with the given ID is defined as an Argo CD
+// cluster secret, in the Argo CD namespace, and that the secret is up to date with the cluster credentials of the
+// managed environment.
+func ensureManagedEnvironmentExists(ctx context.Context, managedEnvironmentID string, dbQueries db.DatabaseQueries,
 	argoCDNamespace corev1.Namespace, eventClient client.Client, log logr.Logger) error {
 
-	if application.Managed_environment_id == "" {
+	if managedEnvironmentID == "" {
 		// No work to do
 		return nil
 	}
 
-	expectedSecret, shouldDeleteSecret, err := generateExpectedClusterSecret(ctx, application, dbQueries, argoCDNamespace, eventClient, log)
+	expectedSecret, shouldDeleteSecret, err := generateExpectedClusterSecret(ctx, managedEnvironmentID, dbQueries, argoCDNamespace, eventClient, log)
 	if err != nil {
 		return fmt.Errorf("unable to generate expected cluster secret: %v", err)
 	}
 
 	// If we detected that the managed environment row was deleted, ensure the secret is deleted.
 	if shouldDeleteSecret {
-		secretName := argosharedutil.GenerateArgoCDClusterSecretName(db.ManagedEnvironment{Managedenvironment_id: application.Managed_environment_id})
+		secretName := argosharedutil.GenerateArgoCDClusterSecretName(db.ManagedEnvironment{Managedenvironment_id: managedEnvironmentID})
 		secret := &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      secretName,
@@ -638,12 +685,12 @@ func ensureManagedEnvironmentExists(ctx context.Context, application db.Applicat
 
 }
 
-// generateExpectedClusterSecret generates (but does apply) an Argo CD cluster secret for the environment of the application.
+// generateExpectedClusterSecret generates (but does apply) an Argo CD cluster secret for the managed environment with the given ID.
 // returns:
 // - argo cd cluster secret based on managed environment
 // - bool: true if secret should be deleted false otherwise
 // - error
-func generateExpectedClusterSecret(ctx context.Context, application db.Application, dbQueries db.DatabaseQueries,
+func generateExpectedClusterSecret(ctx context.Context, managedEnvironmentID string, dbQueries db.DatabaseQueries,
 	argoCDNamespace corev1.Namespace, eventClient client.Client, log logr.Logger) (corev1.Secret, bool, error) {
 
 	const (
@@ -652,7 +699,7 @@ func generateExpectedClusterSecret(ctx context.Context, application db.Applicati
 	)
 
 	managedEnv := &db.ManagedEnvironment{
-		Managedenvironment_id: application.Managed_environment_id,
+		Managedenvironment_id: managedEnvironmentID,
 	}
 
 	if err := dbQueries.GetManagedEnvironmentById(ctx, managedEnv); err != nil {
@@ -688,10 +735,23 @@ func generateExpectedClusterSecret(ctx context.Context, application db.Applicati
 	clusterSecretConfigJSON := ClusterSecretConfigJSON{
 		BearerToken: bearerToken,
 		TLSClientConfig: ClusterSecretTLSClientConfigJSON{
-			Insecure: true, // TODO: GITOPSRVCE-178: Once TLS certification validation configuration is implmented, this value should be updated.
+			Insecure: clusterCredentials.Allow_insecure_skip_tls_verify,
 		},
 	}
 
+	// The client certificate/key are only set if they were provided directly by the user (rather than the bearer token
+	// of a ServiceAccount installed by the GitOps Service).
+	if clusterCredentials.Client_cert_data != "" {
+		clusterSecretConfigJSON.TLSClientConfig.CertData = []byte(clusterCredentials.Client_cert_data)
+		clusterSecretConfigJSON.TLSClientConfig.KeyData = []byte(clusterCredentials.Client_key_data)
+	}
+
+	// Argo CD does not allow a root CA to be specified alongside the insecure flag. If no CA is specified, the system
+	// roots are used to verify the TLS certificate of the cluster.
+	if !clusterCredentials.Allow_insecure_skip_tls_verify && clusterCredentials.Ca_bundle != "" {
+		clusterSecretConfigJSON.TLSClientConfig.CAData = []byte(clusterCredentials.Ca_bundle)
+	}
+
 	jsonString, err := json.Marshal(clusterSecretConfigJSON)
 	if err != nil {
 		return corev1.Secret{}, deleteSecret_false, fmt.Errorf("SEVERE: unable to marshal JSON")
@@ -713,15 +773,25 @@ func generateExpectedClusterSecret(ctx context.Context, application db.Applicati
 		},
 	}
 
+	// If the managed environment is namespace-scoped, Argo CD should only manage resources within those namespaces.
+	if clusterCredentials.Namespaces != "" {
+		managedEnvironmentSecret.Data["namespaces"] = ([]byte)(clusterCredentials.Namespaces)
+	}
+
 	return managedEnvironmentSecret, deleteSecret_false, nil
 
 }
 
 type ClusterSecretConfigJSON struct {
-	BearerToken     string                           `json:"bearerToken"`
+	BearerToken     string                           `json:"bearerToken,omitempty"`
 	TLSClientConfig ClusterSecretTLSClientConfigJSON `json:"tlsClientConfig"`
 }
 
 type ClusterSecretTLSClientConfigJSON struct {
 	Insecure bool `json:"insecure"`
+	// CAData is the PEM-encoded CA bundle used to verify the TLS certificate of the cluster (base64-encoded, in JSON)
+	CAData []byte `json:"caData,omitempty"`
+	// CertData and KeyData are the PEM-encoded client certificate and key used to authenticate to the cluster
+	CertData []byte `json:"certData,omitempty"`
+	KeyData  []byte `json:"keyData,omitempty"`
 }
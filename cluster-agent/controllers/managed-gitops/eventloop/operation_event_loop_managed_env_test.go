@@ -93,10 +93,13 @@ var _ = Describe("Managed Environment Operation Tests", func() {
 
 		})
 
-		It("reconciles an operation that points to a managed environment that still exists, to ensure no action is taken in this case", func() {
+		It("reconciles an operation that points to a managed environment that still exists, to ensure the corresponding Argo CD cluster secret is updated", func() {
 
 			clusterCredentials := db.ClusterCredentials{
-				Clustercredentials_cred_id: string(uuid.NewUUID()),
+				Clustercredentials_cred_id:  string(uuid.NewUUID()),
+				Host:                        "https://api.fake-unit-test-data.origin-ci-int-gce.dev.rhcloud.com:6443",
+				Serviceaccount_bearer_token: "my-rotated-token",
+				Serviceaccount_ns:           "kube-system",
 			}
 
 			err = dbQueries.CreateClusterCredentials(ctx, &clusterCredentials)
@@ -137,7 +140,7 @@ var _ = Describe("Managed Environment Operation Tests", func() {
 			err = task.event.client.Create(ctx, operationCR)
 			Expect(err).To(BeNil())
 
-			By("creating an Argo CD Cluster secret, which we will will test to make sure it has not been deleted.")
+			By("creating an out-of-date Argo CD Cluster secret, which we will test to make sure it has been updated.")
 			clusterSecretName := argosharedutil.GenerateArgoCDClusterSecretName(db.ManagedEnvironment{Managedenvironment_id: managedEnvRow.Managedenvironment_id})
 			secret := &corev1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
@@ -155,11 +158,17 @@ var _ = Describe("Managed Environment Operation Tests", func() {
 			Expect(err).To(BeNil())
 
 			retry, err := task.PerformTask(ctx)
-			Expect(err).ToNot(BeNil(), "an error is expected here, because an operation on a managedenvironment is only supported if the managed environment doesn't exist")
+			Expect(err).To(BeNil())
 			Expect(retry).To(BeFalse())
 
 			err = task.event.client.Get(ctx, client.ObjectKeyFromObject(secret), secret)
 			Expect(err).To(BeNil(), "the Argo CD cluster secret should not have been deleted.")
+			Expect(string(secret.Data["server"])).To(Equal(clusterCredentials.Host))
+			Expect(string(secret.Data["config"])).To(ContainSubstring("my-rotated-token"),
+				"the Argo CD cluster secret should have been updated with the credentials of the managed environment")
+
+			err = expectOperationIsComplete(ctx, operationDB.Operation_id, dbQueries)
+			Expect(err).To(BeNil())
 
 		})
 
@@ -2,6 +2,7 @@ package eventloop
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -664,6 +665,127 @@ var _ = Describe("Operation Controller", func() {
 			})
 		})
 	})
+	Context("generateExpectedClusterSecret test", func() {
+
+		var ctx context.Context
+		var dbQueries db.AllDatabaseQueries
+		var argoCDNamespace *v1.Namespace
+		var k8sClient client.Client
+		var clusterCredentials db.ClusterCredentials
+		var application db.Application
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			err := db.SetupForTestingDBGinkgo()
+			Expect(err).To(BeNil())
+
+			dbQueries, err = db.NewUnsafePostgresDBQueries(true, true)
+			Expect(err).To(BeNil())
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+			argoCDNamespace = argocdNamespace
+
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace, argocdNamespace, kubesystemNamespace).Build()
+
+			clusterCredentials = db.ClusterCredentials{
+				Clustercredentials_cred_id:  "test-cluster-creds",
+				Host:                        "https://api.fake-unit-test-data.origin-ci-int-gce.dev.rhcloud.com:6443",
+				Serviceaccount_bearer_token: "my-token",
+				Serviceaccount_ns:           "kube-system",
+			}
+
+			application = db.Application{
+				Managed_environment_id: "test-managed-env",
+			}
+		})
+
+		AfterEach(func() {
+			dbQueries.CloseDatabase()
+		})
+
+		createManagedEnvironment := func() {
+			err := dbQueries.CreateClusterCredentials(ctx, &clusterCredentials)
+			Expect(err).To(BeNil())
+
+			managedEnvironment := db.ManagedEnvironment{
+				Managedenvironment_id: application.Managed_environment_id,
+				Name:                  "my-managed-env",
+				Clustercredentials_id: clusterCredentials.Clustercredentials_cred_id,
+			}
+			err = dbQueries.CreateManagedEnvironment(ctx, &managedEnvironment)
+			Expect(err).To(BeNil())
+		}
+
+		getClusterSecretConfig := func(secret v1.Secret) ClusterSecretConfigJSON {
+			config := ClusterSecretConfigJSON{}
+			err := json.Unmarshal(secret.Data["config"], &config)
+			Expect(err).To(BeNil())
+			return config
+		}
+
+		It("should verify the TLS certificate of the cluster, using the CA bundle of the cluster credentials", func() {
+			clusterCredentials.Ca_bundle = "my-ca-bundle"
+			createManagedEnvironment()
+
+			secret, shouldDelete, err := generateExpectedClusterSecret(ctx, application.Managed_environment_id, dbQueries, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(shouldDelete).To(BeFalse())
+
+			config := getClusterSecretConfig(secret)
+			Expect(config.BearerToken).To(Equal("my-token"))
+			Expect(config.TLSClientConfig.Insecure).To(BeFalse())
+			Expect(config.TLSClientConfig.CAData).To(Equal([]byte("my-ca-bundle")))
+
+			Expect(secret.Data).ToNot(HaveKey("namespaces"))
+		})
+
+		It("should not verify the TLS certificate of the cluster, if the cluster credentials allow it", func() {
+			clusterCredentials.Ca_bundle = "my-ca-bundle"
+			clusterCredentials.Allow_insecure_skip_tls_verify = true
+			createManagedEnvironment()
+
+			secret, _, err := generateExpectedClusterSecret(ctx, application.Managed_environment_id, dbQueries, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+
+			config := getClusterSecretConfig(secret)
+			Expect(config.TLSClientConfig.Insecure).To(BeTrue())
+			Expect(config.TLSClientConfig.CAData).To(BeNil())
+		})
+
+		It("should authenticate using the client certificate and key of the cluster credentials, if provided", func() {
+			clusterCredentials.Serviceaccount_bearer_token = ""
+			clusterCredentials.Serviceaccount_ns = ""
+			clusterCredentials.Client_cert_data = "my-client-cert"
+			clusterCredentials.Client_key_data = "my-client-key"
+			createManagedEnvironment()
+
+			secret, _, err := generateExpectedClusterSecret(ctx, application.Managed_environment_id, dbQueries, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+
+			config := getClusterSecretConfig(secret)
+			Expect(config.BearerToken).To(BeEmpty())
+			Expect(config.TLSClientConfig.CertData).To(Equal([]byte("my-client-cert")))
+			Expect(config.TLSClientConfig.KeyData).To(Equal([]byte("my-client-key")))
+		})
+
+		It("should limit Argo CD to the namespaces of a namespace-scoped managed environment", func() {
+			clusterCredentials.Namespaces = "namespace-a,namespace-b"
+			createManagedEnvironment()
+
+			secret, _, err := generateExpectedClusterSecret(ctx, application.Managed_environment_id, dbQueries, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(string(secret.Data["namespaces"])).To(Equal("namespace-a,namespace-b"))
+		})
+
+		It("should indicate that the secret should be deleted, if the managed environment does not exist", func() {
+			_, shouldDelete, err := generateExpectedClusterSecret(ctx, application.Managed_environment_id, dbQueries, *argoCDNamespace, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(shouldDelete).To(BeTrue())
+		})
+
+	})
 })
 
 func testTeardown() {
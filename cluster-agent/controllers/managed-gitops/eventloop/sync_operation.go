@@ -0,0 +1,319 @@
+package eventloop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/sync/common"
+	"github.com/go-logr/logr"
+	operation "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/config/db"
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/utils"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// syncOperationTerminateTimeout is the maximum amount of time to wait for Argo CD to terminate a sync operation
+const syncOperationTerminateTimeout = 2 * time.Minute
+
+// processOperation_SyncOperation handles an Operation that targets a SyncOperation: it syncs (or rolls back) the
+// Argo CD Application that corresponds to the SyncOperation's Application row, or, if the desired state of the
+// SyncOperation is terminated, cancels the sync.
+// Returns true if the task should be retried (eg due to failure), false otherwise.
+func processOperation_SyncOperation(ctx context.Context, dbOperation db.Operation, crOperation operation.Operation,
+	dbQueries db.DatabaseQueries, argoCDNamespace corev1.Namespace, credentialService *utils.CredentialService,
+	eventClient client.Client, log logr.Logger) (bool, error) {
+
+	// Sanity check
+	if dbOperation.Resource_id == "" {
+		return true, fmt.Errorf("resource id was nil while processing operation: " + crOperation.Name)
+	}
+
+	dbSyncOperation := &db.SyncOperation{
+		SyncOperation_id: dbOperation.Resource_id,
+	}
+
+	log = log.WithValues("syncOperationRow", dbSyncOperation.SyncOperation_id)
+
+	if err := dbQueries.GetSyncOperationById(ctx, dbSyncOperation); err != nil {
+		if db.IsResultNotFoundError(err) {
+			// The SyncOperation no longer exists (for example, the GitOpsDeploymentSyncRun was deleted), so there is no work to do.
+			log.V(sharedutil.LogLevel_Debug).Info("SyncOperation row no longer exists in the database, so no work to do")
+			return false, nil
+		}
+
+		log.Error(err, "Unable to retrieve database SyncOperation row from database")
+		return true, err
+	}
+
+	if dbSyncOperation.DesiredState != db.SyncOperation_DesiredState_Running &&
+		dbSyncOperation.DesiredState != db.SyncOperation_DesiredState_Terminated {
+		log.Info("SyncOperation does not have a desired state of running or terminated, so no work to do", "desiredState", dbSyncOperation.DesiredState)
+		return false, nil
+	}
+
+	// If the sync operation has previously completed (for example, the Operation was requeued after the sync), there is
+	// no more work to do.
+	previousOperationState, err := decompressSyncOperationState(dbSyncOperation.Operation_state)
+	if err != nil {
+		log.Error(err, "unable to decompress the operation state of the SyncOperation")
+		return false, err
+	}
+	if previousOperationState != nil && previousOperationState.FinishedAt != nil {
+		log.Info("SyncOperation has already completed, so no work to do")
+		return false, nil
+	}
+
+	if dbSyncOperation.Application_id == "" {
+		log.Info("SyncOperation does not reference an Application, so no work to do")
+		return false, nil
+	}
+
+	dbApplication := &db.Application{
+		Application_id: dbSyncOperation.Application_id,
+	}
+	if err := dbQueries.GetApplicationById(ctx, dbApplication); err != nil {
+		if db.IsResultNotFoundError(err) {
+			log.Info("Application referenced by the SyncOperation no longer exists, so no work to do", "applicationRow", dbApplication.Application_id)
+			return false, nil
+		}
+
+		log.Error(err, "Unable to retrieve database Application row from database", "applicationRow", dbApplication.Application_id)
+		return true, err
+	}
+
+	log = log.WithValues("applicationRow", dbApplication.Application_id)
+
+	if dbSyncOperation.DesiredState == db.SyncOperation_DesiredState_Terminated {
+		return terminateSyncOperation(ctx, *dbSyncOperation, previousOperationState, *dbApplication, dbQueries, argoCDNamespace,
+			credentialService, eventClient, log)
+	}
+
+	var syncResources []appv1.SyncOperationResource
+	if dbSyncOperation.Resources != "" {
+		if err := json.Unmarshal([]byte(dbSyncOperation.Resources), &syncResources); err != nil {
+			log.Error(err, "SEVERE: unable to unmarshal the resources of the SyncOperation")
+			return false, err
+		}
+	}
+
+	// Report that the sync operation is running, until Argo CD reports the result
+	startedAt := metav1.Now()
+	if err := updateSyncOperationState(ctx, dbSyncOperation, &appv1.OperationState{
+		Phase:     common.OperationRunning,
+		StartedAt: startedAt,
+	}, dbQueries); err != nil {
+		log.Error(err, "unable to update the operation state of the SyncOperation")
+		return true, err
+	}
+
+	// The sync operation may have been terminated while the state was being updated: if so, don't start the sync.
+	if dbSyncOperation.DesiredState == db.SyncOperation_DesiredState_Terminated {
+		return terminateSyncOperation(ctx, *dbSyncOperation, nil, *dbApplication, dbQueries, argoCDNamespace,
+			credentialService, eventClient, log)
+	}
+
+	var operationState *appv1.OperationState
+	var syncErr error
+
+	if dbSyncOperation.Rollback_history_id != nil {
+
+		historyID := *dbSyncOperation.Rollback_history_id
+
+		log.Info("Rolling back Argo CD Application", "historyID", strconv.FormatInt(historyID, 10))
+
+		operationState, syncErr = utils.AppRollback(ctx, dbApplication.Name, historyID, dbSyncOperation.Prune, dbSyncOperation.Dry_run,
+			argoCDNamespace.Name, eventClient, credentialService, false)
+		if syncErr != nil {
+			log.Error(syncErr, "unable to roll back Argo CD Application")
+			syncErr = fmt.Errorf("unable to roll back Application '%s' to history id '%d': %v", dbApplication.Name, historyID, syncErr)
+		}
+
+	} else {
+
+		log.Info("Syncing Argo CD Application", "revision", dbSyncOperation.Revision)
+
+		operationState, syncErr = utils.AppSync(ctx, dbApplication.Name, utils.AppSyncOptions{
+			Revision:  dbSyncOperation.Revision,
+			Prune:     dbSyncOperation.Prune,
+			DryRun:    dbSyncOperation.Dry_run,
+			Force:     dbSyncOperation.Force,
+			Resources: syncResources,
+		}, argoCDNamespace.Name, eventClient, credentialService, false)
+		if syncErr != nil {
+			log.Error(syncErr, "unable to sync Argo CD Application")
+			syncErr = fmt.Errorf("unable to sync Application '%s' to revision '%s': %v", dbApplication.Name, dbSyncOperation.Revision, syncErr)
+		}
+	}
+
+	// If Argo CD did not report the result of the operation (for example, because the operation could not be started),
+	// then report the error instead.
+	if operationState == nil {
+		finishedAt := metav1.Now()
+		operationState = &appv1.OperationState{
+			Phase:      common.OperationSucceeded,
+			StartedAt:  startedAt,
+			FinishedAt: &finishedAt,
+		}
+		if syncErr != nil {
+			operationState.Phase = common.OperationError
+			operationState.Message = syncErr.Error()
+		}
+	}
+
+	if err := updateSyncOperationState(ctx, dbSyncOperation, operationState, dbQueries); err != nil {
+		log.Error(err, "unable to update the operation state of the SyncOperation")
+		if syncErr == nil {
+			syncErr = err
+		}
+	}
+
+	// The sync/rollback is not retried on failure: the failure is instead reported in the state of the Operation.
+	return false, syncErr
+}
+
+// terminateSyncOperation cancels the sync of the Argo CD Application, if the sync operation is in progress, and reports
+// the sync operation as terminated.
+func terminateSyncOperation(ctx context.Context, dbSyncOperation db.SyncOperation, previousOperationState *appv1.OperationState,
+	dbApplication db.Application, dbQueries db.DatabaseQueries, argoCDNamespace corev1.Namespace,
+	credentialService *utils.CredentialService, eventClient client.Client, log logr.Logger) (bool, error) {
+
+	finishedAt := metav1.Now()
+	terminatedState := &appv1.OperationState{
+		Phase:      common.OperationFailed,
+		Message:    "sync operation was terminated before it started",
+		StartedAt:  finishedAt,
+		FinishedAt: &finishedAt,
+	}
+
+	// If the sync has started, ask Argo CD to terminate it, and wait for it to stop.
+	if previousOperationState != nil {
+
+		log.Info("Terminating sync operation of Argo CD Application")
+
+		if err := updateSyncOperationState(ctx, &dbSyncOperation, &appv1.OperationState{
+			Phase:     common.OperationTerminating,
+			StartedAt: previousOperationState.StartedAt,
+		}, dbQueries); err != nil {
+			log.Error(err, "unable to update the operation state of the SyncOperation")
+			return true, err
+		}
+
+		if err := utils.TerminateOperation(ctx, dbApplication.Name, argoCDNamespace, credentialService, eventClient,
+			syncOperationTerminateTimeout, log); err != nil {
+
+			// Argo CD will refuse to terminate a sync operation that is no longer running (for example, if the
+			// cluster-agent was restarted during the sync), in which case there is nothing left to terminate.
+			if running, getErr := isApplicationOperationRunning(ctx, dbApplication.Name, argoCDNamespace, eventClient); getErr != nil || running {
+				log.Error(err, "unable to terminate sync operation of Argo CD Application")
+				return true, fmt.Errorf("unable to terminate sync operation of Application '%s': %v", dbApplication.Name, err)
+			}
+		}
+
+		terminatedState.Message = "sync operation was terminated"
+		terminatedState.StartedAt = previousOperationState.StartedAt
+		terminatedAt := metav1.Now()
+		terminatedState.FinishedAt = &terminatedAt
+	}
+
+	// The in-progress sync may have recorded the result reported by Argo CD in the meantime: if so, that result is kept.
+	if err := updateSyncOperationState(ctx, &dbSyncOperation, terminatedState, dbQueries); err != nil {
+		log.Error(err, "unable to update the operation state of the SyncOperation")
+		return true, err
+	}
+
+	log.Info("Sync operation was terminated")
+
+	return false, nil
+}
+
+// isApplicationOperationRunning returns true if the Argo CD Application has an operation that is in progress.
+func isApplicationOperationRunning(ctx context.Context, appName string, argoCDNamespace corev1.Namespace, k8sClient client.Client) (bool, error) {
+
+	app := &appv1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: argoCDNamespace.Name,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(app), app); err != nil {
+		if apierr.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if app.Operation != nil {
+		return true, nil
+	}
+
+	return app.Status.OperationState != nil && (app.Status.OperationState.Phase == common.OperationRunning ||
+		app.Status.OperationState.Phase == common.OperationTerminating), nil
+}
+
+// updateSyncOperationState stores the given state of the sync operation in the SyncOperation row. The row is re-read
+// first, as it may have been concurrently modified (for example, to terminate the sync): the state of a sync
+// operation that has already completed is not replaced.
+func updateSyncOperationState(ctx context.Context, dbSyncOperation *db.SyncOperation, operationState *appv1.OperationState,
+	dbQueries db.DatabaseQueries) error {
+
+	if err := dbQueries.GetSyncOperationById(ctx, dbSyncOperation); err != nil {
+		return err
+	}
+
+	if existingOperationState, err := decompressSyncOperationState(dbSyncOperation.Operation_state); err != nil {
+		return err
+	} else if existingOperationState != nil && existingOperationState.FinishedAt != nil {
+		return nil
+	}
+
+	operationStateBytes, err := compressSyncOperationState(operationState)
+	if err != nil {
+		return err
+	}
+
+	dbSyncOperation.Operation_state = operationStateBytes
+
+	return dbQueries.UpdateSyncOperation(ctx, dbSyncOperation)
+}
+
+// compressSyncOperationState converts the state of a sync operation into YAML, and then compresses it into a byte
+// array, in the same format as the operation state of an ApplicationState.
+func compressSyncOperationState(operationState *appv1.OperationState) ([]byte, error) {
+
+	// The sigs.k8s.io/yaml package is used here, as it respects the JSON field names of the API types.
+	operationStateStr, err := yaml.Marshal(operationState)
+	if err != nil {
+		return nil, fmt.Errorf("unable to Marshal operation state. %v", err)
+	}
+
+	return controllers.CompressData(operationStateStr)
+}
+
+// decompressSyncOperationState is the inverse of compressSyncOperationState. Returns nil if no state has been recorded.
+func decompressSyncOperationState(operationStateBytes []byte) (*appv1.OperationState, error) {
+	if len(operationStateBytes) == 0 {
+		return nil, nil
+	}
+
+	operationStateStr, err := controllers.DecompressData(operationStateBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	operationState := &appv1.OperationState{}
+	if err := yaml.Unmarshal(operationStateStr, operationState); err != nil {
+		return nil, fmt.Errorf("unable to Unmarshal operation state. %v", err)
+	}
+
+	return operationState, nil
+}
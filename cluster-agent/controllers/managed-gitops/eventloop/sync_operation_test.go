@@ -0,0 +1,248 @@
+package eventloop
+
+import (
+	"context"
+	"time"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/sync/common"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	operation "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/config/db"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("SyncOperation Operation tests", func() {
+
+	Context("Testing processOperation_SyncOperation function", func() {
+
+		var (
+			ctx             context.Context
+			dbQueries       db.AllDatabaseQueries
+			k8sClient       client.Client
+			argoCDNamespace *corev1.Namespace
+			dbApplication   *db.Application
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			err := db.SetupForTestingDBGinkgo()
+			Expect(err).To(BeNil())
+
+			dbQueries, err = db.NewUnsafePostgresDBQueries(true, true)
+			Expect(err).To(BeNil())
+
+			_, managedEnvironment, _, gitopsEngineInstance, _, err := db.CreateSampleData(dbQueries)
+			Expect(err).To(BeNil())
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+			argoCDNamespace = argocdNamespace
+
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace, argocdNamespace, kubesystemNamespace).Build()
+
+			dbApplication = &db.Application{
+				Application_id:          "test-my-application",
+				Name:                    "my-application",
+				Spec_field:              "{}",
+				Engine_instance_inst_id: gitopsEngineInstance.Gitopsengineinstance_id,
+				Managed_environment_id:  managedEnvironment.Managedenvironment_id,
+			}
+			err = dbQueries.CreateApplication(ctx, dbApplication)
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			dbQueries.CloseDatabase()
+		})
+
+		It("should not retry, or return an error, if the SyncOperation row doesn't exist", func() {
+			dbOperation := db.Operation{
+				Resource_id:   "test-sync-operation-that-does-not-exist",
+				Resource_type: db.OperationResourceType_SyncOperation,
+			}
+
+			retry, err := processOperation_SyncOperation(ctx, dbOperation, operation.Operation{}, dbQueries, *argoCDNamespace,
+				nil, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(retry).To(BeFalse())
+		})
+
+		It("should not sync the Application, and should report it as terminated, if the desired state of the SyncOperation is terminated", func() {
+			syncOperation := &db.SyncOperation{
+				SyncOperation_id:    "test-sync-operation",
+				Application_id:      dbApplication.Application_id,
+				DeploymentNameField: "my-gitops-depl",
+				Revision:            "main",
+				DesiredState:        db.SyncOperation_DesiredState_Terminated,
+			}
+			err := dbQueries.CreateSyncOperation(ctx, syncOperation)
+			Expect(err).To(BeNil())
+
+			dbOperation := db.Operation{
+				Resource_id:   syncOperation.SyncOperation_id,
+				Resource_type: db.OperationResourceType_SyncOperation,
+			}
+
+			// A nil credential service is passed: it would be dereferenced if a sync was (incorrectly) attempted.
+			retry, err := processOperation_SyncOperation(ctx, dbOperation, operation.Operation{}, dbQueries, *argoCDNamespace,
+				nil, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(retry).To(BeFalse())
+
+			By("verifying that the sync operation is reported as terminated, as it had not started")
+			err = dbQueries.GetSyncOperationById(ctx, syncOperation)
+			Expect(err).To(BeNil())
+
+			operationState, err := decompressSyncOperationState(syncOperation.Operation_state)
+			Expect(err).To(BeNil())
+			Expect(operationState).ToNot(BeNil())
+			Expect(operationState.Phase).To(Equal(common.OperationFailed))
+			Expect(operationState.Message).To(Equal("sync operation was terminated before it started"))
+			Expect(operationState.FinishedAt).ToNot(BeNil())
+		})
+
+		It("should not sync the Application again if the SyncOperation has already completed", func() {
+			finishedAt := metav1.Now()
+			operationState, err := compressSyncOperationState(&appv1.OperationState{
+				Phase:      common.OperationSucceeded,
+				StartedAt:  finishedAt,
+				FinishedAt: &finishedAt,
+			})
+			Expect(err).To(BeNil())
+
+			syncOperation := &db.SyncOperation{
+				SyncOperation_id:    "test-sync-operation",
+				Application_id:      dbApplication.Application_id,
+				DeploymentNameField: "my-gitops-depl",
+				Revision:            "main",
+				DesiredState:        db.SyncOperation_DesiredState_Running,
+				Operation_state:     operationState,
+			}
+			err = dbQueries.CreateSyncOperation(ctx, syncOperation)
+			Expect(err).To(BeNil())
+
+			dbOperation := db.Operation{
+				Resource_id:   syncOperation.SyncOperation_id,
+				Resource_type: db.OperationResourceType_SyncOperation,
+			}
+
+			// A nil credential service is passed: it would be dereferenced if a sync was (incorrectly) attempted.
+			retry, err := processOperation_SyncOperation(ctx, dbOperation, operation.Operation{}, dbQueries, *argoCDNamespace,
+				nil, k8sClient, log.FromContext(ctx))
+			Expect(err).To(BeNil())
+			Expect(retry).To(BeFalse())
+		})
+	})
+
+	Context("Testing isApplicationOperationRunning function", func() {
+
+		var (
+			ctx             context.Context
+			k8sClient       client.Client
+			argoCDNamespace *corev1.Namespace
+			app             *appv1.Application
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).To(BeNil())
+			argoCDNamespace = argocdNamespace
+
+			err = appv1.AddToScheme(scheme)
+			Expect(err).To(BeNil())
+
+			app = &appv1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-application",
+					Namespace: argoCDNamespace.Name,
+				},
+			}
+
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace, argocdNamespace, kubesystemNamespace, app).Build()
+		})
+
+		It("should return false if the Application does not exist", func() {
+			running, err := isApplicationOperationRunning(ctx, "does-not-exist", *argoCDNamespace, k8sClient)
+			Expect(err).To(BeNil())
+			Expect(running).To(BeFalse())
+		})
+
+		It("should return false if the Application has no operation", func() {
+			running, err := isApplicationOperationRunning(ctx, app.Name, *argoCDNamespace, k8sClient)
+			Expect(err).To(BeNil())
+			Expect(running).To(BeFalse())
+		})
+
+		It("should return true if the operation of the Application is running, and false once it has completed", func() {
+			app.Status.OperationState = &appv1.OperationState{Phase: common.OperationRunning}
+			err := k8sClient.Update(ctx, app)
+			Expect(err).To(BeNil())
+
+			running, err := isApplicationOperationRunning(ctx, app.Name, *argoCDNamespace, k8sClient)
+			Expect(err).To(BeNil())
+			Expect(running).To(BeTrue())
+
+			app.Status.OperationState = &appv1.OperationState{Phase: common.OperationFailed}
+			err = k8sClient.Update(ctx, app)
+			Expect(err).To(BeNil())
+
+			running, err = isApplicationOperationRunning(ctx, app.Name, *argoCDNamespace, k8sClient)
+			Expect(err).To(BeNil())
+			Expect(running).To(BeFalse())
+		})
+	})
+
+	Context("Testing compressSyncOperationState and decompressSyncOperationState functions", func() {
+
+		It("should return nil if no operation state has been recorded", func() {
+			operationState, err := decompressSyncOperationState(nil)
+			Expect(err).To(BeNil())
+			Expect(operationState).To(BeNil())
+		})
+
+		It("should preserve the operation state, when compressed and then decompressed", func() {
+			startedAt := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+			finishedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+
+			operationState := &appv1.OperationState{
+				Phase:      common.OperationFailed,
+				Message:    "one or more objects failed to apply",
+				StartedAt:  startedAt,
+				FinishedAt: &finishedAt,
+				SyncResult: &appv1.SyncOperationResult{
+					Revision: "abc123",
+					Resources: appv1.ResourceResults{{
+						Group:     "apps",
+						Version:   "v1",
+						Kind:      "Deployment",
+						Namespace: "my-namespace",
+						Name:      "my-deployment",
+						Status:    common.ResultCodeSyncFailed,
+						Message:   "the server could not find the requested resource",
+					}},
+				},
+			}
+
+			compressed, err := compressSyncOperationState(operationState)
+			Expect(err).To(BeNil())
+
+			decompressed, err := decompressSyncOperationState(compressed)
+			Expect(err).To(BeNil())
+			Expect(decompressed.Phase).To(Equal(operationState.Phase))
+			Expect(decompressed.Message).To(Equal(operationState.Message))
+			Expect(decompressed.StartedAt.Equal(&startedAt)).To(BeTrue())
+			Expect(decompressed.FinishedAt.Equal(&finishedAt)).To(BeTrue())
+			Expect(decompressed.SyncResult).To(Equal(operationState.SyncResult))
+		})
+	})
+})
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ManagedApplicationSpecFields are the fields of the '.spec' of an Argo CD Application that are set by the GitOps
+// Service. Any other fields of the '.spec' are left as is, when updating the Application.
+var ManagedApplicationSpecFields = []string{"source", "sources", "destination", "project", "syncPolicy", "ignoreDifferences"}
+
+// IsUnstructuredApplicationSpec returns true if the Argo CD Application spec, as generated by the backend, uses fields
+// that are not supported by the Argo CD API types of the cluster-agent: multiple sources ('.spec.sources') or
+// kustomize patches ('.spec.source.kustomize.patches'). Applications with such a spec must be handled as unstructured
+// objects, as otherwise those fields would be dropped.
+func IsUnstructuredApplicationSpec(spec fauxargocd.FauxApplicationSpec) bool {
+
+	if len(spec.Sources) > 0 {
+		return true
+	}
+
+	return spec.Source.Kustomize != nil && len(spec.Source.Kustomize.Patches) > 0
+}
+
+// ConvertFauxApplicationSpecToUnstructured converts the spec of an Argo CD Application, as generated by the backend,
+// into the '.spec' of an unstructured Argo CD Application.
+func ConvertFauxApplicationSpecToUnstructured(spec fauxargocd.FauxApplicationSpec) (map[string]interface{}, error) {
+
+	res, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert application spec to unstructured: %v", err)
+	}
+
+	// Argo CD ignores 'source' when 'sources' is specified, and the backend leaves 'source' empty in that case, so we
+	// remove it rather than setting an empty source.
+	if len(spec.Sources) > 0 {
+		delete(res, "source")
+	}
+
+	return res, nil
+}
+
+// DiffUnstructuredApplicationSpec returns the fields of ManagedApplicationSpecFields that differ between the '.spec'
+// of an existing unstructured Argo CD Application, and the expected '.spec' (see ConvertFauxApplicationSpecToUnstructured).
+func DiffUnstructuredApplicationSpec(existingSpec map[string]interface{}, expectedSpec map[string]interface{}) []string {
+
+	var res []string
+	for _, field := range ManagedApplicationSpecFields {
+		if !equality.Semantic.DeepEqual(existingSpec[field], expectedSpec[field]) {
+			res = append(res, field)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Tests for the unstructured Argo CD Application utility functions", func() {
+
+	Context("Testing ConvertFauxApplicationSpecToUnstructured function", func() {
+
+		It("should include sources, and not source, when multiple sources are specified", func() {
+
+			res, err := ConvertFauxApplicationSpecToUnstructured(fauxargocd.FauxApplicationSpec{
+				Sources: fauxargocd.ApplicationSources{
+					{RepoURL: "https://github.com/example/repo", Path: "resources", TargetRevision: "main"},
+					{RepoURL: "https://github.com/example/values", TargetRevision: "main", Ref: "values"},
+				},
+				Destination: fauxargocd.ApplicationDestination{Name: "in-cluster", Namespace: "my-namespace"},
+				Project:     "default",
+			})
+			Expect(err).To(BeNil())
+
+			Expect(res).ToNot(HaveKey("source"))
+
+			sources, exists, err := unstructured.NestedSlice(res, "sources")
+			Expect(err).To(BeNil())
+			Expect(exists).To(BeTrue())
+			Expect(sources).To(HaveLen(2))
+			Expect(sources[1]).To(HaveKeyWithValue("ref", "values"))
+
+			Expect(res).To(HaveKeyWithValue("project", "default"))
+		})
+	})
+
+	Context("Testing IsUnstructuredApplicationSpec function", func() {
+
+		It("should return true only for multi-source specs, and specs with kustomize patches", func() {
+
+			spec := fauxargocd.FauxApplicationSpec{
+				Source: fauxargocd.ApplicationSource{RepoURL: "https://github.com/example/repo", Path: "resources"},
+			}
+			Expect(IsUnstructuredApplicationSpec(spec)).To(BeFalse())
+
+			spec.Source.Kustomize = &fauxargocd.ApplicationSourceKustomize{NamePrefix: "prefix-"}
+			Expect(IsUnstructuredApplicationSpec(spec)).To(BeFalse())
+
+			spec.Source.Kustomize.Patches = fauxargocd.KustomizePatches{{Patch: "- op: remove\n  path: /spec/replicas\n"}}
+			Expect(IsUnstructuredApplicationSpec(spec)).To(BeTrue())
+
+			spec = fauxargocd.FauxApplicationSpec{
+				Sources: fauxargocd.ApplicationSources{{RepoURL: "https://github.com/example/repo", Path: "resources"}},
+			}
+			Expect(IsUnstructuredApplicationSpec(spec)).To(BeTrue())
+		})
+	})
+
+	Context("Testing DiffUnstructuredApplicationSpec function", func() {
+
+		It("should return only the managed fields that differ", func() {
+
+			expectedSpec, err := ConvertFauxApplicationSpecToUnstructured(fauxargocd.FauxApplicationSpec{
+				Sources: fauxargocd.ApplicationSources{
+					{RepoURL: "https://github.com/example/repo", Path: "resources", TargetRevision: "main"},
+				},
+				Destination: fauxargocd.ApplicationDestination{Name: "in-cluster", Namespace: "my-namespace"},
+				Project:     "default",
+			})
+			Expect(err).To(BeNil())
+
+			existingSpec, err := ConvertFauxApplicationSpecToUnstructured(fauxargocd.FauxApplicationSpec{
+				Sources: fauxargocd.ApplicationSources{
+					{RepoURL: "https://github.com/example/repo", Path: "resources", TargetRevision: "main"},
+				},
+				Destination: fauxargocd.ApplicationDestination{Name: "in-cluster", Namespace: "my-namespace"},
+				Project:     "default",
+			})
+			Expect(err).To(BeNil())
+
+			By("ignoring fields that are not managed by the GitOps Service")
+			existingSpec["revisionHistoryLimit"] = int64(5)
+			Expect(DiffUnstructuredApplicationSpec(existingSpec, expectedSpec)).To(BeEmpty())
+
+			By("detecting a change to the sources")
+			err = unstructured.SetNestedSlice(existingSpec, []interface{}{
+				map[string]interface{}{"repoURL": "https://github.com/example/repo", "path": "other", "targetRevision": "main"},
+			}, "sources")
+			Expect(err).To(BeNil())
+			Expect(DiffUnstructuredApplicationSpec(existingSpec, expectedSpec)).To(Equal([]string{"sources"}))
+		})
+	})
+})
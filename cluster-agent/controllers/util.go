@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"fmt"
+	"io"
 	"time"
 
 	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
@@ -186,3 +190,44 @@ func DeleteArgoCDApplication(ctx context.Context, appFromList appv1.Application,
 
 	return nil
 }
+
+// CompressData compresses the given data into a byte array, using gzip
+func CompressData(data []byte) ([]byte, error) {
+	var byteArr []byte
+	var buffer bytes.Buffer
+
+	// Compress string data
+	gzipWriter, err := gzip.NewWriterLevel(&buffer, gzip.BestSpeed)
+	if err != nil {
+		return byteArr, fmt.Errorf("unable to create Buffer writer. %v", err)
+	}
+
+	_, err = gzipWriter.Write(data)
+
+	if err != nil {
+		return byteArr, fmt.Errorf("unable to compress resource string. %v", err)
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return byteArr, fmt.Errorf("unable to close gzip writer connection. %v", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// DecompressData is the inverse of CompressData: it decompresses the given gzip-compressed byte array.
+func DecompressData(byteArr []byte) ([]byte, error) {
+
+	gzipReader, err := gzip.NewReader(bytes.NewBuffer(byteArr))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create gzip reader. %v", err)
+	}
+	defer gzipReader.Close()
+
+	res, err := io.ReadAll(gzipReader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress data. %v", err)
+	}
+
+	return res, nil
+}
@@ -3,22 +3,56 @@ package utils
 import (
 	"context"
 	"fmt"
+	"os"
 
 	argocdclient "github.com/argoproj/argo-cd/v2/pkg/apiclient"
 	sessionpkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/session"
 	grpc_util "github.com/argoproj/argo-cd/v2/util/grpc"
 	"github.com/argoproj/argo-cd/v2/util/io"
+	"github.com/go-logr/logr"
 	"github.com/golang-jwt/jwt/v4"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 // This file is loosely based on the 'argocd login' CLI command (https://github.com/argoproj/argo-cd/blob/0a46d37fc6af9fe0aa963bdd845e3d799aa0320d/cmd/argocd/commands/login.go#L60)
 
+// argoCDServerRootCAFileEnv is the environment variable containing the path to a PEM-encoded CA bundle, used to verify
+// the TLS certificate of the Argo CD server.
+//
+// The Argo CD server is accessed via its Route, which by default is served with a self-signed certificate: so, TLS
+// verification of the Argo CD server is only enabled when a CA bundle is provided via this environment variable.
+// See 'manifests/managed-gitops-clusteragent-deployment.yaml' for how the CA bundle is provided to the cluster-agent.
+const argoCDServerRootCAFileEnv = "ARGO_CD_SERVER_ROOT_CA_FILE"
+
+// getArgoCDServerRootCAFile returns the path of the CA bundle that should be used to verify the TLS certificate of the
+// Argo CD server, or "" if TLS verification should be skipped (in which case a warning is logged).
+func getArgoCDServerRootCAFile(log logr.Logger) string {
+
+	rootCAFile := os.Getenv(argoCDServerRootCAFileEnv)
+	if rootCAFile == "" {
+		log.Info("WARNING: " + argoCDServerRootCAFileEnv + " is not set, so the TLS certificate of the Argo CD server will not be verified")
+		return ""
+	}
+
+	// The CA bundle is mounted from an optional ConfigMap, so it may not exist
+	if _, err := os.Stat(rootCAFile); err != nil {
+		log.Error(err, "WARNING: unable to read the CA bundle specified by "+argoCDServerRootCAFileEnv+
+			", so the TLS certificate of the Argo CD server will not be verified", "path", rootCAFile)
+		return ""
+	}
+
+	return rootCAFile
+}
+
 func generateDefaultClientForServerAddress(server string, optionalAuthToken string, skipTLSTest bool) (argocdclient.Client, error) {
 
+	rootCAFile := getArgoCDServerRootCAFile(log.FromContext(context.Background()))
+
 	globalClientOpts := argocdclient.ClientOptions{
 		ConfigPath:           "",
 		ServerAddr:           server,
-		Insecure:             true, // TODO: GITOPSRVCE-178: once support for TLS certification validation is implemented, the value should be used here.
+		Insecure:             rootCAFile == "",
+		CertFile:             rootCAFile,
 		PlainText:            false,
 		ClientCertFile:       "",
 		ClientCertKeyFile:    "",
@@ -45,7 +79,8 @@ func generateDefaultClientForServerAddress(server string, optionalAuthToken stri
 				return nil, fmt.Errorf("server is not configured with TLS")
 			}
 		} else if tlsTestResult.InsecureErr != nil {
-			if !globalClientOpts.Insecure {
+			// The TLS test verifies the certificate using the system roots, so the error is ignored if a CA bundle is specified
+			if !globalClientOpts.Insecure && globalClientOpts.CertFile == "" {
 				return nil, fmt.Errorf("WARNING: server certificate had error: %s", tlsTestResult.InsecureErr)
 			}
 		}
@@ -55,6 +90,7 @@ func generateDefaultClientForServerAddress(server string, optionalAuthToken stri
 		ServerAddr:           server,
 		AuthToken:            optionalAuthToken,
 		Insecure:             globalClientOpts.Insecure,
+		CertFile:             globalClientOpts.CertFile,
 		PlainText:            globalClientOpts.PlainText,
 		ClientCertFile:       globalClientOpts.ClientCertFile,
 		ClientCertKeyFile:    globalClientOpts.ClientCertKeyFile,
@@ -1,11 +1,15 @@
 package utils
 
 import (
+	"os"
+	"path/filepath"
+
 	"github.com/argoproj/argo-cd/v2/pkg/apiclient/session"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/redhat-appstudio/managed-gitops/cluster-agent/utils/mocks"
 	"github.com/stretchr/testify/mock"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 // These tests are expected to run in parallel with other test cases, We can run the ginkgo tests parallelly by passing "ginkgo -p" command in CLI.
@@ -29,4 +33,36 @@ var _ = Describe("ArgoCD Login Command", func() {
 			Expect(err).To(BeNil())
 		})
 	})
+
+	Context("Argo CD server root CA file test", func() {
+
+		BeforeEach(func() {
+			originalValue, isSet := os.LookupEnv(argoCDServerRootCAFileEnv)
+			DeferCleanup(func() {
+				if isSet {
+					Expect(os.Setenv(argoCDServerRootCAFileEnv, originalValue)).To(Succeed())
+				} else {
+					Expect(os.Unsetenv(argoCDServerRootCAFileEnv)).To(Succeed())
+				}
+			})
+		})
+
+		It("should skip TLS verification if the environment variable is not set", func() {
+			Expect(os.Unsetenv(argoCDServerRootCAFileEnv)).To(Succeed())
+			Expect(getArgoCDServerRootCAFile(logf.Log)).To(BeEmpty())
+		})
+
+		It("should skip TLS verification if the CA bundle does not exist", func() {
+			Expect(os.Setenv(argoCDServerRootCAFileEnv, filepath.Join(GinkgoT().TempDir(), "ca.crt"))).To(Succeed())
+			Expect(getArgoCDServerRootCAFile(logf.Log)).To(BeEmpty())
+		})
+
+		It("should return the path of the CA bundle, if it exists", func() {
+			rootCAFile := filepath.Join(GinkgoT().TempDir(), "ca.crt")
+			Expect(os.WriteFile(rootCAFile, []byte("(PEM-encoded CA bundle)"), 0600)).To(Succeed())
+
+			Expect(os.Setenv(argoCDServerRootCAFileEnv, rootCAFile)).To(Succeed())
+			Expect(getArgoCDServerRootCAFile(logf.Log)).To(Equal(rootCAFile))
+		})
+	})
 })
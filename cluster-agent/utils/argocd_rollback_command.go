@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	argocdclient "github.com/argoproj/argo-cd/v2/pkg/apiclient"
+	applicationpkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	argoappv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	argoio "github.com/argoproj/argo-cd/v2/util/io"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// This contents of this file are loosely based on the 'argocd app rollback' CLI command:
+// https://github.com/argoproj/argo-cd/blob/0a46d37fc6af9fe0aa963bdd845e3d799aa0320d/cmd/argocd/commands/app.go#L2089
+
+// AppRollback will roll back the given Argo CD Application, in the given namespace, to the revision of the history
+// entry with the given ID. On completion of the rollback operation, the final state of the operation is returned (if
+// available), whether or not the operation succeeded.
+func AppRollback(ctx context.Context, appName string, historyID int64, prune bool, dryRun bool, namespaceName string,
+	k8sClient client.Client, credentialsService *CredentialService, skipTLSTest bool) (*argoappv1.OperationState, error) {
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespaceName,
+			Name:      namespaceName,
+		},
+	}
+
+	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(namespace), namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve namespace in AppRollback: %s, %v", namespaceName, err)
+	}
+
+	_, acdClient, err := credentialsService.GetArgoCDLoginCredentials(ctx, namespaceName, string(namespace.UID), false, k8sClient)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := appRollback(ctx, acdClient, appName, historyID, prune, dryRun, appOperationTimeout(ctx))
+
+	var operationState *argoappv1.OperationState
+	if app != nil {
+		operationState = app.Status.OperationState
+	}
+
+	return operationState, err
+}
+
+// appRollback returns the Application after the rollback operation has completed, whether or not the operation succeeded.
+func appRollback(ctx context.Context, acdClient argocdclient.Client, appName string, historyID int64, prune bool, dryRun bool,
+	timeout uint) (*argoappv1.Application, error) {
+
+	conn, appIf, err := acdClient.NewApplicationClient()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve acd client: %v", err)
+	}
+	defer argoio.Close(conn)
+
+	app, err := appIf.Get(ctx, &applicationpkg.ApplicationQuery{Name: &appName})
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify that the history entry exists, before requesting the rollback.
+	historyFound := false
+	for _, entry := range app.Status.History {
+		if entry.ID == historyID {
+			historyFound = true
+			break
+		}
+	}
+	if !historyFound {
+		return nil, fmt.Errorf("application '%s' does not have history id '%d'", appName, historyID)
+	}
+
+	_, err = appIf.Rollback(ctx, &applicationpkg.ApplicationRollbackRequest{
+		Name:   &appName,
+		ID:     historyID,
+		Prune:  prune,
+		DryRun: dryRun,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	app, err = waitOnApplicationStatus(ctx, acdClient, appName, timeout, false, false, true, false, []argoappv1.SyncOperationResource{})
+	if err != nil {
+		return nil, err
+	}
+
+	if !dryRun && !app.Status.OperationState.Phase.Successful() {
+		return app, fmt.Errorf("rollback operation has completed with phase: %s", app.Status.OperationState.Phase)
+	}
+
+	return app, nil
+}
@@ -25,9 +25,49 @@ import (
 // This contents of this file are loosely based on the 'argocd app sync' CLI command:
 // https://github.com/argoproj/argo-cd/blob/0a46d37fc6af9fe0aa963bdd845e3d799aa0320d/cmd/argocd/commands/app.go#L1333
 
+// defaultAppOperationTimeout is the maximum amount of time to wait for a sync (or rollback) operation of an Argo CD
+// Application to complete, if the context does not have an earlier deadline.
+const defaultAppOperationTimeout = 10 * time.Minute
+
+// appOperationTimeout returns the number of seconds to wait for a sync (or rollback) operation to complete: this is
+// the time remaining until the deadline of the context, if any, up to defaultAppOperationTimeout.
+func appOperationTimeout(ctx context.Context) uint {
+
+	timeout := defaultAppOperationTimeout
+
+	if deadline, exists := ctx.Deadline(); exists {
+		if remaining := time.Until(deadline); remaining < timeout {
+			timeout = remaining
+		}
+	}
+
+	// A timeout of 0 would wait indefinitely, so wait for at least a second.
+	if timeout < time.Second {
+		timeout = time.Second
+	}
+
+	return uint(timeout / time.Second)
+}
+
+// AppSyncOptions are the options of a sync operation: these correspond to the options of the 'argocd app sync' CLI command.
+type AppSyncOptions struct {
+	// Revision is the revision to sync to
+	Revision string
+	// Prune deletes resources that are no longer defined in the source
+	Prune bool
+	// DryRun performs the sync without applying any changes
+	DryRun bool
+	// Force deletes and re-creates resources which could not otherwise be applied
+	Force bool
+	// Resources, if non-empty, limits the sync to only the given resources
+	Resources []argoappv1.SyncOperationResource
+}
+
 // AppSync will trigger a synchronize application on the given Argo CD appliatication, in the given namespace.
-func AppSync(ctx context.Context, appName string, revision string, namespaceName string, k8sClient client.Client,
-	credentialsService *CredentialService, skipTLSTest bool) error {
+// On completion of the sync operation, the final state of the operation is returned (if available), whether or not
+// the operation succeeded.
+func AppSync(ctx context.Context, appName string, syncOptions AppSyncOptions, namespaceName string, k8sClient client.Client,
+	credentialsService *CredentialService, skipTLSTest bool) (*argoappv1.OperationState, error) {
 
 	namespace := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
@@ -38,30 +78,35 @@ func AppSync(ctx context.Context, appName string, revision string, namespaceName
 
 	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(namespace), namespace)
 	if err != nil {
-		return fmt.Errorf("unable to retrieve namespace in AppSync: %s, %v", namespaceName, err)
+		return nil, fmt.Errorf("unable to retrieve namespace in AppSync: %s, %v", namespaceName, err)
 	}
 
 	_, acdClient, err := credentialsService.GetArgoCDLoginCredentials(ctx, namespaceName, string(namespace.UID), false, k8sClient)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = appSync(ctx, acdClient, appName, false, false, revision, false, "", false, false, 0, 0, 0, 0, 0)
-	if err != nil {
-		return err
+	app, err := appSync(ctx, acdClient, appName, syncOptions.DryRun, false, syncOptions.Revision, syncOptions.Prune, "",
+		syncOptions.Force, false, appOperationTimeout(ctx), 0, 0, 0, 0, syncOptions.Resources)
+
+	var operationState *argoappv1.OperationState
+	if app != nil {
+		operationState = app.Status.OperationState
 	}
 
-	return nil
+	return operationState, err
 
 }
 
+// appSync returns the Application after the sync operation has completed (if it was waited on), whether or not the
+// operation succeeded.
 func appSync(ctx context.Context, acdClient argocdclient.Client, appName string, dryRun bool, replace bool, revision string, prune bool,
 	strategy string, force bool, async bool, timeout uint, retryLimit int64, retryBackoffDuration time.Duration,
-	retryBackoffMaxDuration time.Duration, retryBackoffFactor int64) error {
+	retryBackoffMaxDuration time.Duration, retryBackoffFactor int64, selectedResources []argoappv1.SyncOperationResource) (*argoappv1.Application, error) {
 
 	conn, appIf, err := acdClient.NewApplicationClient()
 	if err != nil {
-		return fmt.Errorf("unable to retrieve acd client: %v", err)
+		return nil, fmt.Errorf("unable to retrieve acd client: %v", err)
 	}
 	defer argoio.Close(conn)
 
@@ -84,7 +129,7 @@ func appSync(ctx context.Context, acdClient argocdclient.Client, appName string,
 		Name:        &appName,
 		DryRun:      dryRun,
 		Revision:    revision,
-		Resources:   nil,
+		Resources:   selectedResources,
 		Prune:       prune,
 		Manifests:   nil,
 		Infos:       []*argoappv1.Info{},
@@ -99,7 +144,7 @@ func appSync(ctx context.Context, acdClient argocdclient.Client, appName string,
 		syncReq.Strategy = &argoappv1.SyncStrategy{Hook: &argoappv1.SyncStrategyHook{}}
 		syncReq.Strategy.Hook.Force = force
 	default:
-		return fmt.Errorf("unknown sync strategy: '%s'", strategy)
+		return nil, fmt.Errorf("unknown sync strategy: '%s'", strategy)
 	}
 	if retryLimit > 0 {
 		syncReq.RetryStrategy = &argoappv1.RetryStrategy{
@@ -113,29 +158,31 @@ func appSync(ctx context.Context, acdClient argocdclient.Client, appName string,
 	}
 	_, err = appIf.Sync(ctx, &syncReq)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !async {
-		app, err := waitOnApplicationStatus(ctx, acdClient, appName, timeout, false, false, true, false, []argoappv1.SyncOperationResource{})
+		app, err := waitOnApplicationStatus(ctx, acdClient, appName, timeout, false, false, true, false, selectedResources)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if !dryRun {
 			if !app.Status.OperationState.Phase.Successful() {
-				return fmt.Errorf("operation has completed with phase: %s", app.Status.OperationState.Phase)
-			} else if /*len(selectedResources) == 0 &&*/ app.Status.Sync.Status != argoappv1.SyncStatusCodeSynced {
+				return app, fmt.Errorf("operation has completed with phase: %s", app.Status.OperationState.Phase)
+			} else if len(selectedResources) == 0 && app.Status.Sync.Status != argoappv1.SyncStatusCodeSynced {
 				// Only get resources to be pruned if sync was application-wide and final status is not synced
 				pruningRequired := app.Status.OperationState.SyncResult.Resources.PruningRequired()
 				if pruningRequired > 0 {
-					return fmt.Errorf("%d resources require pruning", pruningRequired)
+					return app, fmt.Errorf("%d resources require pruning", pruningRequired)
 				}
 			}
 		}
+
+		return app, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
 // ResourceDiff tracks the state of a resource when waiting on an application status.
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"time"
 
 	applicationpkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
 	"github.com/argoproj/argo-cd/v2/pkg/apiclient/session"
@@ -20,6 +21,26 @@ import (
 )
 
 var _ = Describe("ArgoCD AppSync Command", func() {
+	Context("appOperationTimeout Test", func() {
+		It("should use the default timeout, unless the context has an earlier deadline", func() {
+
+			Expect(appOperationTimeout(context.Background())).To(Equal(uint(defaultAppOperationTimeout / time.Second)))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+			Expect(appOperationTimeout(ctx)).To(And(BeNumerically(">", 100), BeNumerically("<=", 120)))
+
+			ctx, cancel = context.WithTimeout(context.Background(), time.Hour)
+			defer cancel()
+			Expect(appOperationTimeout(ctx)).To(Equal(uint(defaultAppOperationTimeout / time.Second)))
+
+			By("never returning 0, which would wait indefinitely")
+			ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			defer cancel()
+			Expect(appOperationTimeout(ctx)).To(Equal(uint(1)))
+		})
+	})
+
 	Context("ArgoCD AppSync Command Test", func() {
 		It("Synchronize application on the given Argo CD appliatication, in the given namespace.", func() {
 			var err error
@@ -125,8 +146,9 @@ var _ = Describe("ArgoCD AppSync Command", func() {
 			}
 
 			cs := NewCredentialService(&clientGenerator, true)
-			err = AppSync(context.Background(), appName, "master", "openshift-gitops", k8sClient, cs, true)
+			operationState, err := AppSync(context.Background(), appName, AppSyncOptions{Revision: "master"}, "openshift-gitops", k8sClient, cs, true)
 			Expect(err).To(BeNil())
+			Expect(operationState).To(Equal(app.Status.OperationState))
 		})
 	})
 })
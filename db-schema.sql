@@ -34,8 +34,27 @@ CREATE TABLE ClusterCredentials (
 	serviceaccount_bearer_token VARCHAR (2048),
 
 	-- State 2) The namespace of the ServiceAccount
+	-- (empty if the bearer token/client certificate were provided directly by the user, rather than being those of a ServiceAccount installed by the GitOps Service)
 	serviceaccount_ns VARCHAR (128),
 
+	-- State 2) The time at which the ServiceAccount bearer token expires
+	-- (null if the token does not expire, for example a token that was not requested via the TokenRequest API)
+	serviceaccount_bearer_token_expiry TIMESTAMP,
+
+	-- State 2) PEM-encoded client certificate and key, provided by the user to authenticate to the cluster (may be used instead of, or alongside, the bearer token)
+	client_cert_data VARCHAR (16384),
+	client_key_data VARCHAR (16384),
+
+	-- PEM-encoded CA bundle, used to verify the TLS certificate of the API server of the cluster.
+	-- If empty, the system roots of the host are used.
+	ca_bundle VARCHAR (65000),
+
+	-- If true, the TLS certificate of the API server of the cluster will not be verified.
+	allow_insecure_skip_tls_verify BOOLEAN,
+
+	-- Comma-separated list of namespaces that the credentials are limited to: if empty, the credentials are cluster-scoped.
+	namespaces VARCHAR (4096),
+
 	seq_id serial
 );
 
@@ -224,7 +243,7 @@ CREATE TABLE Application (
 	-- '.spec' field of the Application CR
 	-- Note: Rather than converting individual JSON fields into SQL Table fields, we just pull the whole spec field. 
 	-- In the future, it might be beneficial to pull out SOME of the fields, to reduce CPU time spent on json parsing
-	spec_field VARCHAR ( 16384 ) NOT NULL,
+	spec_field VARCHAR ( 65536 ) NOT NULL,
 
 	-- Which Argo CD instance it's hosted on
 	-- Foreign key to: GitopsEngineInstance.gitopsengineinstance_id
@@ -271,7 +290,17 @@ CREATE TABLE ApplicationState (
 	sync_status VARCHAR (30) NOT NULL,
 
 	-- resources field comes directly from Argo CD Application CR's .Status.Resources field
-	resources bytea
+	resources bytea,
+
+	-- operation_state field comes directly from Argo CD Application CR's .Status.OperationState field
+	operation_state bytea,
+
+	-- history field is the sync history of the Application: it is based on Argo CD Application CR's .Status.History
+	-- field, plus the initiator of each sync. It is bounded to the most recent 10 syncs.
+	history bytea,
+
+	-- reconciled_at field comes directly from Argo CD Application CR's .Status.ReconciledAt field
+	reconciled_at TIMESTAMP
 );
 
 -- Represents the relationship from GitOpsDeployment CR in the API namespace, to an Application table row.
@@ -428,6 +457,22 @@ CREATE TABLE SyncOperation (
 	-- values: Running, Terminated
 	desired_state VARCHAR(16) NOT NULL,	
 
+	-- The 'rollbackToHistoryID' field of the GitOpsDeploymentSyncRun CR: if non-null, the SyncOperation is a rollback to
+	-- the entry with this ID in the sync history of the Application.
+	rollback_history_id BIGINT,
+
+	-- The 'prune', 'dryRun' and 'force' fields of the GitOpsDeploymentSyncRun CR
+	prune BOOLEAN,
+	dry_run BOOLEAN,
+	force BOOLEAN,
+
+	-- The 'resources' field of the GitOpsDeploymentSyncRun CR, as JSON: if non-empty, only these resources are synced.
+	resources VARCHAR(16384),
+
+	-- The state of the sync operation: a compressed YAML representation of the Argo CD Application CR's
+	-- .Status.OperationState field, for this sync operation. Set by the cluster-agent.
+	operation_state bytea,
+
 	seq_id serial
 
 );
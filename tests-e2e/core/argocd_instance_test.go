@@ -104,7 +104,7 @@ var _ = Describe("Standalone ArgoCD instance E2E tests", func() {
 			By("calling AppSync and waiting for it to return with no error")
 			Eventually(func() bool {
 				GinkgoWriter.Println("Attempting to sync application: ", app.Name)
-				err := argocdv1.AppSync(context.Background(), app.Name, "", app.Namespace, k8sClient, cs, true)
+				_, err := argocdv1.AppSync(context.Background(), app.Name, argocdv1.AppSyncOptions{}, app.Namespace, k8sClient, cs, true)
 				GinkgoWriter.Println("- AppSync result: ", err)
 				return err == nil
 			}).WithTimeout(time.Minute * 4).WithPolling(time.Second * 1).Should(BeTrue())
@@ -564,6 +564,57 @@ var _ = Describe("GitOpsDeployment E2E tests", func() {
 			expectAllResourcesToBeDeleted(expectedResourceStatusList)
 		})
 
+		It("Checks whether the resources of all the sources of a multi-source GitOpsDeployment are deployed", func() {
+
+			Expect(fixture.EnsureCleanSlate()).To(Succeed())
+
+			By("creating a new GitOpsDeployment resource with multiple sources")
+			gitOpsDeploymentResource := buildGitOpsDeploymentResource("gitops-depl-multi-source",
+				"", "", managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated)
+			gitOpsDeploymentResource.Spec.Sources = []managedgitopsv1alpha1.ApplicationSource{
+				{RepoURL: repoURL, Path: "components/componentA/overlays/staging"},
+				{RepoURL: repoURL, Path: "components/componentB/overlays/staging"},
+			}
+
+			err := k8s.Create(&gitOpsDeploymentResource)
+			Expect(err).To(Succeed())
+
+			By("ensuring the resources of both sources are deployed")
+			expectedResourceStatusList := getResourceStatusList_GitOpsRepositoryTemplateRepo("component-a")
+			expectedResourceStatusList = append(expectedResourceStatusList, getResourceStatusList_GitOpsRepositoryTemplateRepo("component-b")...)
+
+			Eventually(gitOpsDeploymentResource, ArgoCDReconcileWaitTime, "1s").Should(
+				SatisfyAll(
+					gitopsDeplFixture.HaveSpecSources(gitOpsDeploymentResource.Spec.Sources),
+					gitopsDeplFixture.HaveSyncStatusCode(managedgitopsv1alpha1.SyncStatusCodeSynced),
+					gitopsDeplFixture.HaveHealthStatusCode(managedgitopsv1alpha1.HeathStatusCodeHealthy),
+					gitopsDeplFixture.HaveResources(expectedResourceStatusList),
+				),
+			)
+
+			By("removing a source, and ensuring only the resources of the remaining source are deployed")
+			err = k8s.Get(&gitOpsDeploymentResource)
+			Expect(err).To(Succeed())
+
+			gitOpsDeploymentResource.Spec.Sources = gitOpsDeploymentResource.Spec.Sources[:1]
+			err = k8s.Update(&gitOpsDeploymentResource)
+			Expect(err).To(Succeed())
+
+			Eventually(gitOpsDeploymentResource, ArgoCDReconcileWaitTime, "1s").Should(
+				SatisfyAll(
+					gitopsDeplFixture.HaveSpecSources(gitOpsDeploymentResource.Spec.Sources),
+					gitopsDeplFixture.HaveSyncStatusCode(managedgitopsv1alpha1.SyncStatusCodeSynced),
+					gitopsDeplFixture.HaveResources(getResourceStatusList_GitOpsRepositoryTemplateRepo("component-a")),
+				),
+			)
+
+			By("deleting the GitOpsDeployment resource and waiting for the resources to be deleted")
+			err = k8s.Delete(&gitOpsDeploymentResource)
+			Expect(err).To(Succeed())
+
+			expectAllResourcesToBeDeleted(expectedResourceStatusList)
+		})
+
 		It("Checks for failure of deployment when an invalid input is provided", func() {
 
 			Expect(fixture.EnsureCleanSlate()).To(Succeed())
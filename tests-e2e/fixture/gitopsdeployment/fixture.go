@@ -137,3 +137,27 @@ func HaveSpecSource(source managedgitopsv1alpha1.ApplicationSource) matcher.Gome
 		return res
 	}, BeTrue())
 }
+
+// HaveSpecSources checks if the .spec.sources field of GitOpsDeployment matches the expected sources
+func HaveSpecSources(sources []managedgitopsv1alpha1.ApplicationSource) matcher.GomegaMatcher {
+
+	return WithTransform(func(gitopsDepl managedgitopsv1alpha1.GitOpsDeployment) bool {
+
+		k8sClient, err := fixture.GetKubeClient()
+		if err != nil {
+			fmt.Println(k8sFixture.K8sClientError, err)
+			return false
+		}
+
+		err = k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&gitopsDepl), &gitopsDepl)
+		if err != nil {
+			fmt.Println(k8sFixture.K8sClientError, err)
+			return false
+		}
+
+		res := reflect.DeepEqual(sources, gitopsDepl.Spec.Sources)
+		fmt.Println("HaveSpecSources:", res, "/ Expected:", sources, "/ Actual:", gitopsDepl.Spec.Sources)
+
+		return res
+	}, BeTrue())
+}
@@ -0,0 +1 @@
+ALTER TABLE application ALTER COLUMN spec_field TYPE VARCHAR ( 65536 );
@@ -0,0 +1,3 @@
+ALTER TABLE applicationstate DROP COLUMN reconciled_at;
+ALTER TABLE applicationstate DROP COLUMN history;
+ALTER TABLE applicationstate DROP COLUMN operation_state;
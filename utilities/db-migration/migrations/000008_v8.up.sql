@@ -0,0 +1,3 @@
+ALTER TABLE applicationstate ADD COLUMN operation_state bytea;
+ALTER TABLE applicationstate ADD COLUMN history bytea;
+ALTER TABLE applicationstate ADD COLUMN reconciled_at TIMESTAMP;
@@ -0,0 +1 @@
+ALTER TABLE syncoperation DROP COLUMN rollback_history_id;
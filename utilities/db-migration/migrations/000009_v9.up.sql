@@ -0,0 +1 @@
+ALTER TABLE syncoperation ADD COLUMN rollback_history_id BIGINT;
@@ -0,0 +1,5 @@
+ALTER TABLE syncoperation DROP COLUMN operation_state;
+ALTER TABLE syncoperation DROP COLUMN resources;
+ALTER TABLE syncoperation DROP COLUMN force;
+ALTER TABLE syncoperation DROP COLUMN dry_run;
+ALTER TABLE syncoperation DROP COLUMN prune;
@@ -0,0 +1,5 @@
+ALTER TABLE syncoperation ADD COLUMN prune BOOLEAN;
+ALTER TABLE syncoperation ADD COLUMN dry_run BOOLEAN;
+ALTER TABLE syncoperation ADD COLUMN force BOOLEAN;
+ALTER TABLE syncoperation ADD COLUMN resources VARCHAR(16384);
+ALTER TABLE syncoperation ADD COLUMN operation_state bytea;
@@ -0,0 +1,2 @@
+ALTER TABLE clustercredentials DROP COLUMN allow_insecure_skip_tls_verify;
+ALTER TABLE clustercredentials DROP COLUMN ca_bundle;
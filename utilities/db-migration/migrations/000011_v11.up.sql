@@ -0,0 +1,6 @@
+ALTER TABLE clustercredentials ADD COLUMN ca_bundle VARCHAR(65000);
+ALTER TABLE clustercredentials ADD COLUMN allow_insecure_skip_tls_verify BOOLEAN;
+
+-- Cluster credentials created before these columns were added did not verify the TLS certificate of the cluster,
+-- so preserve that behaviour for existing rows.
+UPDATE clustercredentials SET allow_insecure_skip_tls_verify = true;
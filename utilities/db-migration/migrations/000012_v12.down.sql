@@ -0,0 +1 @@
+ALTER TABLE clustercredentials DROP COLUMN namespaces;
@@ -0,0 +1 @@
+ALTER TABLE clustercredentials ADD COLUMN namespaces VARCHAR(4096);
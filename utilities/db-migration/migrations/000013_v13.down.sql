@@ -0,0 +1,2 @@
+ALTER TABLE clustercredentials DROP COLUMN client_key_data;
+ALTER TABLE clustercredentials DROP COLUMN client_cert_data;
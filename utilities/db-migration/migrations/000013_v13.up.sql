@@ -0,0 +1,2 @@
+ALTER TABLE clustercredentials ADD COLUMN client_cert_data VARCHAR(16384);
+ALTER TABLE clustercredentials ADD COLUMN client_key_data VARCHAR(16384);
@@ -0,0 +1 @@
+ALTER TABLE clustercredentials DROP COLUMN serviceaccount_bearer_token_expiry;
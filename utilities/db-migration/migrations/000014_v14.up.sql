@@ -0,0 +1 @@
+ALTER TABLE clustercredentials ADD COLUMN serviceaccount_bearer_token_expiry TIMESTAMP;